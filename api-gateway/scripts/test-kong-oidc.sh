@@ -53,7 +53,7 @@ fi
 # Step 3: Test the API with the access token
 echo -e "\n3. Testing the API with the access token..."
 API_RESPONSE=$(curl -s -X POST \
-  http://$SERVER_IP:30080/analyze \
+  http://$SERVER_IP:30080/v1/analyze \
   -H 'Content-Type: application/json' \
   -H "Authorization: Bearer $ACCESS_TOKEN" \
   -d '{"sentence": "Hello World"}')
@@ -69,7 +69,7 @@ fi
 # Step 4: Test the API without an access token (should fail)
 echo -e "\n4. Testing the API without an access token (should fail)..."
 UNAUTHORIZED_RESPONSE=$(curl -s -o /dev/null -w "%{http_code}" -X POST \
-  http://$SERVER_IP:30080/analyze \
+  http://$SERVER_IP:30080/v1/analyze \
   -H 'Content-Type: application/json' \
   -d '{"sentence": "Hello World"}')
 
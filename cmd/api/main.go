@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
 	"github.com/hc12r/sentence-analyzer-vm/internal/server"
 )
@@ -9,6 +10,7 @@ import (
 func main() {
 	// Setup and run the server using the internal server package
 	if err := server.SetupAndRun(); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		slog.Error("failed to start server", "error", err)
+		os.Exit(1)
 	}
 }
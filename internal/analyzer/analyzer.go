@@ -1,32 +1,47 @@
 package analyzer
 
-import (
-	"strings"
-)
+import "strings"
 
 // AnalyzeSentence counts words, vowels, and consonants in a sentence
 func AnalyzeSentence(sentence string) SentenceAnalysisResult {
-	// Count words
-	words := strings.Fields(sentence)
-	wordCount := len(words)
-
-	// Count vowels and consonants
-	vowelCount := 0
-	consonantCount := 0
+	text := []rune(sentence)
+	result := SentenceAnalysisResult{WordCount: countWords(text)}
+	result.VowelCount, result.ConsonantCount = countLetters(text)
+	return result
+}
 
-	for _, char := range strings.ToLower(sentence) {
-		if char >= 'a' && char <= 'z' {
-			if char == 'a' || char == 'e' || char == 'i' || char == 'o' || char == 'u' {
-				vowelCount++
-			} else {
-				consonantCount++
-			}
+// ClassifyCharacters counts the vowels, consonants and other characters of a text, ignoring case
+// Only ASCII letters are vowels or consonants; every other character, spaces included, is other
+func ClassifyCharacters(text string) (vowels, consonants, other int) {
+	for _, char := range strings.ToLower(text) {
+		switch classify(char) {
+		case vowel:
+			vowels++
+		case consonant:
+			consonants++
+		default:
+			other++
 		}
 	}
+	return vowels, consonants, other
+}
 
-	return SentenceAnalysisResult{
-		WordCount:      wordCount,
-		VowelCount:     vowelCount,
-		ConsonantCount: consonantCount,
+// characterClass is how a lower case character is counted
+type characterClass int
+
+const (
+	other characterClass = iota
+	vowel
+	consonant
+)
+
+// classify returns the class of a lower case character; only ASCII letters are counted
+func classify(char rune) characterClass {
+	if char < 'a' || char > 'z' {
+		return other
+	}
+	if char == 'a' || char == 'e' || char == 'i' || char == 'o' || char == 'u' {
+		return vowel
 	}
+	return consonant
 }
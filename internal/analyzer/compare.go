@@ -0,0 +1,269 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+)
+
+// Operations of a word diff
+const (
+	DiffEqual  = "equal"
+	DiffDelete = "delete"
+	DiffInsert = "insert"
+)
+
+// maxDiffEdits bounds the edits the word diff searches for, keeping its memory to about
+// maxDiffEdits² positions; texts further apart are diffed as one replacement of their differing middles
+const maxDiffEdits = 1000
+
+// DiffWords diffs the words of two texts, as runs of words that are kept, deleted from original
+// or inserted in revised; within each change deletions come before insertions
+// Words are compared exactly, so a change of case or punctuation changes the word
+func DiffWords(ctx context.Context, original, revised string) []DiffOp {
+	_, span := tracing.Start(ctx, "analyzer.diff_words")
+	defer span.End()
+
+	a, b := strings.Fields(original), strings.Fields(revised)
+
+	// The shared start and end are trimmed, as most rewrites leave them alone
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var d diff
+	d.add(DiffEqual, a[:prefix]...)
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if edits, ok := shortestEdits(middleA, middleB, maxDiffEdits); ok {
+		for _, e := range edits {
+			d.add(e.op, e.word)
+		}
+	} else {
+		d.add(DiffDelete, middleA...)
+		d.add(DiffInsert, middleB...)
+	}
+	d.add(DiffEqual, a[len(a)-suffix:]...)
+
+	ops := d.finish()
+	span.SetAttributes(attribute.Int("analyzer.diff_ops", len(ops)))
+	return ops
+}
+
+// EditDistance counts the words substituted, deleted or inserted by a word diff
+// A change deleting d words and inserting i words costs the larger of the two
+func EditDistance(ops []DiffOp) int {
+	distance, deleted, inserted := 0, 0, 0
+	for _, op := range ops {
+		switch op.Op {
+		case DiffDelete:
+			deleted += len(op.Words)
+		case DiffInsert:
+			inserted += len(op.Words)
+		default:
+			distance += max(deleted, inserted)
+			deleted, inserted = 0, 0
+		}
+	}
+	return distance + max(deleted, inserted)
+}
+
+// JaccardSimilarity is the size of the intersection of two texts' sets of words over the size of their union,
+// with words compared as in WordFrequencies; it is 1 when neither text has words
+func JaccardSimilarity(original, revised string) float64 {
+	a, b := wordSet(original), wordSet(revised)
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// CosineSimilarity is the cosine of the angle between the letter frequencies of two texts, ignoring case;
+// it is 1 when neither text has letters and 0 when only one does
+func CosineSimilarity(original, revised string) float64 {
+	a, b := LetterFrequencies(original), LetterFrequencies(revised)
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == len(b) {
+			return 1
+		}
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for letter, count := range a {
+		dot += float64(count * b[letter])
+		normA += float64(count * count)
+	}
+	for _, count := range b {
+		normB += float64(count * count)
+	}
+	// Rounding can take identical distributions just past 1
+	return math.Min(dot/math.Sqrt(normA*normB), 1)
+}
+
+// wordSet returns the distinct words of a text, compared as in WordFrequencies
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, field := range strings.Fields(text) {
+		if word := NormalizeWord(field); word != "" {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// edit is a single word kept, deleted or inserted by a diff
+type edit struct {
+	op   string
+	word string
+}
+
+// shortestEdits finds a shortest script of word deletions and insertions turning a into b
+// with Myers' algorithm, or reports false when it needs more than maxEdits edits
+func shortestEdits(a, b []string, maxEdits int) ([]edit, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+
+	// v holds the furthest x reached on each diagonal k = x - y, at index k+offset
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v for diagonals -d to d after d edits, so the path can be walked back
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(a, b, trace), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrack walks the trace of shortestEdits back from the end of both texts, returning the edits in order
+func backtrack(a, b []string, trace [][]int) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1]
+		at := func(k int) int { return previous[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		// An insertion moves down from the previous diagonal and a deletion right
+		startX := prevX
+		if prevK == k-1 {
+			startX++
+		}
+		// Words matched after the edit
+		for x > startX {
+			x--
+			y--
+			edits = append(edits, edit{op: DiffEqual, word: a[x]})
+		}
+		if prevK == k+1 {
+			edits = append(edits, edit{op: DiffInsert, word: b[prevY]})
+		} else {
+			edits = append(edits, edit{op: DiffDelete, word: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	// Words matched before the first edit
+	for x > 0 {
+		x--
+		edits = append(edits, edit{op: DiffEqual, word: a[x]})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// diff collects the words of a diff into runs, holding back each change's deletions and insertions
+// so the deletions come first
+type diff struct {
+	ops      []DiffOp
+	deleted  []string
+	inserted []string
+}
+
+// add appends words with the given operation
+func (d *diff) add(op string, words ...string) {
+	switch op {
+	case DiffDelete:
+		d.deleted = append(d.deleted, words...)
+	case DiffInsert:
+		d.inserted = append(d.inserted, words...)
+	default:
+		if len(words) == 0 {
+			return
+		}
+		d.flush()
+		if n := len(d.ops); n > 0 && d.ops[n-1].Op == DiffEqual {
+			d.ops[n-1].Words = append(d.ops[n-1].Words, words...)
+			return
+		}
+		// Copied, as the run may grow and words may be part of a longer slice
+		d.ops = append(d.ops, DiffOp{Op: DiffEqual, Words: append([]string(nil), words...)})
+	}
+}
+
+// flush appends the pending change
+func (d *diff) flush() {
+	if len(d.deleted) > 0 {
+		d.ops = append(d.ops, DiffOp{Op: DiffDelete, Words: d.deleted})
+		d.deleted = nil
+	}
+	if len(d.inserted) > 0 {
+		d.ops = append(d.ops, DiffOp{Op: DiffInsert, Words: d.inserted})
+		d.inserted = nil
+	}
+}
+
+// finish appends the pending change and returns the runs, empty rather than nil
+func (d *diff) finish() []DiffOp {
+	d.flush()
+	if d.ops == nil {
+		return []DiffOp{}
+	}
+	return d.ops
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		revised  string
+		want     []DiffOp
+		distance int
+	}{
+		{
+			name:     "identical texts",
+			original: "The quick  brown fox",
+			revised:  "The quick brown fox",
+			want:     []DiffOp{{Op: DiffEqual, Words: []string{"The", "quick", "brown", "fox"}}},
+		},
+		{
+			name:     "empty texts",
+			original: "",
+			revised:  " ",
+			want:     []DiffOp{},
+		},
+		{
+			name:     "word replaced",
+			original: "The quick brown fox jumps",
+			revised:  "The quick red fox jumps",
+			want: []DiffOp{
+				{Op: DiffEqual, Words: []string{"The", "quick"}},
+				{Op: DiffDelete, Words: []string{"brown"}},
+				{Op: DiffInsert, Words: []string{"red"}},
+				{Op: DiffEqual, Words: []string{"fox", "jumps"}},
+			},
+			distance: 1,
+		},
+		{
+			name:     "words inserted and deleted",
+			original: "a b c d e f",
+			revised:  "a x b c e f y",
+			want: []DiffOp{
+				{Op: DiffEqual, Words: []string{"a"}},
+				{Op: DiffInsert, Words: []string{"x"}},
+				{Op: DiffEqual, Words: []string{"b", "c"}},
+				{Op: DiffDelete, Words: []string{"d"}},
+				{Op: DiffEqual, Words: []string{"e", "f"}},
+				{Op: DiffInsert, Words: []string{"y"}},
+			},
+			distance: 3,
+		},
+		{
+			name:     "words moved",
+			original: "one two three",
+			revised:  "three one two",
+			want: []DiffOp{
+				{Op: DiffInsert, Words: []string{"three"}},
+				{Op: DiffEqual, Words: []string{"one", "two"}},
+				{Op: DiffDelete, Words: []string{"three"}},
+			},
+			distance: 2,
+		},
+		{
+			name:     "case and punctuation are compared",
+			original: "Hello world.",
+			revised:  "hello world",
+			want: []DiffOp{
+				{Op: DiffDelete, Words: []string{"Hello", "world."}},
+				{Op: DiffInsert, Words: []string{"hello", "world"}},
+			},
+			distance: 2,
+		},
+		{
+			name:     "text removed",
+			original: "keep this drop that",
+			revised:  "",
+			want:     []DiffOp{{Op: DiffDelete, Words: []string{"keep", "this", "drop", "that"}}},
+			distance: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffWords(context.Background(), tt.original, tt.revised)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffWords() = %v, want %v", got, tt.want)
+			}
+			if distance := EditDistance(got); distance != tt.distance {
+				t.Errorf("EditDistance() = %d, want %d", distance, tt.distance)
+			}
+		})
+	}
+}
+
+func TestDiffWordsFarApart(t *testing.T) {
+	// More edits than maxDiffEdits fall back to replacing the differing middle
+	a := strings.Repeat("a ", maxDiffEdits)
+	b := strings.Repeat("b ", maxDiffEdits)
+	original, revised := "start "+a+"end", "start "+b+"end"
+
+	got := DiffWords(context.Background(), original, revised)
+	var ops []string
+	for _, op := range got {
+		ops = append(ops, op.Op)
+	}
+	want := []string{DiffEqual, DiffDelete, DiffInsert, DiffEqual}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("DiffWords() ops = %v, want %v", ops, want)
+	}
+	if distance := EditDistance(got); distance != maxDiffEdits {
+		t.Errorf("EditDistance() = %d, want %d", distance, maxDiffEdits)
+	}
+}
+
+func TestJaccardSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		revised  string
+		want     float64
+	}{
+		{name: "same words in another order", original: "The cat sat.", revised: "sat, the CAT", want: 1},
+		{name: "half the words shared", original: "red green blue", revised: "red green yellow", want: 0.5},
+		{name: "no words shared", original: "red", revised: "blue", want: 0},
+		{name: "neither has words", original: "...", revised: "", want: 1},
+		{name: "one has no words", original: "red", revised: "!", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JaccardSimilarity(tt.original, tt.revised); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("JaccardSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		revised  string
+		want     float64
+	}{
+		{name: "anagrams", original: "Listen", revised: "silent", want: 1},
+		{name: "no letters shared", original: "abc", revised: "xyz", want: 0},
+		{name: "some letters shared", original: "ab", revised: "bc", want: 0.5},
+		{name: "neither has letters", original: "123", revised: "456", want: 1},
+		{name: "one has no letters", original: "abc", revised: "123", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CosineSimilarity(tt.original, tt.revised); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
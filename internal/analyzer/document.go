@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"errors"
+	"unicode"
+)
+
+// ErrEditOutOfRange is returned for an edit that does not fit inside the document
+var ErrEditOutOfRange = errors.New("edit out of range")
+
+// Document is a text whose analysis is kept up to date as it is edited
+// The text is held in a gap buffer, so an edit only moves the characters between it and the previous edit,
+// and only the changed characters and the words touching them are recounted
+type Document struct {
+	// buf holds the text around a gap, buf[gapStart:gapEnd], where the next edit is made
+	buf      []rune
+	gapStart int
+	gapEnd   int
+	result   SentenceAnalysisResult
+}
+
+// minGap is the smallest gap left after the buffer grows, so typing does not grow it on every keystroke
+const minGap = 64
+
+// NewDocument analyzes text as the starting content of a Document
+func NewDocument(text string) *Document {
+	buf := []rune(text)
+	d := &Document{buf: buf, gapStart: len(buf), gapEnd: len(buf)}
+	d.result.WordCount = countWords(buf)
+	d.result.VowelCount, d.result.ConsonantCount = countLetters(buf)
+	return d
+}
+
+// Edit replaces deleteCount characters starting at offset with insert
+// Offsets and counts are in characters (Unicode code points), not bytes
+func (d *Document) Edit(offset, deleteCount int, insert string) error {
+	if offset < 0 || deleteCount < 0 || offset+deleteCount > d.Len() {
+		return ErrEditOutOfRange
+	}
+	inserted := []rune(insert)
+
+	// Words can only change between the whitespace on either side of the edit,
+	// so only that region is recounted
+	start, stop := offset, offset+deleteCount
+	for start > 0 && !unicode.IsSpace(d.at(start-1)) {
+		start--
+	}
+	for stop < d.Len() && !unicode.IsSpace(d.at(stop)) {
+		stop++
+	}
+	wordsBefore := d.countWords(start, stop)
+
+	// The deleted characters follow the gap once it is at offset, and are dropped by widening it
+	d.moveGap(offset)
+	vowelsRemoved, consonantsRemoved := countLetters(d.buf[d.gapEnd : d.gapEnd+deleteCount])
+	d.gapEnd += deleteCount
+
+	d.grow(len(inserted))
+	copy(d.buf[d.gapStart:], inserted)
+	d.gapStart += len(inserted)
+	vowelsAdded, consonantsAdded := countLetters(inserted)
+
+	wordsAfter := d.countWords(start, stop-deleteCount+len(inserted))
+
+	d.result.WordCount += wordsAfter - wordsBefore
+	d.result.VowelCount += vowelsAdded - vowelsRemoved
+	d.result.ConsonantCount += consonantsAdded - consonantsRemoved
+	return nil
+}
+
+// Result returns the analysis of the current text
+func (d *Document) Result() SentenceAnalysisResult {
+	return d.result
+}
+
+// Len returns the length of the text in characters
+func (d *Document) Len() int {
+	return len(d.buf) - (d.gapEnd - d.gapStart)
+}
+
+// String returns the current text
+func (d *Document) String() string {
+	text := make([]rune, 0, d.Len())
+	text = append(text, d.buf[:d.gapStart]...)
+	text = append(text, d.buf[d.gapEnd:]...)
+	return string(text)
+}
+
+// at returns the character at offset i of the text
+func (d *Document) at(i int) rune {
+	if i < d.gapStart {
+		return d.buf[i]
+	}
+	return d.buf[i+d.gapEnd-d.gapStart]
+}
+
+// countWords counts the words of the text between offsets start and stop, as countWords does
+func (d *Document) countWords(start, stop int) int {
+	words, inWord := 0, false
+	for i := start; i < stop; i++ {
+		if unicode.IsSpace(d.at(i)) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+// moveGap moves the gap to offset by shifting the characters between them across it
+func (d *Document) moveGap(offset int) {
+	switch {
+	case offset < d.gapStart:
+		n := d.gapStart - offset
+		copy(d.buf[d.gapEnd-n:d.gapEnd], d.buf[offset:d.gapStart])
+		d.gapStart -= n
+		d.gapEnd -= n
+	case offset > d.gapStart:
+		n := offset - d.gapStart
+		copy(d.buf[d.gapStart:], d.buf[d.gapEnd:d.gapEnd+n])
+		d.gapStart += n
+		d.gapEnd += n
+	}
+}
+
+// grow makes room for at least n characters in the gap, doubling the buffer so growth is amortized
+func (d *Document) grow(n int) {
+	if d.gapEnd-d.gapStart >= n {
+		return
+	}
+	size := max(2*len(d.buf), d.Len()+n+minGap)
+	buf := make([]rune, size)
+	copy(buf, d.buf[:d.gapStart])
+	tail := len(d.buf) - d.gapEnd
+	copy(buf[size-tail:], d.buf[d.gapEnd:])
+	d.buf = buf
+	d.gapEnd = size - tail
+}
+
+// countWords counts whitespace separated words as strings.Fields does
+func countWords(text []rune) int {
+	words, inWord := 0, false
+	for _, char := range text {
+		if unicode.IsSpace(char) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+// countLetters counts vowels and consonants as AnalyzeSentence does
+func countLetters(text []rune) (vowels, consonants int) {
+	for _, char := range text {
+		switch classify(unicode.ToLower(char)) {
+		case vowel:
+			vowels++
+		case consonant:
+			consonants++
+		}
+	}
+	return vowels, consonants
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDocumentEdit(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		offset      int
+		deleteCount int
+		insert      string
+		want        string
+	}{
+		{"append a word", "Hello", 5, 0, " World", "Hello World"},
+		{"join two words", "Hello World", 5, 1, "", "HelloWorld"},
+		{"split a word", "HelloWorld", 5, 0, " ", "Hello World"},
+		{"replace inside a word", "The cat sat", 5, 1, "o", "The cot sat"},
+		{"delete everything", "The cat sat", 0, 11, "", ""},
+		{"insert into empty document", "", 0, 0, "New text here", "New text here"},
+		{"multi-byte characters", "héllo wörld", 1, 1, "e", "hello wörld"},
+		{"newlines are whitespace", "one two", 3, 1, "\n", "one\ntwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := NewDocument(tt.text)
+			if err := doc.Edit(tt.offset, tt.deleteCount, tt.insert); err != nil {
+				t.Fatalf("Edit() error = %v", err)
+			}
+			if doc.String() != tt.want {
+				t.Errorf("Expected text %q, got %q", tt.want, doc.String())
+			}
+			if want := AnalyzeSentence(tt.want); doc.Result() != want {
+				t.Errorf("Expected %+v, got %+v", want, doc.Result())
+			}
+		})
+	}
+}
+
+func TestDocumentEditOutOfRange(t *testing.T) {
+	doc := NewDocument("abc")
+
+	for _, edit := range [][2]int{{-1, 0}, {0, -1}, {4, 0}, {2, 2}} {
+		if err := doc.Edit(edit[0], edit[1], "x"); err != ErrEditOutOfRange {
+			t.Errorf("Edit(%d, %d) error = %v, want %v", edit[0], edit[1], err, ErrEditOutOfRange)
+		}
+	}
+	if doc.String() != "abc" {
+		t.Errorf("Expected a rejected edit to leave the text unchanged, got %q", doc.String())
+	}
+}
+
+// TestDocumentMatchesAnalyzeSentence applies random edits and checks the incremental
+// result against a full analysis after each one
+func TestDocumentMatchesAnalyzeSentence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	alphabet := []rune("aeiou bcdfg XYZ\t\n.,!é字")
+
+	randomText := func(n int) string {
+		text := make([]rune, n)
+		for i := range text {
+			text[i] = alphabet[random.Intn(len(alphabet))]
+		}
+		return string(text)
+	}
+
+	// text is edited by copying, alongside the document's gap buffer
+	text := []rune(randomText(20))
+	doc := NewDocument(string(text))
+	for i := 0; i < 2000; i++ {
+		offset := random.Intn(doc.Len() + 1)
+		deleteCount := random.Intn(doc.Len() - offset + 1)
+		if deleteCount > 5 {
+			deleteCount = 5
+		}
+		insert := randomText(random.Intn(6))
+		if err := doc.Edit(offset, deleteCount, insert); err != nil {
+			t.Fatalf("edit %d: unexpected error %v", i, err)
+		}
+		text = append(text[:offset:offset], append([]rune(insert), text[offset+deleteCount:]...)...)
+		if doc.String() != string(text) || doc.Len() != len(text) {
+			t.Fatalf("edit %d: expected text %q, got %q", i, string(text), doc.String())
+		}
+
+		if want := AnalyzeSentence(doc.String()); doc.Result() != want {
+			t.Fatalf("edit %d: expected %+v, got %+v for %q", i, want, doc.Result(), doc.String())
+		}
+	}
+}
@@ -6,3 +6,15 @@ type SentenceAnalysisResult struct {
 	VowelCount     int
 	ConsonantCount int
 }
+
+// WordFrequency represents how often a word occurs in a text
+type WordFrequency struct {
+	Word  string
+	Count int
+}
+
+// DiffOp is a run of words kept, deleted or inserted by a word diff
+type DiffOp struct {
+	Op    string
+	Words []string
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Syllables estimates the syllables of an English word as its groups of vowels, y included,
+// less a silent final e; a word with letters has at least one and a word without has none
+func Syllables(word string) int {
+	var letters []rune
+	for _, char := range strings.ToLower(word) {
+		if unicode.IsLetter(char) {
+			letters = append(letters, char)
+		}
+	}
+	if len(letters) == 0 {
+		return 0
+	}
+
+	groups := 0
+	inGroup := false
+	for _, char := range letters {
+		isVowel := strings.ContainsRune("aeiouy", char)
+		if isVowel && !inGroup {
+			groups++
+		}
+		inGroup = isVowel
+	}
+
+	// "make" has one syllable but "table" two
+	n := len(letters)
+	if groups > 1 && letters[n-1] == 'e' && !(n >= 3 && letters[n-2] == 'l' && !strings.ContainsRune("aeiouy", letters[n-3])) {
+		groups--
+	}
+	return max(groups, 1)
+}
+
+// ReadingEase is the Flesch reading ease of a text with the given counts: about 100 for text a young child
+// reads easily, 60 to 70 for plain English and below 30 for academic prose; it is 0 for a text without words
+func ReadingEase(words, sentences, syllables int) float64 {
+	if words == 0 || sentences == 0 {
+		return 0
+	}
+	return 206.835 - 1.015*float64(words)/float64(sentences) - 84.6*float64(syllables)/float64(words)
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{word: "cat", want: 1},
+		{word: "The", want: 1},
+		{word: "make", want: 1},
+		{word: "whale", want: 1},
+		{word: "table", want: 2},
+		{word: "apple,", want: 2},
+		{word: "rhythm", want: 1},
+		{word: "beautiful", want: 3},
+		{word: "readability", want: 5},
+		{word: "42", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Syllables(tt.word); got != tt.want {
+				t.Errorf("Syllables(%q) = %d, want %d", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingEase(t *testing.T) {
+	tests := []struct {
+		name      string
+		words     int
+		sentences int
+		syllables int
+		want      float64
+	}{
+		{name: "short words and sentence", words: 3, sentences: 1, syllables: 3, want: 119.19},
+		{name: "long words and sentences", words: 40, sentences: 2, syllables: 80, want: 17.335},
+		{name: "no words", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadingEase(tt.words, tt.sentences, tt.syllables); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ReadingEase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+)
+
+// WordFrequencies counts each distinct word in a text, most frequent first
+// Words are compared case-insensitively with leading and trailing punctuation removed
+func WordFrequencies(ctx context.Context, text string) []WordFrequency {
+	_, span := tracing.Start(ctx, "analyzer.word_frequencies")
+	defer span.End()
+
+	counts := make(map[string]int)
+	for _, field := range strings.Fields(text) {
+		if word := NormalizeWord(field); word != "" {
+			counts[word]++
+		}
+	}
+
+	frequencies := make([]WordFrequency, 0, len(counts))
+	for word, count := range counts {
+		frequencies = append(frequencies, WordFrequency{Word: word, Count: count})
+	}
+	// Ties are broken alphabetically so the order is stable
+	sort.Slice(frequencies, func(i, j int) bool {
+		if frequencies[i].Count != frequencies[j].Count {
+			return frequencies[i].Count > frequencies[j].Count
+		}
+		return frequencies[i].Word < frequencies[j].Word
+	})
+
+	span.SetAttributes(attribute.Int("analyzer.distinct_words", len(frequencies)))
+	return frequencies
+}
+
+// LetterFrequencies counts each letter of a text, ignoring case
+func LetterFrequencies(text string) map[rune]int {
+	counts := make(map[rune]int)
+	for _, char := range text {
+		if unicode.IsLetter(char) {
+			counts[unicode.ToLower(char)]++
+		}
+	}
+	return counts
+}
+
+// NormalizeWord lowercases a whitespace-separated field and trims its leading and trailing punctuation
+func NormalizeWord(field string) string {
+	return strings.ToLower(strings.TrimFunc(field, isPunctuation))
+}
+
+// SplitSentences splits a text into sentences ending in '.', '!' or '?'
+// A terminator only ends a sentence when followed by whitespace or the end of the text,
+// so numbers such as "3.14" stay intact; any trailing text without a terminator is the last sentence
+func SplitSentences(ctx context.Context, text string) []string {
+	_, span := tracing.Start(ctx, "analyzer.split_sentences")
+	defer span.End()
+
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		if !isTerminator(r) {
+			continue
+		}
+		// Keep runs of terminators such as "?!" or "..." with their sentence
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+
+	span.SetAttributes(attribute.Int("analyzer.sentence_count", len(sentences)))
+	return sentences
+}
+
+func isTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?'
+}
+
+func isPunctuation(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWordFrequencies(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []WordFrequency
+	}{
+		{
+			name: "empty string",
+			text: "",
+			want: []WordFrequency{},
+		},
+		{
+			name: "case and punctuation are ignored",
+			text: "The cat saw the dog. The end!",
+			want: []WordFrequency{
+				{Word: "the", Count: 3},
+				{Word: "cat", Count: 1},
+				{Word: "dog", Count: 1},
+				{Word: "end", Count: 1},
+				{Word: "saw", Count: 1},
+			},
+		},
+		{
+			name: "punctuation only fields are skipped",
+			text: "wait - what ?",
+			want: []WordFrequency{
+				{Word: "wait", Count: 1},
+				{Word: "what", Count: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordFrequencies(context.Background(), tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WordFrequencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLetterFrequencies(t *testing.T) {
+	got := LetterFrequencies("Añ a, 1 Ñ!")
+	want := map[rune]int{'a': 2, 'ñ': 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LetterFrequencies() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty string", "", nil},
+		{"single sentence without terminator", "Hello world", []string{"Hello world"}},
+		{"several sentences", "Hello world. How are you? Fine!", []string{"Hello world.", "How are you?", "Fine!"}},
+		{"decimal numbers stay intact", "Pi is 3.14. Roughly.", []string{"Pi is 3.14.", "Roughly."}},
+		{"repeated terminators", "Really?! Yes... ok", []string{"Really?!", "Yes...", "ok"}},
+		{"surrounding whitespace", "  One.\n\nTwo.  ", []string{"One.", "Two."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSentences(context.Background(), tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
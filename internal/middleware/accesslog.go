@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+)
+
+// accessLogEntry collects request details that are only known further down the chain
+type accessLogEntry struct {
+	userID string
+}
+
+// accessLogKey is the key used to store the accessLogEntry in the context
+const accessLogKey contextKey = "access_log"
+
+// Context key type to avoid collisions
+type contextKey string
+
+// AccessLog middleware that logs one line per request with status, size, latency and user ID
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &accessLogEntry{}
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessLogKey, entry)))
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(r.Context(), level, "request completed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int("bytes", recorder.bytes),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", auth.ClientIP(r)),
+			slog.String("user_id", entry.userID),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// setAccessLogUser records the authenticated user for the access log line, if one is being written
+func setAccessLogUser(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessLogKey).(*accessLogEntry); ok {
+		entry.userID = userID
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+)
+
+func TestAccessLog(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json", "info"))
+	defer slog.SetDefault(original)
+
+	handler := RequestID(AccessLog(JWTAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})))
+
+	req, _ := http.NewRequest(http.MethodPost, "/analyze", nil)
+	req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "log-user", []string{"user"}))
+	req.Header.Set(logging.RequestIDHeader, "req-access-log")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to unmarshal access log line %q: %v", buf.String(), err)
+	}
+
+	want := map[string]interface{}{
+		"msg":        "request completed",
+		"method":     "POST",
+		"path":       "/analyze",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"user_id":    "log-user",
+		"request_id": "req-access-log",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, record[key])
+		}
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("Expected a latency attribute")
+	}
+}
+
+// TestAccessLogHijack tests that handlers behind the access log can take over the connection
+func TestAccessLogHijack(t *testing.T) {
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json", "info"))
+	defer slog.SetDefault(original)
+
+	server := httptest.NewServer(AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Failed to hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected status %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to unmarshal access log line %q: %v", buf.String(), err)
+	}
+	if record["status"] != float64(http.StatusSwitchingProtocols) {
+		t.Errorf("Expected status %d in the access log, got %v", http.StatusSwitchingProtocols, record["status"])
+	}
+}
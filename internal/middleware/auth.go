@@ -1,10 +1,17 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
 )
 
 // NoAuth middleware that simply passes through all requests
@@ -17,7 +24,7 @@ func NoAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// JWTAuth middleware that validates JWT tokens
+// JWTAuth middleware that validates JWT tokens or API keys sent in the X-API-Key header
 func JWTAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Skip authentication for OPTIONS requests (for CORS)
@@ -27,24 +34,41 @@ func JWTAuth(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Extract and validate the token
+		_, span := tracing.Start(r.Context(), "auth.validate")
 		authInfo, err := auth.GetAuthInfoFromRequest(r)
 		if err != nil {
-			log.Printf("Authentication error: %v", err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetAttributes(attribute.Bool("auth.api_key", authInfo.APIKeyID != ""))
+		}
+		span.End()
+		if err != nil {
+			// Failures are counted in metrics; only log them when debugging
+			slog.DebugContext(r.Context(), "authentication failed", "error", err)
+
+			metrics.AuthFailuresTotal.WithLabelValues(authFailureReason(err)).Inc()
 
 			switch err {
 			case auth.ErrNoToken:
-				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
 			case auth.ErrExpiredToken:
-				http.Error(w, "Token has expired", http.StatusUnauthorized)
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeExpiredToken, "Token has expired")
 			case auth.ErrInvalidToken:
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidToken, "Invalid token")
+			case auth.ErrInvalidAPIKey:
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidAPIKey, "Invalid API key")
+			case auth.ErrExpiredAPIKey:
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeExpiredAPIKey, "API key has expired")
+			case auth.ErrRevokedAPIKey:
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeRevokedAPIKey, "API key has been revoked")
 			default:
-				http.Error(w, "Authentication error", http.StatusInternalServerError)
+				problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Authentication error")
 			}
 			return
 		}
 
 		// Store auth info in the request context for later use
+		setAccessLogUser(r.Context(), authInfo.UserID)
 		ctx := r.Context()
 		ctx = auth.WithAuthInfo(ctx, authInfo)
 		r = r.WithContext(ctx)
@@ -53,3 +77,82 @@ func JWTAuth(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+// WebSocketToken middleware that lets browsers, which cannot set headers on a WebSocket handshake,
+// send their bearer token as a "bearer.<token>" subprotocol
+// It must be chained before JWTAuth; an Authorization header takes precedence
+func WebSocketToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+				for _, protocol := range strings.Split(value, ",") {
+					if token, ok := strings.CutPrefix(strings.TrimSpace(protocol), "bearer."); ok {
+						r.Header.Set("Authorization", "Bearer "+token)
+						break
+					}
+				}
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// QueryToken middleware that lets browsers, whose EventSource cannot set headers, send their
+// bearer token as the access_token query parameter (RFC 6750)
+// It must be chained before JWTAuth; an Authorization header takes precedence. The parameter is
+// removed from the request so it is not passed on, and only the path is logged and traced
+func QueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del("access_token")
+			r.URL.RawQuery = query.Encode()
+		}
+
+		next(w, r)
+	}
+}
+
+// RequireRole middleware that only lets through requests whose AuthInfo has the given role
+// It must be chained after JWTAuth so the AuthInfo is present in the context
+func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authInfo, ok := auth.GetAuthInfo(r.Context())
+		if !ok {
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+			return
+		}
+
+		if !authInfo.HasRole(role) {
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.ReasonForbidden).Inc()
+			problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "Forbidden")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authFailureReason maps an authentication error to its metrics label
+func authFailureReason(err error) string {
+	switch err {
+	case auth.ErrNoToken:
+		return metrics.ReasonNoToken
+	case auth.ErrExpiredToken:
+		return metrics.ReasonExpiredToken
+	case auth.ErrInvalidToken:
+		return metrics.ReasonInvalidToken
+	case auth.ErrInvalidAPIKey:
+		return metrics.ReasonInvalidAPIKey
+	case auth.ErrExpiredAPIKey:
+		return metrics.ReasonExpiredAPIKey
+	case auth.ErrRevokedAPIKey:
+		return metrics.ReasonRevokedAPIKey
+	default:
+		return metrics.ReasonError
+	}
+}
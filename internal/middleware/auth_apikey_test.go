@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+)
+
+func TestJWTAuthWithAPIKey(t *testing.T) {
+	original := auth.GetAPIKeyStore()
+	auth.SetAPIKeyStore(auth.NewMemoryAPIKeyStore())
+	defer auth.SetAPIKeyStore(original)
+
+	validKey, _, err := auth.IssueAPIKey("valid", []string{"user"}, time.Hour, auth.Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	revokedKey, revoked, err := auth.IssueAPIKey("revoked", []string{"user"}, 0, auth.Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	auth.RevokeAPIKey(revoked.ID)
+
+	// Create a handler that echoes the authenticated API key name
+	handler := JWTAuth(func(w http.ResponseWriter, r *http.Request) {
+		authInfo, ok := auth.GetAuthInfo(r.Context())
+		if !ok {
+			t.Error("Expected auth info in context, got none")
+			return
+		}
+		w.Write([]byte(authInfo.APIKeyID))
+	})
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		wantStatusCode int
+		wantCode       string
+	}{
+		{"valid key", validKey, http.StatusOK, ""},
+		{"unknown key", "sak_unknown", http.StatusUnauthorized, problem.CodeInvalidAPIKey},
+		{"revoked key", revokedKey, http.StatusUnauthorized, problem.CodeRevokedAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+			if tt.wantCode != "" {
+				assertProblem(t, rr, tt.wantCode)
+			}
+			if tt.wantStatusCode == http.StatusOK && rr.Body.Len() == 0 {
+				t.Error("Expected the API key ID in the response body")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	handler := JWTAuth(RequireRole("admin", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name           string
+		roles          []string
+		wantStatusCode int
+	}{
+		{"admin role", []string{"user", "admin"}, http.StatusOK},
+		{"missing role", []string{"user"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/admin", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+generateTestToken(t, "test-user", tt.roles))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
 )
 
@@ -41,6 +43,7 @@ func TestJWTAuth(t *testing.T) {
 		method         string
 		wantStatusCode int
 		wantBody       string
+		wantCode       string
 	}{
 		{
 			name:           "valid token",
@@ -54,14 +57,14 @@ func TestJWTAuth(t *testing.T) {
 			token:          "",
 			method:         http.MethodGet,
 			wantStatusCode: http.StatusUnauthorized,
-			wantBody:       "Authentication required\n",
+			wantCode:       problem.CodeNoToken,
 		},
 		{
 			name:           "invalid token",
 			token:          "invalid-token",
 			method:         http.MethodGet,
 			wantStatusCode: http.StatusUnauthorized,
-			wantBody:       "Invalid token\n",
+			wantCode:       problem.CodeInvalidToken,
 		},
 		{
 			name:           "options method bypasses auth",
@@ -96,14 +99,36 @@ func TestJWTAuth(t *testing.T) {
 				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
 			}
 
-			// Check response body
-			if rr.Body.String() != tt.wantBody {
+			// Check response body; errors are problem+json documents
+			if tt.wantCode != "" {
+				assertProblem(t, rr, tt.wantCode)
+			} else if rr.Body.String() != tt.wantBody {
 				t.Errorf("handler returned unexpected body: got %q want %q", rr.Body.String(), tt.wantBody)
 			}
 		})
 	}
 }
 
+// Helper function to check that the response is a problem+json document with the given code
+func assertProblem(t *testing.T, rr *httptest.ResponseRecorder, wantCode string) {
+	t.Helper()
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != problem.ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", problem.ContentType, contentType)
+	}
+
+	var p problem.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to unmarshal problem: %v", err)
+	}
+	if p.Code != wantCode {
+		t.Errorf("Expected problem code %s, got %s", wantCode, p.Code)
+	}
+	if p.Status != rr.Code {
+		t.Errorf("Expected problem status %d to match response status %d", p.Status, rr.Code)
+	}
+}
+
 // Helper function to generate a test token
 func generateTestToken(t *testing.T, userID string, roles []string) string {
 	token, err := auth.GenerateToken(userID, roles)
@@ -123,3 +123,69 @@ func TestNoAuthWithCustomHeaders(t *testing.T) {
 		t.Errorf("NoAuth middleware returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 }
+
+func TestWebSocketToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		wantHeader string
+	}{
+		{"token subprotocol", http.Header{"Sec-Websocket-Protocol": {"analysis.v1, bearer.abc.def"}}, "Bearer abc.def"},
+		{"authorization header wins", http.Header{"Authorization": {"Bearer header"}, "Sec-Websocket-Protocol": {"bearer.sub"}}, "Bearer header"},
+		{"no token", http.Header{"Sec-Websocket-Protocol": {"analysis.v1"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := WebSocketToken(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/analyze/live", nil)
+			req.Header = tt.header
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.wantHeader {
+				t.Errorf("Expected Authorization %q, got %q", tt.wantHeader, got)
+			}
+		})
+	}
+}
+
+func TestQueryToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		wantHeader string
+		wantQuery  string
+	}{
+		{"token parameter", "/analyze/jobs/1/events?access_token=abc.def&last_event_id=2", "", "Bearer abc.def", "last_event_id=2"},
+		{"authorization header wins", "/analyze/jobs/1/events?access_token=query", "Bearer header", "Bearer header", ""},
+		{"no token", "/analyze/jobs/1/events?last_event_id=2", "", "", "last_event_id=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader, gotQuery string
+			handler := QueryToken(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get("Authorization")
+				gotQuery = r.URL.RawQuery
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotHeader != tt.wantHeader {
+				t.Errorf("Expected Authorization %q, got %q", tt.wantHeader, gotHeader)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("Expected query %q, got %q", tt.wantQuery, gotQuery)
+			}
+		})
+	}
+}
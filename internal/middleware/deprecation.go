@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// Deprecated middleware that marks the responses of a deprecated route with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and links to the same path under successorPrefix
+func Deprecated(cfg config.APIConfig, successorPrefix string, next http.HandlerFunc) http.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", cfg.DeprecatedAt.Unix())
+	var sunset string
+	if !cfg.SunsetAt.IsZero() {
+		sunset = cfg.SunsetAt.UTC().Format(http.TimeFormat)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		if sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successorPrefix, r.URL.Path))
+
+		next(w, r)
+	}
+}
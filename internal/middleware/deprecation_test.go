@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+func TestDeprecated(t *testing.T) {
+	deprecatedAt := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		cfg        config.APIConfig
+		wantSunset string
+	}{
+		{name: "with sunset", cfg: config.APIConfig{DeprecatedAt: deprecatedAt, SunsetAt: sunsetAt}, wantSunset: "Mon, 19 Apr 2027 00:00:00 GMT"},
+		{name: "without sunset", cfg: config.APIConfig{DeprecatedAt: deprecatedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := Deprecated(tt.cfg, "/v1", func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/analyze", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if !called {
+				t.Fatal("Expected the deprecated route to still be served")
+			}
+			if got := rr.Header().Get("Deprecation"); got != "@1792368000" {
+				t.Errorf("Expected Deprecation @1792368000, got %q", got)
+			}
+			if got := rr.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Expected Sunset %q, got %q", tt.wantSunset, got)
+			}
+			if got := rr.Header().Get("Link"); got != `</v1/analyze>; rel="successor-version"` {
+				t.Errorf("Expected a successor link to /v1/analyze, got %q", got)
+			}
+		})
+	}
+}
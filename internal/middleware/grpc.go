@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// apiKeyMetadata is the gRPC metadata key carrying an API key; metadata keys are lower case
+var apiKeyMetadata = strings.ToLower(auth.APIKeyHeader)
+
+// publicGRPCServices are served without authentication so probes and tooling work
+var publicGRPCServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// GRPCUnaryAuth interceptor that authenticates unary calls with the same JWTs and API keys as JWTAuth
+func GRPCUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isPublicGRPCMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	ctx, err := authenticateGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// GRPCStreamAuth interceptor that authenticates streaming calls with the same JWTs and API keys as JWTAuth
+func GRPCStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isPublicGRPCMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	ctx, err := authenticateGRPC(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticateGRPC validates the credentials in the call metadata and stores the AuthInfo in the context
+func authenticateGRPC(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	authInfo, err := auth.Authenticate(firstMetadata(md, "authorization"), firstMetadata(md, apiKeyMetadata))
+	if err != nil {
+		slog.DebugContext(ctx, "authentication failed", "error", err)
+		metrics.AuthFailuresTotal.WithLabelValues(authFailureReason(err)).Inc()
+
+		switch err {
+		case auth.ErrNoToken:
+			return nil, status.Error(codes.Unauthenticated, "Authentication required")
+		case auth.ErrExpiredToken:
+			return nil, status.Error(codes.Unauthenticated, "Token has expired")
+		case auth.ErrInvalidToken:
+			return nil, status.Error(codes.Unauthenticated, "Invalid token")
+		case auth.ErrInvalidAPIKey:
+			return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+		case auth.ErrExpiredAPIKey:
+			return nil, status.Error(codes.Unauthenticated, "API key has expired")
+		case auth.ErrRevokedAPIKey:
+			return nil, status.Error(codes.Unauthenticated, "API key has been revoked")
+		default:
+			return nil, status.Error(codes.Internal, "Authentication error")
+		}
+	}
+
+	return auth.WithAuthInfo(ctx, authInfo), nil
+}
+
+// GRPCUnaryInterceptor returns an interceptor that applies the rate limits of Limit to unary calls
+// It shares its budget with the HTTP routes and must be chained after GRPCUnaryAuth
+func (rl *RateLimiter) GRPCUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isPublicGRPCMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := rl.limitGRPC(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// GRPCStreamInterceptor returns an interceptor that counts each streaming call as one request
+// Characters are still charged per message by the service
+func (rl *RateLimiter) GRPCStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicGRPCMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := rl.limitGRPC(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// limitGRPC counts a call against the client's request budget and adds its character quota to the context
+func (rl *RateLimiter) limitGRPC(ctx context.Context) (context.Context, error) {
+	if !rl.config.Enabled {
+		return ctx, nil
+	}
+
+	authInfo, _ := auth.GetAuthInfo(ctx)
+	key := clientKey(authInfo, peerIP(ctx))
+	rpm, burst := rl.requestLimit(authInfo)
+
+	decision := rl.limiter.Allow(key, rpm, burst)
+	if !decision.Allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ceilSeconds(decision.RetryAfter))))
+		return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+	}
+
+	quota := ratelimit.NewQuota(rl.limiter, key, rl.dailyCharacters(authInfo))
+	return ratelimit.WithQuota(ctx, quota), nil
+}
+
+// serverStream replaces the context of a grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the replaced context
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func isPublicGRPCMethod(fullMethod string) bool {
+	for _, prefix := range publicGRPCServices {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP returns the IP address of the gRPC client
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
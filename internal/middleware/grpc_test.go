@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+func TestGRPCUnaryAuth(t *testing.T) {
+	original := auth.GetAPIKeyStore()
+	auth.SetAPIKeyStore(auth.NewMemoryAPIKeyStore())
+	defer auth.SetAPIKeyStore(original)
+
+	token, err := auth.GenerateToken("grpc-user", []string{"user"})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	apiKey, _, err := auth.IssueAPIKey("grpc", []string{"user"}, time.Hour, auth.Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+
+	// The handler echoes the authenticated user or API key
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		authInfo, ok := auth.GetAuthInfo(ctx)
+		if !ok {
+			return "", nil
+		}
+		return authInfo.UserID + authInfo.APIKeyID, nil
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		md       metadata.MD
+		wantCode codes.Code
+		wantAuth bool
+	}{
+		{"no credentials", "/sentenceanalyzer.v1.AnalyzerService/Analyze", nil, codes.Unauthenticated, false},
+		{"valid token", "/sentenceanalyzer.v1.AnalyzerService/Analyze", metadata.Pairs("authorization", "Bearer "+token), codes.OK, true},
+		{"invalid token", "/sentenceanalyzer.v1.AnalyzerService/Analyze", metadata.Pairs("authorization", "Bearer invalid"), codes.Unauthenticated, false},
+		{"valid API key", "/sentenceanalyzer.v1.AnalyzerService/Analyze", metadata.Pairs("x-api-key", apiKey), codes.OK, true},
+		{"unknown API key", "/sentenceanalyzer.v1.AnalyzerService/Analyze", metadata.Pairs("x-api-key", "sak_unknown"), codes.Unauthenticated, false},
+		{"health check is public", "/grpc.health.v1.Health/Check", nil, codes.OK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			resp, err := GRPCUnaryAuth(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("Expected code %v, got %v (%v)", tt.wantCode, code, err)
+			}
+			if tt.wantAuth && resp == "" {
+				t.Error("Expected auth info in the handler context")
+			}
+		})
+	}
+}
+
+func TestGRPCStreamAuth(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+	info := &grpc.StreamServerInfo{FullMethod: "/sentenceanalyzer.v1.AnalyzerService/AnalyzeStream"}
+
+	called := false
+	err := GRPCStreamAuth(nil, &serverStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+	if called {
+		t.Error("Expected the handler not to be called without credentials")
+	}
+}
+
+func TestRateLimiterGRPCUnaryInterceptor(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 60,
+		Burst:             2,
+		DailyCharacters:   100,
+	})
+	interceptor := limiter.GRPCUnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/sentenceanalyzer.v1.AnalyzerService/Analyze"}
+
+	// The handler reports whether a quota was added to the context by charging past it
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, err := ratelimit.ChargeCharacters(ctx, 101)
+		return err != nil, nil
+	}
+
+	call := func(ip string) (interface{}, error) {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1000}})
+		return interceptor(ctx, nil, info, handler)
+	}
+
+	for i := 0; i < 2; i++ {
+		hasQuota, err := call("192.0.2.1")
+		if err != nil {
+			t.Fatalf("call %d: unexpected error %v", i+1, err)
+		}
+		if hasQuota != true {
+			t.Errorf("call %d: expected a quota in the context", i+1)
+		}
+	}
+
+	if _, err := call("192.0.2.1"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got %v", err)
+	}
+
+	// A different client IP has its own budget
+	if _, err := call("192.0.2.2"); err != nil {
+		t.Errorf("Expected another client to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+)
+
+// RecordHistory middleware that lets handlers record the authenticated client's analyses in their history
+// It should be chained after JWTAuth and wrap every route that analyzes text; nothing is recorded when history is disabled
+func RecordHistory(cfg config.HistoryConfig, next http.HandlerFunc) http.HandlerFunc {
+	if !cfg.Enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(withHistoryRecorder(r.Context(), cfg)))
+	}
+}
+
+// GRPCUnaryHistory returns an interceptor that lets unary calls record the client's analyses, as RecordHistory does
+// It must be chained after GRPCUnaryAuth
+func GRPCUnaryHistory(cfg config.HistoryConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !cfg.Enabled {
+			return handler(ctx, req)
+		}
+		return handler(withHistoryRecorder(ctx, cfg), req)
+	}
+}
+
+// GRPCStreamHistory returns an interceptor that lets streaming calls record the client's analyses, as RecordHistory does
+// It must be chained after GRPCStreamAuth
+func GRPCStreamHistory(cfg config.HistoryConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !cfg.Enabled {
+			return handler(srv, ss)
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withHistoryRecorder(ss.Context(), cfg)})
+	}
+}
+
+// withHistoryRecorder adds a Recorder for the authenticated client to the context
+// Unauthenticated calls, such as health checks, get none and are not recorded
+func withHistoryRecorder(ctx context.Context, cfg config.HistoryConfig) context.Context {
+	authInfo, ok := auth.GetAuthInfo(ctx)
+	if !ok {
+		return ctx
+	}
+	return history.WithRecorder(ctx, history.NewRecorder(authInfo.UserID, cfg.RetentionDays, cfg.MaxEntries))
+}
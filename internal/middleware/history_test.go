@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+)
+
+func TestRecordHistory(t *testing.T) {
+	original := history.GetStore()
+	defer history.SetStore(original)
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		userID      string
+		wantEntries int
+	}{
+		{name: "authenticated", enabled: true, userID: "alice", wantEntries: 1},
+		{name: "anonymous", enabled: true},
+		{name: "disabled", userID: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history.SetStore(history.NewMemoryStore())
+			cfg := config.HistoryConfig{Enabled: tt.enabled, RetentionDays: 30, MaxEntries: 10}
+			handler := RecordHistory(cfg, func(w http.ResponseWriter, r *http.Request) {
+				if err := history.Record(r.Context(), domain.HistoryEntry{Preview: "One."}); err != nil {
+					t.Errorf("Record() error = %v", err)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/analyze", nil)
+			if tt.userID != "" {
+				req = req.WithContext(auth.WithAuthInfo(req.Context(), &auth.AuthInfo{UserID: tt.userID}))
+			}
+			handler(httptest.NewRecorder(), req)
+
+			l, _ := history.GetStore().Get("alice")
+			if len(l.Entries) != tt.wantEntries {
+				t.Errorf("Expected %d recorded entries, got %d", tt.wantEntries, len(l.Entries))
+			}
+		})
+	}
+}
+
+func TestGRPCHistory(t *testing.T) {
+	original := history.GetStore()
+	defer history.SetStore(original)
+	history.SetStore(history.NewMemoryStore())
+
+	cfg := config.HistoryConfig{Enabled: true, RetentionDays: 30, MaxEntries: 10}
+	ctx := auth.WithAuthInfo(context.Background(), &auth.AuthInfo{UserID: "alice"})
+	record := func(ctx context.Context) {
+		if err := history.Record(ctx, domain.HistoryEntry{Preview: "One."}); err != nil {
+			t.Errorf("Record() error = %v", err)
+		}
+	}
+
+	unary := GRPCUnaryHistory(cfg)
+	if _, err := unary(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		record(ctx)
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("unary interceptor error = %v", err)
+	}
+	stream := GRPCStreamHistory(cfg)
+	if err := stream(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		record(ss.Context())
+		return nil
+	}); err != nil {
+		t.Fatalf("stream interceptor error = %v", err)
+	}
+
+	if l, _ := history.GetStore().Get("alice"); len(l.Entries) != 2 {
+		t.Errorf("Expected 2 recorded entries, got %d", len(l.Entries))
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+)
+
+// Instrument middleware that records request count, latency and in-flight requests for a route
+// route should be the registered pattern, not the request path, to keep label cardinality bounded
+func Instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	inFlight := metrics.RequestsInFlight.WithLabelValues(route)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		recorder := newResponseRecorder(w)
+
+		next(recorder, r)
+
+		status := strconv.Itoa(recorder.status)
+		method := methodLabel(r.Method)
+		metrics.RequestsTotal.WithLabelValues(route, method, status).Inc()
+		metrics.RequestDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// methodLabel returns the request method for metrics and spans, bounding the label values
+// Clients may send any token as a method, so methods other than the standard ones are reported as "other"
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+)
+
+func TestInstrument(t *testing.T) {
+	route := "/instrument-test"
+	counter := metrics.RequestsTotal.WithLabelValues(route, http.MethodPost, "418")
+	before := testutil.ToFloat64(counter)
+
+	handler := Instrument(route, func(w http.ResponseWriter, r *http.Request) {
+		// The in-flight gauge counts this request while it is being served
+		if inFlight := testutil.ToFloat64(metrics.RequestsInFlight.WithLabelValues(route)); inFlight != 1 {
+			t.Errorf("Expected 1 request in flight, got %v", inFlight)
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, route, nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Expected the request counter to increase by 1, got %v", got)
+	}
+	if inFlight := testutil.ToFloat64(metrics.RequestsInFlight.WithLabelValues(route)); inFlight != 0 {
+		t.Errorf("Expected 0 requests in flight after completion, got %v", inFlight)
+	}
+}
+
+func TestInstrumentBoundsMethods(t *testing.T) {
+	route := "/instrument-methods-test"
+	counter := metrics.RequestsTotal.WithLabelValues(route, "other", "200")
+	before := testutil.ToFloat64(counter)
+
+	handler := Instrument(route, func(w http.ResponseWriter, r *http.Request) {})
+	for _, method := range []string{"FOO", "BAR1", "PROPFIND"} {
+		req, _ := http.NewRequest(method, route, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 3 {
+		t.Errorf("Expected nonstandard methods to be counted as other, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.RequestsTotal.WithLabelValues(route, "FOO", "200")); got != 0 {
+		t.Errorf("Expected no series for a nonstandard method, got %v", got)
+	}
+}
+
+func TestJWTAuthRecordsFailureReason(t *testing.T) {
+	counter := metrics.AuthFailuresTotal.WithLabelValues(metrics.ReasonNoToken)
+	before := testutil.ToFloat64(counter)
+
+	handler := JWTAuth(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called without a token")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Expected the no_token failure counter to increase by 1, got %v", got)
+	}
+}
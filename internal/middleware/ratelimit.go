@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// RateLimiter middleware factory that applies per-client token bucket limits and daily character quotas
+type RateLimiter struct {
+	config  config.RateLimitConfig
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimiter creates a RateLimiter from the rate limit configuration
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:  cfg,
+		limiter: ratelimit.NewLimiter(),
+	}
+}
+
+// Limit middleware that rate limits requests per client
+// Clients are identified by API key, then user ID, then client IP, so it should be chained
+// after JWTAuth on authenticated routes
+func (rl *RateLimiter) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rl.config.Enabled || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		authInfo, _ := auth.GetAuthInfo(r.Context())
+		key := clientKey(authInfo, auth.ClientIP(r))
+		rpm, burst := rl.requestLimit(authInfo)
+
+		decision := rl.limiter.Allow(key, rpm, burst)
+
+		// Standard RateLimit-* headers (IETF draft-ietf-httpapi-ratelimit-headers)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=60;burst=%d", rpm, burst))
+
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeRateLimited, "Rate limit exceeded")
+			return
+		}
+
+		// Make the daily character quota available to handlers
+		quota := ratelimit.NewQuota(rl.limiter, key, rl.dailyCharacters(authInfo))
+		r = r.WithContext(ratelimit.WithQuota(r.Context(), quota))
+
+		next(w, r)
+	}
+}
+
+// requestLimit returns the requests per minute and burst for the client
+// A per-key quota wins over role limits; otherwise the most generous role applies
+func (rl *RateLimiter) requestLimit(authInfo *auth.AuthInfo) (int, int) {
+	rpm := rl.config.RequestsPerMinute
+	if authInfo != nil {
+		if authInfo.Quota.RequestsPerMinute > 0 {
+			rpm = authInfo.Quota.RequestsPerMinute
+		} else {
+			roleLimit := 0
+			for _, role := range authInfo.Roles {
+				if limit, ok := rl.config.RoleRequestsPerMinute[role]; ok && limit > roleLimit {
+					roleLimit = limit
+				}
+			}
+			if roleLimit > 0 {
+				rpm = roleLimit
+			}
+		}
+	}
+
+	burst := rl.config.Burst
+	if burst <= 0 {
+		burst = rpm
+	}
+	return rpm, burst
+}
+
+// dailyCharacters returns the daily character quota for the client, 0 meaning unlimited
+func (rl *RateLimiter) dailyCharacters(authInfo *auth.AuthInfo) int {
+	if authInfo == nil {
+		return rl.config.DailyCharacters
+	}
+	if authInfo.Quota.DailyCharacters > 0 {
+		return authInfo.Quota.DailyCharacters
+	}
+
+	limit, matched := 0, false
+	for _, role := range authInfo.Roles {
+		roleLimit, ok := rl.config.RoleDailyCharacters[role]
+		if !ok {
+			continue
+		}
+		if roleLimit == 0 {
+			return 0
+		}
+		if !matched || roleLimit > limit {
+			limit, matched = roleLimit, true
+		}
+	}
+	if matched {
+		return limit
+	}
+	return rl.config.DailyCharacters
+}
+
+// clientKey identifies the client a request is counted against
+func clientKey(authInfo *auth.AuthInfo, ip string) string {
+	switch {
+	case authInfo != nil && authInfo.APIKeyID != "":
+		return "key:" + authInfo.APIKeyID
+	case authInfo != nil && authInfo.UserID != "":
+		return "user:" + authInfo.UserID
+	default:
+		return "ip:" + ip
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+func TestRateLimiterLimit(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 60,
+		Burst:             2,
+	})
+
+	handler := limiter.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		rr := send("192.0.2.1:1000")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+		if rr.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("Expected RateLimit-Limit 2, got %q", rr.Header().Get("RateLimit-Limit"))
+		}
+	}
+
+	rr := send("192.0.2.1:1001")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Expected RateLimit-Remaining 0, got %q", rr.Header().Get("RateLimit-Remaining"))
+	}
+
+	// A different client IP has its own budget
+	if rr := send("192.0.2.2:1000"); rr.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %v", rr.Code)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{Enabled: false, RequestsPerMinute: 1, Burst: 1})
+
+	handler := limiter.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestRateLimiterLimits(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		Enabled:               true,
+		RequestsPerMinute:     60,
+		RoleRequestsPerMinute: map[string]int{"admin": 600, "user": 30},
+		DailyCharacters:       1000,
+		RoleDailyCharacters:   map[string]int{"admin": 0, "user": 5000},
+	})
+
+	tests := []struct {
+		name      string
+		authInfo  *auth.AuthInfo
+		wantRPM   int
+		wantChars int
+	}{
+		{"anonymous", nil, 60, 1000},
+		{"user role", &auth.AuthInfo{UserID: "u", Roles: []string{"user"}}, 30, 5000},
+		{"most generous role wins", &auth.AuthInfo{UserID: "a", Roles: []string{"user", "admin"}}, 600, 0},
+		{"unknown role", &auth.AuthInfo{UserID: "g", Roles: []string{"guest"}}, 60, 1000},
+		{"api key quota", &auth.AuthInfo{APIKeyID: "k", Roles: []string{"user"}, Quota: auth.Quota{RequestsPerMinute: 5, DailyCharacters: 50}}, 5, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpm, burst := limiter.requestLimit(tt.authInfo)
+			if rpm != tt.wantRPM || burst != tt.wantRPM {
+				t.Errorf("requestLimit = %d, %d, want %d, %d", rpm, burst, tt.wantRPM, tt.wantRPM)
+			}
+			if chars := limiter.dailyCharacters(tt.authInfo); chars != tt.wantChars {
+				t.Errorf("dailyCharacters = %d, want %d", chars, tt.wantChars)
+			}
+		})
+	}
+}
+
+func TestRateLimiterQuotaInContext(t *testing.T) {
+	limiter := NewRateLimiter(config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 60,
+		DailyCharacters:   10,
+	})
+
+	handler := limiter.Limit(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ratelimit.ChargeCharacters(r.Context(), 6); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	codes := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, want := range codes {
+		req, _ := http.NewRequest(http.MethodPost, "/analyze", nil)
+		req = req.WithContext(auth.WithAuthInfo(req.Context(), &auth.AuthInfo{UserID: "quota-user"}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("request %d: got status %v want %v", i+1, rr.Code, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+)
+
+// maxRequestIDLength bounds incoming request IDs so clients cannot flood the logs
+const maxRequestIDLength = 128
+
+// RequestID middleware that propagates the X-Request-ID header, generating one if missing or invalid
+// The ID is stored in the request context and echoed in the response headers
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+			r.Header.Set(logging.RequestIDHeader, requestID)
+		}
+
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		r = r.WithContext(logging.WithRequestID(r.Context(), requestID))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID accepts IDs made of printable ASCII without spaces, such as UUIDs or Kong correlation IDs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random version 4 UUID
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
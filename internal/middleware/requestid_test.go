@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"propagates incoming ID", "kong-correlation-123", true},
+		{"generates missing ID", "", false},
+		{"replaces ID with spaces", "bad id", false},
+		{"replaces overlong ID", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logging.RequestID(r.Context())
+			}))
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			responseID := rr.Header().Get(logging.RequestIDHeader)
+			if responseID != seen {
+				t.Errorf("Expected response header %q to match context ID %q", responseID, seen)
+			}
+			if tt.wantSame && seen != tt.incoming {
+				t.Errorf("Expected incoming ID %q to be propagated, got %q", tt.incoming, seen)
+			}
+			if !tt.wantSame && !uuidPattern.MatchString(seen) {
+				t.Errorf("Expected a generated UUID, got %q", seen)
+			}
+		})
+	}
+}
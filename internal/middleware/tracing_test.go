@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	originalProvider := otel.GetTracerProvider()
+	originalPropagator := otel.GetTextMapPropagator()
+	defer otel.SetTracerProvider(originalProvider)
+	defer otel.SetTextMapPropagator(originalPropagator)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantError   bool
+	}{
+		{"new trace", "", http.StatusOK, false},
+		{"continued trace", "00-" + parentTraceID + "-00f067aa0ba902b7-01", http.StatusOK, false},
+		{"server error", "", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Trace("/analyze", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/analyze", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+
+			if span.Name() != "POST /analyze" {
+				t.Errorf("Expected span name %q, got %q", "POST /analyze", span.Name())
+			}
+			if tt.traceparent != "" && span.SpanContext().TraceID().String() != parentTraceID {
+				t.Errorf("Expected trace ID %s, got %s", parentTraceID, span.SpanContext().TraceID())
+			}
+			if gotError := span.Status().Code == codes.Error; gotError != tt.wantError {
+				t.Errorf("Expected error status %v, got %v", tt.wantError, gotError)
+			}
+		})
+	}
+}
+
+func TestTraceBoundsMethods(t *testing.T) {
+	originalProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(originalProvider)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	handler := Trace("/analyze", func(w http.ResponseWriter, r *http.Request) {})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/analyze", nil))
+
+	spans := recorder.Ended()
+	if name := spans[len(spans)-1].Name(); name != "other /analyze" {
+		t.Errorf("Expected span name %q, got %q", "other /analyze", name)
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/hc12r/sentence-analyzer-vm/internal/middleware"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/analyzerpb"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/rpc"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// grpcServer is the gRPC server together with its health service
+type grpcServer struct {
+	*grpc.Server
+	health *grpchealth.Server
+}
+
+// newGRPCServer creates the gRPC server with authentication, rate limiting, history, health and reflection
+// limiter should be the one used by the HTTP routes so both APIs share a client's budget
+func newGRPCServer(cfg config.Config, limiter *middleware.RateLimiter) *grpcServer {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.GRPCUnaryAuth, limiter.GRPCUnaryInterceptor(), middleware.GRPCUnaryHistory(cfg.History)),
+		grpc.ChainStreamInterceptor(middleware.GRPCStreamAuth, limiter.GRPCStreamInterceptor(), middleware.GRPCStreamHistory(cfg.History)),
+		grpc.MaxRecvMsgSize(int(cfg.Input.MaxBodyBytes)),
+	)
+
+	analyzerpb.RegisterAnalyzerServiceServer(s, rpc.NewAnalyzerServer(cfg.Input))
+
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus(analyzerpb.AnalyzerService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	reflection.Register(s)
+
+	return &grpcServer{Server: s, health: healthServer}
+}
+
+// stop waits for in-flight calls to finish, cancelling them when ctx is done
+func (s *grpcServer) stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/hc12r/sentence-analyzer-vm/internal/middleware"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/analyzerpb"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// TestGRPCServer tests that health checks are public, analysis requires credentials
+// and the health service reports NOT_SERVING once draining
+func TestGRPCServer(t *testing.T) {
+	cfg := config.LoadConfig()
+	srv := newGRPCServer(cfg, middleware.NewRateLimiter(cfg.RateLimit))
+
+	listener := bufconn.Listen(1 << 20)
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	healthClient := healthpb.NewHealthClient(conn)
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: analyzerpb.AnalyzerService_ServiceDesc.ServiceName,
+		})
+		if err != nil {
+			t.Fatalf("Health check failed: %v", err)
+		}
+		return resp.GetStatus()
+	}
+
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING, got %v", got)
+	}
+
+	_, err = analyzerpb.NewAnalyzerServiceClient(conn).Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Sentence: "Hello"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+
+	srv.health.Shutdown()
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING after shutdown, got %v", got)
+	}
+}
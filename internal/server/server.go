@@ -1,41 +1,404 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/hc12r/sentence-analyzer-vm/internal/middleware"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/api/handlers"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/corpus"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/docs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/health"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/jobs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
 )
 
+// routes holds the patterns registered by the last call to SetupRoutes
+var routes []string
+
+// handle registers a handler on the default mux, traced and instrumented under its route pattern
+func handle(pattern string, handler http.HandlerFunc) {
+	routes = append(routes, pattern)
+	http.HandleFunc(pattern, middleware.Trace(pattern, middleware.Instrument(pattern, handler)))
+}
+
+// Routes returns the route patterns registered by SetupRoutes
+func Routes() []string {
+	return append([]string(nil), routes...)
+}
+
 // SetupRoutes configures all the routes for the HTTP server
-func SetupRoutes() {
-	// Register login endpoint without authentication
-	http.HandleFunc("/login", handlers.HandleLogin)
+func SetupRoutes(cfg config.Config) {
+	setupRoutes(cfg, middleware.NewRateLimiter(cfg.RateLimit))
+}
+
+// API version prefixes
+const (
+	v1Prefix = "/v1"
+	v2Prefix = "/v2"
+)
+
+// setupRoutes configures the routes with the given rate limiter
+// The limiter is shared by all limited routes, and with the gRPC server, so a client has one budget
+func setupRoutes(cfg config.Config, limiter *middleware.RateLimiter) {
+	routes = nil
+
+	// handleV1 registers a route of the v1 API under /v1 and, as a deprecated alias, unversioned
+	handleV1 := func(pattern string, handler http.HandlerFunc) {
+		handle(v1Prefix+pattern, handler)
+		handle(pattern, middleware.Deprecated(cfg.API, v1Prefix, handler))
+	}
+
+	// Register login endpoint without authentication, rate limited per client IP
+	handleV1("/login", limiter.Limit(handlers.LoginHandler(cfg.Login)))
+
+	// Register handlers with JWT authentication, rate limited per authenticated client
+	handleV1("/analyze", middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.AnalyzeSentenceHandler(cfg.Input)))))
+	if cfg.WebSocket.Enabled {
+		handleV1("/analyze/live", middleware.WebSocketToken(middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.LiveAnalysisHandler(cfg.Input, cfg.WebSocket))))))
+	}
+	if cfg.Jobs.Enabled {
+		handleV1(handlers.JobsPath, middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.AnalysisJobsHandler(cfg.Input, cfg.Jobs)))))
+		handleV1(handlers.JobsPath+"/", middleware.QueryToken(middleware.JWTAuth(limiter.Limit(handlers.AnalysisJobEventsHandler(cfg.Jobs)))))
+	}
+
+	// Endpoints added after versioning are only served under /v1, with no unversioned alias
+	handle(v1Prefix+handlers.ComparePath, middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.CompareHandler(cfg.Input)))))
+	if cfg.Upload.Enabled {
+		handle(v1Prefix+handlers.DocumentsPath, middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.DocumentAnalysisHandler(cfg.Upload)))))
+	}
+	if cfg.Corpus.Enabled {
+		handle(v1Prefix+handlers.CorporaPath, middleware.JWTAuth(limiter.Limit(handlers.HandleCorpora)))
+		handle(v1Prefix+handlers.CorporaPath+"/", middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.CorpusHandler(cfg.Input, cfg.Corpus)))))
+	}
+	if cfg.History.Enabled {
+		handle(v1Prefix+handlers.HistoryPath, middleware.JWTAuth(limiter.Limit(handlers.HistoryHandler(cfg.History))))
+		handle(v1Prefix+handlers.HistoryPath+"/", middleware.JWTAuth(limiter.Limit(handlers.HistoryEntryHandler(cfg.History))))
+		handle(v1Prefix+handlers.HistoryRetentionPath, middleware.JWTAuth(limiter.Limit(handlers.HistoryRetentionHandler(cfg.Input, cfg.History))))
+	}
 
-	// Register handlers with JWT authentication
-	http.HandleFunc("/analyze", middleware.JWTAuth(handlers.HandleAnalyzeSentence))
+	// Register API key administration endpoints, restricted to the admin role
+	handleV1(handlers.APIKeysPath, middleware.JWTAuth(middleware.RequireRole("admin", handlers.HandleAPIKeys)))
+	handleV1(handlers.APIKeysPath+"/", middleware.JWTAuth(middleware.RequireRole("admin", handlers.HandleAPIKey)))
 
-	// Register health endpoint without authentication
-	http.HandleFunc("/health", handlers.HandleHealth)
+	// Register the v2 API, which only holds the endpoints whose responses changed since v1
+	handle(v2Prefix+"/analyze", middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.AnalyzeSentenceV2Handler(cfg.Input)))))
 
-	// Register Swagger documentation endpoints
-	http.HandleFunc("/swagger", docs.HandleSwaggerUI)
-	http.HandleFunc("/swagger/openapi.yaml", docs.HandleSwaggerYAML)
+	// Register GraphQL, which evolves through its schema rather than by version
+	if cfg.GraphQL.Enabled {
+		handle("/graphql", middleware.JWTAuth(limiter.Limit(middleware.RecordHistory(cfg.History, handlers.GraphQLHandler(cfg.Input, cfg.GraphQL)))))
+	}
+
+	// Register health endpoints without authentication
+	handle("/health", handlers.HandleHealth)
+	handle("/livez", handlers.HandleLivez)
+	handle("/readyz", handlers.HandleReadyz)
+
+	// Register version endpoint without authentication
+	handle("/version", handlers.HandleVersion)
+
+	// Register Prometheus metrics endpoint without authentication so the cluster can scrape it
+	handle("/metrics", metrics.HandleMetrics)
+
+	// Register Swagger documentation endpoints unless disabled
+	if cfg.Docs.Enabled {
+		handle("/swagger", docs.HandleSwaggerUI)
+		handle("/swagger/openapi.yaml", docs.HandleSwaggerYAML)
+		handle(docs.AssetsPath, docs.HandleSwaggerAssets)
+	}
+}
+
+// registerHealthChecks registers the readiness checks for the service's dependencies
+func registerHealthChecks(cfg config.Config) {
+	health.Register("config", func(context.Context) error { return cfg.Validate() })
+	health.Register("signing_key", func(context.Context) error { return auth.CheckSigningKey() })
+	health.Register("apikey_store", func(context.Context) error { return auth.CheckAPIKeyStore() })
+	health.Register("login_attempt_store", func(context.Context) error { return auth.CheckLoginAttemptStore() })
+	if cfg.Jobs.Enabled {
+		health.Register("job_store", func(context.Context) error { return jobs.CheckStore() })
+	}
+	if cfg.Corpus.Enabled {
+		health.Register("corpus_store", func(context.Context) error { return corpus.CheckStore() })
+	}
+	if cfg.History.Enabled {
+		health.Register("history_store", func(context.Context) error { return history.CheckStore() })
+	}
+}
+
+// enabledFeatures lists the optional feature modules turned on by the configuration
+func enabledFeatures(cfg config.Config) []string {
+	features := []string{"api_keys", "metrics"}
+	if cfg.Docs.Enabled {
+		features = append(features, "docs")
+	}
+	if cfg.RateLimit.Enabled {
+		features = append(features, "rate_limit")
+	}
+	if cfg.Tracing.Endpoint != "" {
+		features = append(features, "tracing")
+	}
+	if cfg.GRPC.Enabled {
+		features = append(features, "grpc")
+	}
+	if cfg.GraphQL.Enabled {
+		features = append(features, "graphql")
+	}
+	if cfg.WebSocket.Enabled {
+		features = append(features, "websocket")
+	}
+	if cfg.Jobs.Enabled {
+		features = append(features, "sse")
+	}
+	if cfg.Upload.Enabled {
+		features = append(features, "uploads")
+	}
+	if cfg.Corpus.Enabled {
+		features = append(features, "corpora")
+	}
+	if cfg.History.Enabled {
+		features = append(features, "history")
+	}
+	if cfg.Rules.File != "" {
+		features = append(features, "custom_rules")
+	}
+	return features
 }
 
 // SetupAndRun configures and starts the HTTP server
+// On SIGINT or SIGTERM it fails readiness, waits for the drain delay and then shuts down gracefully
 func SetupAndRun() error {
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Setup routes
-	SetupRoutes()
+	// Setup structured logging
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level))
+
+	// Setup tracing; spans are only exported when a collector endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	// Publish build information
+	version.SetFeatures(enabledFeatures(cfg))
+	info := version.Get()
+	metrics.SetBuildInfo(info)
+
+	// Take login client IPs from X-Forwarded-For only behind a trusted proxy
+	auth.SetTrustProxyHeaders(cfg.Login.TrustProxyHeaders)
+
+	// Keep API keys on disk unless no directory is configured
+	if cfg.APIKeys.Dir != "" {
+		store, err := auth.NewFileAPIKeyStore(cfg.APIKeys.Dir)
+		if err != nil {
+			return fmt.Errorf("opening API key store: %w", err)
+		}
+		auth.SetAPIKeyStore(store)
+	}
+
+	// Keep corpora on disk unless no directory is configured
+	if cfg.Corpus.Enabled && cfg.Corpus.Dir != "" {
+		store, err := corpus.NewFileStore(cfg.Corpus.Dir)
+		if err != nil {
+			return fmt.Errorf("opening corpus store: %w", err)
+		}
+		corpus.SetStore(store)
+	}
+
+	// Keep histories on disk unless no directory is configured
+	if cfg.History.Enabled && cfg.History.Dir != "" {
+		store, err := history.NewFileStore(cfg.History.Dir)
+		if err != nil {
+			return fmt.Errorf("opening history store: %w", err)
+		}
+		history.SetStore(store)
+	}
+
+	// Compile the custom counting rules before serving, so invalid rules stop the server from starting
+	var rulesReloader *rules.Reloader
+	if cfg.Rules.File != "" {
+		rulesReloader = rules.NewReloader(cfg.Rules.File)
+		if _, err := rulesReloader.Reload(); err != nil {
+			return fmt.Errorf("loading counting rules: %w", err)
+		}
+		slog.Info("counting rules loaded", "file", cfg.Rules.File, "rules", rules.Active().Len())
+	}
+
+	// Setup routes and readiness checks
+	limiter := middleware.NewRateLimiter(cfg.RateLimit)
+	setupRoutes(cfg, limiter)
+	registerHealthChecks(cfg)
+
+	// Every request gets a request ID and an access log line
+	// Slow clients cannot hold connections open by trickling in their headers or body
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           middleware.RequestID(middleware.AccessLog(http.DefaultServeMux)),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+	}
+	// Hijacked WebSocket connections are not closed by Shutdown, so tell their clients to go away
+	srv.RegisterOnShutdown(handlers.CloseLiveSessions)
+	// Event streams end once their job does, so stop the jobs still running
+	srv.RegisterOnShutdown(handlers.CancelAnalysisJobs)
 
 	// Start server
-	port := fmt.Sprintf(":%d", cfg.Port)
-	fmt.Printf("Server starting on port %s...\n", port)
-	return http.ListenAndServe(port, nil)
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("server starting",
+			"port", cfg.Port,
+			"version", info.Version,
+			"commit", info.Commit,
+			"build_time", info.BuildTime,
+			"go_version", info.GoVersion,
+			"features", info.Features,
+		)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	// Start the gRPC server on its own port, sharing the HTTP rate limits
+	var grpcSrv *grpcServer
+	if cfg.GRPC.Enabled {
+		grpcSrv = newGRPCServer(cfg, limiter)
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+		if err != nil {
+			// The HTTP server is already serving, so stop it rather than leave it running after Start returns
+			srv.Close()
+			return fmt.Errorf("listening for grpc: %w", err)
+		}
+		go func() {
+			slog.Info("grpc server starting", "port", cfg.GRPC.Port)
+			errCh <- grpcSrv.Serve(listener)
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Drop finished jobs once their events can no longer be replayed, even when no new jobs are started
+	if cfg.Jobs.Enabled {
+		go pruneJobs(ctx, cfg.Jobs, jobsPruneInterval)
+	}
+
+	// Drop expired analyses even of clients that no longer make requests
+	if cfg.History.Enabled {
+		go pruneHistory(ctx, cfg.History, historyPruneInterval)
+	}
+
+	// Reload the counting rules when the file changes or on SIGHUP
+	if rulesReloader != nil {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+		go reloadRules(ctx, rulesReloader, cfg.Rules.ReloadInterval, hangup)
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	return shutdown(srv, grpcSrv, cfg.Shutdown)
+}
+
+// jobsPruneInterval is how often finished jobs past their retention are dropped
+const jobsPruneInterval = time.Minute
+
+// pruneJobs drops the jobs that finished more than the retention ago every interval until ctx is done
+func pruneJobs(ctx context.Context, cfg config.JobsConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := jobs.GetStore().Prune(time.Now().Add(-cfg.Retention)); err != nil {
+			slog.ErrorContext(ctx, "error pruning jobs", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// historyPruneInterval is how often expired analyses are dropped from every client's history
+const historyPruneInterval = time.Hour
+
+// pruneHistory drops the analyses past their client's retention every interval until ctx is done
+func pruneHistory(ctx context.Context, cfg config.HistoryConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := history.Prune(time.Now().UTC(), cfg.RetentionDays); err != nil {
+			slog.ErrorContext(ctx, "error pruning history", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reloadRules reloads the counting rules every interval, if positive, and on each hangup until ctx is done
+// Invalid rules are logged and the active rules kept
+func reloadRules(ctx context.Context, reloader *rules.Reloader, interval time.Duration, hangup <-chan os.Signal) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-hangup:
+		}
+		changed, err := reloader.Reload()
+		if err != nil {
+			slog.ErrorContext(ctx, "error reloading counting rules, keeping the active rules", "error", err)
+		} else if changed {
+			slog.InfoContext(ctx, "counting rules reloaded", "rules", rules.Active().Len())
+		}
+	}
+}
+
+// shutdown drains srv and grpcSrv, if set: readiness fails first so the pod leaves the
+// load balancer, then in-flight requests and calls are given until the timeout to finish
+func shutdown(srv *http.Server, grpcSrv *grpcServer, cfg config.ShutdownConfig) error {
+	health.SetDraining(true)
+	if grpcSrv != nil {
+		grpcSrv.health.Shutdown()
+	}
+	slog.Info("server draining", "drain_delay", cfg.DrainDelay)
+	time.Sleep(cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	slog.Info("server shutting down")
+	if grpcSrv != nil {
+		grpcSrv.stop(ctx)
+	}
+	return srv.Shutdown(ctx)
 }
@@ -1,10 +1,27 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/docs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/health"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/jobs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
 )
 
 // TestSetupRoutes tests that all routes are registered correctly
@@ -21,7 +38,7 @@ func TestSetupRoutes(t *testing.T) {
 	}()
 
 	// Call the function under test
-	SetupRoutes()
+	SetupRoutes(config.LoadConfig())
 
 	// Get all registered routes
 	registeredRoutes := make(map[string]bool)
@@ -41,10 +58,37 @@ func TestSetupRoutes(t *testing.T) {
 		// If we can't access the map (Go version changes, etc.),
 		// at least check that the routes respond to requests
 		expectedRoutes := []string{
+			"/login",
+			"/v1/login",
 			"/analyze",
+			"/v1/analyze",
+			"/v2/analyze",
+			"/analyze/live",
+			"/v1/analyze/live",
+			"/analyze/jobs",
+			"/v1/analyze/jobs",
+			"/analyze/jobs/",
+			"/v1/analyze/jobs/",
+			"/v1/analyze/documents",
+			"/v1/compare",
+			"/v1/corpora",
+			"/v1/corpora/",
+			"/v1/history",
+			"/v1/history/",
+			"/v1/history/retention",
+			"/graphql",
+			"/admin/apikeys",
+			"/v1/admin/apikeys",
+			"/admin/apikeys/",
+			"/v1/admin/apikeys/",
 			"/health",
+			"/livez",
+			"/readyz",
+			"/version",
+			"/metrics",
 			"/swagger",
 			"/swagger/openapi.yaml",
+			"/swagger/assets/",
 		}
 
 		for _, route := range expectedRoutes {
@@ -66,10 +110,37 @@ func TestSetupRoutes(t *testing.T) {
 
 	// Check that all expected routes were registered
 	expectedRoutes := []string{
+		"/login",
+		"/v1/login",
 		"/analyze",
+		"/v1/analyze",
+		"/v2/analyze",
+		"/analyze/live",
+		"/v1/analyze/live",
+		"/analyze/jobs",
+		"/v1/analyze/jobs",
+		"/analyze/jobs/",
+		"/v1/analyze/jobs/",
+		"/v1/analyze/documents",
+		"/v1/compare",
+		"/v1/corpora",
+		"/v1/corpora/",
+		"/v1/history",
+		"/v1/history/",
+		"/v1/history/retention",
+		"/graphql",
+		"/admin/apikeys",
+		"/v1/admin/apikeys",
+		"/admin/apikeys/",
+		"/v1/admin/apikeys/",
 		"/health",
+		"/livez",
+		"/readyz",
+		"/version",
+		"/metrics",
 		"/swagger",
 		"/swagger/openapi.yaml",
+		"/swagger/assets/",
 	}
 
 	for _, route := range expectedRoutes {
@@ -95,6 +166,11 @@ func TestSetupAndRunPortConfiguration(t *testing.T) {
 		http.DefaultServeMux = originalServeMux
 	}()
 
+	// Keep API keys, corpora and histories out of the source tree
+	t.Setenv("API_KEYS_DIR", t.TempDir())
+	t.Setenv("CORPUS_DIR", t.TempDir())
+	t.Setenv("HISTORY_DIR", t.TempDir())
+
 	// Create a channel to catch panics
 	done := make(chan bool)
 
@@ -121,3 +197,307 @@ func TestSetupAndRunPortConfiguration(t *testing.T) {
 		// This is expected - the function should block on http.ListenAndServe
 	}
 }
+
+// TestSetupAndRunGRPCListenFailure tests that the HTTP server is stopped when the gRPC port cannot be listened on
+func TestSetupAndRunGRPCListenFailure(t *testing.T) {
+	originalServeMux := http.DefaultServeMux
+	http.DefaultServeMux = http.NewServeMux()
+	defer func() {
+		http.DefaultServeMux = originalServeMux
+	}()
+
+	// Hold the gRPC port, and find a free one for HTTP
+	grpcListener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer grpcListener.Close()
+	httpListener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	httpPort := httpListener.Addr().(*net.TCPAddr).Port
+	httpListener.Close()
+
+	t.Setenv("PORT", strconv.Itoa(httpPort))
+	t.Setenv("GRPC_ENABLED", "true")
+	t.Setenv("GRPC_PORT", strconv.Itoa(grpcListener.Addr().(*net.TCPAddr).Port))
+	t.Setenv("API_KEYS_DIR", t.TempDir())
+	t.Setenv("CORPUS_DIR", t.TempDir())
+	t.Setenv("HISTORY_DIR", t.TempDir())
+
+	done := make(chan error, 1)
+	go func() { done <- SetupAndRun() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected an error when the gRPC port is taken")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SetupAndRun did not return when the gRPC port was taken")
+	}
+
+	// The HTTP server must not be left serving after SetupAndRun returns
+	time.Sleep(100 * time.Millisecond)
+	if conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", httpPort)); err == nil {
+		conn.Close()
+		t.Error("Expected the HTTP server to be stopped, but it accepted a connection")
+	}
+}
+
+// TestShutdown tests that shutdown fails readiness and stops the server
+func TestShutdown(t *testing.T) {
+	defer health.SetDraining(false)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.NewServeMux()}
+
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(listener) }()
+
+	if err := shutdown(srv, nil, config.ShutdownConfig{Timeout: time.Second}); err != nil {
+		t.Fatalf("shutdown returned error: %v", err)
+	}
+
+	if !health.Draining() {
+		t.Error("Expected the server to be draining after shutdown")
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Errorf("Expected http.ErrServerClosed, got %v", err)
+	}
+}
+
+// TestPruneJobs tests that finished jobs past their retention are dropped as soon as pruning starts
+func TestPruneJobs(t *testing.T) {
+	original := jobs.GetStore()
+	defer jobs.SetStore(original)
+	jobs.SetStore(jobs.NewMemoryStore())
+
+	finished, _ := jobs.New("alice")
+	running, _ := jobs.New("alice")
+	finished.Finish("result", nil)
+	for _, job := range []*jobs.Job{finished, running} {
+		if err := jobs.GetStore().Create(job, jobs.Limits{}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	// A cancelled context stops pruning after the first pass
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pruneJobs(ctx, config.JobsConfig{Enabled: true, Retention: time.Nanosecond}, time.Hour)
+
+	if _, err := jobs.GetStore().Get(finished.ID); err != jobs.ErrJobNotFound {
+		t.Errorf("Expected the finished job to be pruned, got %v", err)
+	}
+	if _, err := jobs.GetStore().Get(running.ID); err != nil {
+		t.Errorf("Expected the running job to be kept, got %v", err)
+	}
+}
+
+// TestPruneHistory tests that expired analyses are dropped as soon as pruning starts
+func TestPruneHistory(t *testing.T) {
+	original := history.GetStore()
+	defer history.SetStore(original)
+	history.SetStore(history.NewMemoryStore())
+
+	if _, err := history.GetStore().Update("alice", func(l *history.Log) error {
+		l.Add(domain.HistoryEntry{CreatedAt: time.Now().AddDate(0, 0, -8)}, 10)
+		l.Add(domain.HistoryEntry{CreatedAt: time.Now()}, 10)
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// A cancelled context stops pruning after the first pass
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pruneHistory(ctx, config.HistoryConfig{Enabled: true, RetentionDays: 7, MaxEntries: 10}, time.Hour)
+
+	if l, _ := history.GetStore().Get("alice"); len(l.Entries) != 1 || l.Entries[0].ID != 2 {
+		t.Errorf("Expected only the recent entry to be kept, got %+v", l.Entries)
+	}
+}
+
+// TestReloadRules tests that the counting rules are reloaded on SIGHUP and invalid rules are not applied
+func TestReloadRules(t *testing.T) {
+	original := rules.Active()
+	defer rules.SetActive(original)
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("Failed to write rules: %v", err)
+		}
+	}
+	write("rules:\n  - name: hashtags\n    pattern: '#\\w+'\n")
+	reloader := rules.NewReloader(path)
+	if _, err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hangup := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		reloadRules(ctx, reloader, 0, hangup)
+		close(done)
+	}()
+
+	// Each hangup is received once the previous reload has finished
+	write("rules:\n  - name: mentions\n    pattern: '('\n")
+	hangup <- syscall.SIGHUP
+	hangup <- syscall.SIGHUP
+	if got, want := domain.CountCustom(context.Background(), "#go @ana"), map[string]int{"hashtags": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected invalid rules to keep the active rules, got counts %v", got)
+	}
+	write("rules:\n  - name: mentions\n    pattern: '@\\w+'\n")
+	hangup <- syscall.SIGHUP
+	hangup <- syscall.SIGHUP
+	if got, want := domain.CountCustom(context.Background(), "#go @ana"), map[string]int{"mentions": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the rules to be reloaded, got counts %v", got)
+	}
+
+	cancel()
+	<-done
+}
+
+// TestEnabledFeatures tests that optional modules are listed only when configured
+func TestEnabledFeatures(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Docs.Enabled = true
+	cfg.RateLimit.Enabled = true
+	cfg.Tracing.Endpoint = "http://collector:4318"
+	cfg.GRPC.Enabled = true
+	cfg.GraphQL.Enabled = true
+	cfg.WebSocket.Enabled = true
+	cfg.Jobs.Enabled = true
+	cfg.Upload.Enabled = true
+	cfg.Corpus.Enabled = true
+	cfg.History.Enabled = true
+	cfg.Rules.File = "rules.yaml"
+
+	want := []string{"api_keys", "metrics", "docs", "rate_limit", "tracing", "grpc", "graphql", "websocket", "sse", "uploads", "corpora", "history", "custom_rules"}
+	if got := enabledFeatures(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected features %v, got %v", want, got)
+	}
+
+	want = []string{"api_keys", "metrics"}
+	if got := enabledFeatures(config.Config{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected features %v, got %v", want, got)
+	}
+}
+
+// TestRoutesDocumented keeps the OpenAPI paths in sync with the registered routes
+func TestRoutesDocumented(t *testing.T) {
+	originalServeMux := http.DefaultServeMux
+	http.DefaultServeMux = http.NewServeMux()
+	defer func() {
+		http.DefaultServeMux = originalServeMux
+	}()
+
+	SetupRoutes(config.LoadConfig())
+
+	paths, err := docs.Paths()
+	if err != nil {
+		t.Fatalf("Failed to read documented paths: %v", err)
+	}
+
+	// A path parameter such as /v1/admin/apikeys/{id} is served by the subtree pattern /v1/admin/apikeys/
+	documented := make(map[string]bool)
+	for _, path := range paths {
+		if i := strings.Index(path, "{"); i >= 0 {
+			path = path[:i]
+		}
+		documented[path] = true
+	}
+
+	// The documentation endpoints themselves are not part of the API
+	undocumented := map[string]bool{
+		"/swagger":              true,
+		"/swagger/openapi.yaml": true,
+		docs.AssetsPath:         true,
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range Routes() {
+		registered[route] = true
+		// Unversioned aliases are documented through their /v1 path
+		if !documented[route] && !documented[v1Prefix+route] && !undocumented[route] {
+			t.Errorf("Route %s is not documented in the OpenAPI specification", route)
+		}
+	}
+	for path := range documented {
+		if !registered[path] {
+			t.Errorf("Documented path %s is not registered", path)
+		}
+	}
+}
+
+// TestSetupRoutesDocsDisabled tests that the documentation endpoints can be turned off
+func TestSetupRoutesDocsDisabled(t *testing.T) {
+	originalServeMux := http.DefaultServeMux
+	http.DefaultServeMux = http.NewServeMux()
+	defer func() {
+		http.DefaultServeMux = originalServeMux
+	}()
+
+	cfg := config.LoadConfig()
+	cfg.Docs.Enabled = false
+	SetupRoutes(cfg)
+
+	for _, route := range Routes() {
+		if strings.HasPrefix(route, "/swagger") {
+			t.Errorf("Expected no documentation routes, got %s", route)
+		}
+	}
+}
+
+// TestVersionedRoutes tests that unversioned routes are deprecated aliases of /v1
+func TestVersionedRoutes(t *testing.T) {
+	originalServeMux := http.DefaultServeMux
+	http.DefaultServeMux = http.NewServeMux()
+	defer func() {
+		http.DefaultServeMux = originalServeMux
+	}()
+
+	SetupRoutes(config.LoadConfig())
+
+	tests := []struct {
+		path           string
+		wantDeprecated bool
+	}{
+		{path: "/analyze", wantDeprecated: true},
+		{path: "/v1/analyze"},
+		{path: "/v2/analyze"},
+		{path: "/v1/analyze/documents"},
+		{path: "/v1/compare"},
+		{path: "/v1/corpora/essays"},
+		{path: "/v1/history/retention"},
+		{path: "/admin/apikeys/abc", wantDeprecated: true},
+		{path: "/graphql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// Unauthenticated requests are rejected, but still carry the deprecation headers
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			rr := httptest.NewRecorder()
+			http.DefaultServeMux.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+			}
+			if deprecated := rr.Header().Get("Deprecation") != ""; deprecated != tt.wantDeprecated {
+				t.Errorf("Expected deprecated %v, got headers %v", tt.wantDeprecated, rr.Header())
+			}
+			if tt.wantDeprecated && rr.Header().Get("Link") != "<"+v1Prefix+tt.path+`>; rel="successor-version"` {
+				t.Errorf("Expected a successor link to the /v1 path, got %q", rr.Header().Get("Link"))
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: analyzer/v1/analyzer.proto
+
+package analyzerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AnalyzerService_Analyze_FullMethodName       = "/sentenceanalyzer.v1.AnalyzerService/Analyze"
+	AnalyzerService_AnalyzeStream_FullMethodName = "/sentenceanalyzer.v1.AnalyzerService/AnalyzeStream"
+	AnalyzerService_AnalyzeBatch_FullMethodName  = "/sentenceanalyzer.v1.AnalyzerService/AnalyzeBatch"
+)
+
+// AnalyzerServiceClient is the client API for AnalyzerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalyzerServiceClient interface {
+	// Analyze counts the words, vowels and consonants in one sentence.
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error)
+	// AnalyzeStream analyzes each sentence of the request and streams one result per sentence
+	// as soon as it is ready.
+	AnalyzeStream(ctx context.Context, in *AnalyzeStreamRequest, opts ...grpc.CallOption) (AnalyzerService_AnalyzeStreamClient, error)
+	// AnalyzeBatch analyzes every sentence the client streams and returns all results, plus
+	// their totals, once the client closes the stream.
+	AnalyzeBatch(ctx context.Context, opts ...grpc.CallOption) (AnalyzerService_AnalyzeBatchClient, error)
+}
+
+type analyzerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyzerServiceClient(cc grpc.ClientConnInterface) AnalyzerServiceClient {
+	return &analyzerServiceClient{cc}
+}
+
+func (c *analyzerServiceClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error) {
+	out := new(AnalyzeResponse)
+	err := c.cc.Invoke(ctx, AnalyzerService_Analyze_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyzerServiceClient) AnalyzeStream(ctx context.Context, in *AnalyzeStreamRequest, opts ...grpc.CallOption) (AnalyzerService_AnalyzeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AnalyzerService_ServiceDesc.Streams[0], AnalyzerService_AnalyzeStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyzerServiceAnalyzeStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyzerService_AnalyzeStreamClient interface {
+	Recv() (*AnalyzeStreamResponse, error)
+	grpc.ClientStream
+}
+
+type analyzerServiceAnalyzeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyzerServiceAnalyzeStreamClient) Recv() (*AnalyzeStreamResponse, error) {
+	m := new(AnalyzeStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyzerServiceClient) AnalyzeBatch(ctx context.Context, opts ...grpc.CallOption) (AnalyzerService_AnalyzeBatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AnalyzerService_ServiceDesc.Streams[1], AnalyzerService_AnalyzeBatch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyzerServiceAnalyzeBatchClient{stream}
+	return x, nil
+}
+
+type AnalyzerService_AnalyzeBatchClient interface {
+	Send(*AnalyzeRequest) error
+	CloseAndRecv() (*AnalyzeBatchResponse, error)
+	grpc.ClientStream
+}
+
+type analyzerServiceAnalyzeBatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyzerServiceAnalyzeBatchClient) Send(m *AnalyzeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *analyzerServiceAnalyzeBatchClient) CloseAndRecv() (*AnalyzeBatchResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AnalyzeBatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AnalyzerServiceServer is the server API for AnalyzerService service.
+// All implementations must embed UnimplementedAnalyzerServiceServer
+// for forward compatibility
+type AnalyzerServiceServer interface {
+	// Analyze counts the words, vowels and consonants in one sentence.
+	Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error)
+	// AnalyzeStream analyzes each sentence of the request and streams one result per sentence
+	// as soon as it is ready.
+	AnalyzeStream(*AnalyzeStreamRequest, AnalyzerService_AnalyzeStreamServer) error
+	// AnalyzeBatch analyzes every sentence the client streams and returns all results, plus
+	// their totals, once the client closes the stream.
+	AnalyzeBatch(AnalyzerService_AnalyzeBatchServer) error
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+// UnimplementedAnalyzerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAnalyzerServiceServer struct {
+}
+
+func (UnimplementedAnalyzerServiceServer) Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) AnalyzeStream(*AnalyzeStreamRequest, AnalyzerService_AnalyzeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeStream not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) AnalyzeBatch(AnalyzerService_AnalyzeBatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeBatch not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) mustEmbedUnimplementedAnalyzerServiceServer() {}
+
+// UnsafeAnalyzerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyzerServiceServer will
+// result in compilation errors.
+type UnsafeAnalyzerServiceServer interface {
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+func RegisterAnalyzerServiceServer(s grpc.ServiceRegistrar, srv AnalyzerServiceServer) {
+	s.RegisterService(&AnalyzerService_ServiceDesc, srv)
+}
+
+func _AnalyzerService_Analyze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyzerServiceServer).Analyze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyzerService_Analyze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyzerServiceServer).Analyze(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyzerService_AnalyzeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AnalyzeStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyzerServiceServer).AnalyzeStream(m, &analyzerServiceAnalyzeStreamServer{stream})
+}
+
+type AnalyzerService_AnalyzeStreamServer interface {
+	Send(*AnalyzeStreamResponse) error
+	grpc.ServerStream
+}
+
+type analyzerServiceAnalyzeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyzerServiceAnalyzeStreamServer) Send(m *AnalyzeStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyzerService_AnalyzeBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AnalyzerServiceServer).AnalyzeBatch(&analyzerServiceAnalyzeBatchServer{stream})
+}
+
+type AnalyzerService_AnalyzeBatchServer interface {
+	SendAndClose(*AnalyzeBatchResponse) error
+	Recv() (*AnalyzeRequest, error)
+	grpc.ServerStream
+}
+
+type analyzerServiceAnalyzeBatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyzerServiceAnalyzeBatchServer) SendAndClose(m *AnalyzeBatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *analyzerServiceAnalyzeBatchServer) Recv() (*AnalyzeRequest, error) {
+	m := new(AnalyzeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AnalyzerService_ServiceDesc is the grpc.ServiceDesc for AnalyzerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyzerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sentenceanalyzer.v1.AnalyzerService",
+	HandlerType: (*AnalyzerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyze",
+			Handler:    _AnalyzerService_Analyze_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeStream",
+			Handler:       _AnalyzerService_AnalyzeStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "AnalyzeBatch",
+			Handler:       _AnalyzerService_AnalyzeBatch_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "analyzer/v1/analyzer.proto",
+}
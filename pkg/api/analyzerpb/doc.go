@@ -0,0 +1,4 @@
+// Package analyzerpb holds the gRPC service definition generated from proto/analyzer/v1/analyzer.proto
+package analyzerpb
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/hc12r/sentence-analyzer-vm --go-grpc_out=../../.. --go-grpc_opt=module=github.com/hc12r/sentence-analyzer-vm analyzer/v1/analyzer.proto
@@ -0,0 +1,276 @@
+// Package gql serves the analysis result as a GraphQL schema so clients can select the fields they need
+package gql
+
+import (
+	"context"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// Request is one GraphQL operation as posted over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Error is a resolver error whose code is reported in the error's extensions
+// Codes are the same as the problem codes of the REST API
+type Error struct {
+	Code    string
+	Message string
+	Fields  []domain.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions returns the code and any field errors for the GraphQL response
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.Code}
+	if len(e.Fields) > 0 {
+		extensions["errors"] = e.Fields
+	}
+	return extensions
+}
+
+// analysis is the source of an Analysis or Sentence object
+// Counts are always computed; the other sections are only computed when selected
+type analysis struct {
+	text   string
+	index  int
+	counts domain.SentenceAnalysisResponse
+}
+
+// newAnalysis counts a text; a failed analysis is logged and reported as an internal error
+func newAnalysis(ctx context.Context, text string, index int) (*analysis, error) {
+	counts, err := domain.AnalyzeSentenceContext(ctx, text)
+	if err != nil {
+		slog.ErrorContext(ctx, "error analyzing sentence", "error", err)
+		return nil, &Error{Code: problem.CodeInternal, Message: "Internal server error"}
+	}
+	return &analysis{text: text, index: index, counts: counts}, nil
+}
+
+// NewSchema builds the GraphQL schema; analyzed sentences are held to the given input limits
+func NewSchema(limits config.InputLimits) (graphql.Schema, error) {
+	wordFrequencyType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "WordFrequency",
+		Description: "How often a word occurs, compared case-insensitively without surrounding punctuation",
+		Fields: graphql.Fields{
+			"word":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	// Fields shared by a whole analysis and each of its sentences
+	countFields := func() graphql.Fields {
+		return graphql.Fields{
+			"text": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*analysis).text, nil },
+			},
+			"characterCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return utf8.RuneCountInString(p.Source.(*analysis).text), nil
+				},
+			},
+			"wordCount": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*analysis).counts.WordCount, nil },
+			},
+			"vowelCount": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*analysis).counts.VowelCount, nil },
+			},
+			"consonantCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*analysis).counts.ConsonantCount, nil
+				},
+			},
+			"wordFrequencies": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(wordFrequencyType))),
+				Description: "Distinct words, most frequent first",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Return at most this many words"},
+				},
+				Resolve: resolveWordFrequencies,
+			},
+		}
+	}
+
+	sentenceFields := countFields()
+	sentenceFields["index"] = &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.Int),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*analysis).index, nil },
+	}
+	sentenceType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Sentence",
+		Description: "The analysis of one sentence of the text",
+		Fields:      sentenceFields,
+	})
+
+	analysisFields := countFields()
+	analysisFields["sentences"] = &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(sentenceType))),
+		Description: "The text split into sentences, each analyzed on its own",
+		Resolve:     resolveSentences,
+	}
+	analysisType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Analysis",
+		Description: "The analysis of a text",
+		Fields:      analysisFields,
+	})
+
+	viewerType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Viewer",
+		Description: "The authenticated client",
+		Fields: graphql.Fields{
+			"userId":   &graphql.Field{Type: graphql.String},
+			"apiKeyId": &graphql.Field{Type: graphql.String},
+			"roles":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"analyze": &graphql.Field{
+				Type: graphql.NewNonNull(analysisType),
+				Args: graphql.FieldConfigArgument{
+					"sentence": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: analyzeResolver(limits),
+			},
+			"viewer": &graphql.Field{
+				Type:    graphql.NewNonNull(viewerType),
+				Resolve: resolveViewer,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// Execute runs one operation against the schema
+func Execute(ctx context.Context, schema graphql.Schema, req Request) *graphql.Result {
+	return graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+}
+
+// analyzeResolver validates and charges the sentence as the REST endpoint does
+func analyzeResolver(limits config.InputLimits) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		sentence, _ := p.Args["sentence"].(string)
+
+		req := domain.SentenceAnalysisRequest{Sentence: sentence}
+		if fieldErrs := req.Validate(limits.MaxSentenceLength); len(fieldErrs) > 0 {
+			return nil, &Error{Code: problem.CodeValidationFailed, Message: "Validation failed", Fields: fieldErrs}
+		}
+
+		// Charge the sentence against the client's daily character quota
+		characters := utf8.RuneCountInString(sentence)
+		if _, err := ratelimit.ChargeCharacters(p.Context, characters); err != nil {
+			return nil, &Error{Code: problem.CodeQuotaExceeded, Message: "Daily character quota exceeded"}
+		}
+
+		result, err := newAnalysis(p.Context, sentence, 0)
+		if err != nil {
+			ratelimit.RefundCharacters(p.Context, characters)
+			return nil, err
+		}
+		metrics.ObserveAnalysis(characters, result.counts.WordCount)
+
+		// A history that cannot be written does not fail the analysis
+		if err := history.Record(p.Context, domain.HistoryEntry{
+			Endpoint:       "/graphql",
+			Preview:        sentence,
+			CharacterCount: characters,
+			WordCount:      result.counts.WordCount,
+			VowelCount:     result.counts.VowelCount,
+			ConsonantCount: result.counts.ConsonantCount,
+		}); err != nil {
+			slog.WarnContext(p.Context, "error recording analysis history", "error", err)
+		}
+		return result, nil
+	}
+}
+
+func resolveWordFrequencies(p graphql.ResolveParams) (interface{}, error) {
+	limit, hasLimit := p.Args["limit"].(int)
+	if hasLimit && limit < 0 {
+		return nil, &Error{
+			Code:    problem.CodeValidationFailed,
+			Message: "Validation failed",
+			Fields:  []domain.FieldError{{Field: "limit", Message: "must not be negative"}},
+		}
+	}
+
+	frequencies := domain.WordFrequencies(p.Context, p.Source.(*analysis).text)
+	if hasLimit && limit < len(frequencies) {
+		frequencies = frequencies[:limit]
+	}
+
+	result := make([]map[string]interface{}, len(frequencies))
+	for i, frequency := range frequencies {
+		result[i] = map[string]interface{}{"word": frequency.Word, "count": frequency.Count}
+	}
+	return result, nil
+}
+
+func resolveSentences(p graphql.ResolveParams) (interface{}, error) {
+	texts := domain.SplitSentences(p.Context, p.Source.(*analysis).text)
+
+	sentences := make([]*analysis, len(texts))
+	for i, text := range texts {
+		sentence, err := newAnalysis(p.Context, text, i)
+		if err != nil {
+			return nil, err
+		}
+		sentences[i] = sentence
+	}
+	return sentences, nil
+}
+
+func resolveViewer(p graphql.ResolveParams) (interface{}, error) {
+	authInfo, ok := auth.GetAuthInfo(p.Context)
+	if !ok {
+		return nil, &Error{Code: problem.CodeNoToken, Message: "Authentication required"}
+	}
+
+	roles := authInfo.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return map[string]interface{}{
+		"userId":   nullable(authInfo.UserID),
+		"apiKeyId": nullable(authInfo.APIKeyID),
+		"roles":    roles,
+	}, nil
+}
+
+// nullable returns nil for an empty string so it is null in the response
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
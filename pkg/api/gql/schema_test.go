@@ -0,0 +1,102 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// execute runs a query and returns its JSON encoded result
+func execute(t *testing.T, ctx context.Context, query string, variables map[string]interface{}) string {
+	t.Helper()
+
+	schema, err := NewSchema(config.InputLimits{MaxBodyBytes: 1024, MaxSentenceLength: 50})
+	if err != nil {
+		t.Fatalf("Failed to build schema: %v", err)
+	}
+
+	data, err := json.Marshal(Execute(ctx, schema, Request{Query: query, Variables: variables}))
+	if err != nil {
+		t.Fatalf("Failed to encode result: %v", err)
+	}
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name:  "selected counts only",
+			query: `{ analyze(sentence: "Hello World") { wordCount vowelCount } }`,
+			want:  `{"data":{"analyze":{"vowelCount":3,"wordCount":2}}}`,
+		},
+		{
+			name:  "word frequencies with a limit",
+			query: `{ analyze(sentence: "the cat and the hat") { wordFrequencies(limit: 2) { word count } } }`,
+			want:  `{"data":{"analyze":{"wordFrequencies":[{"count":2,"word":"the"},{"count":1,"word":"and"}]}}}`,
+		},
+		{
+			name:      "per-sentence breakdown with variables",
+			query:     `query($s: String!) { analyze(sentence: $s) { characterCount sentences { index text wordCount } } }`,
+			variables: map[string]interface{}{"s": "Hi there. Bye!"},
+			want:      `{"data":{"analyze":{"characterCount":14,"sentences":[{"index":0,"text":"Hi there.","wordCount":2},{"index":1,"text":"Bye!","wordCount":1}]}}}`,
+		},
+		{
+			name:  "validation error carries the problem code",
+			query: `{ analyze(sentence: " ") { wordCount } }`,
+			want:  `{"data":null,"errors":[{"message":"Validation failed","locations":[{"line":1,"column":3}],"path":["analyze"],"extensions":{"code":"validation_failed","errors":[{"field":"sentence","message":"must contain non-whitespace characters"}]}}]}`,
+		},
+		{
+			name:  "negative limit",
+			query: `{ analyze(sentence: "a b") { wordFrequencies(limit: -1) { word } } }`,
+			want:  `{"data":null,"errors":[{"message":"Validation failed","locations":[{"line":1,"column":30}],"path":["analyze","wordFrequencies"],"extensions":{"code":"validation_failed","errors":[{"field":"limit","message":"must not be negative"}]}}]}`,
+		},
+		{
+			name:  "unknown field",
+			query: `{ analyze(sentence: "a") { readability } }`,
+			want:  `{"data":null,"errors":[{"message":"Cannot query field \"readability\" on type \"Analysis\".","locations":[{"line":1,"column":28}]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execute(t, context.Background(), tt.query, tt.variables); got != tt.want {
+				t.Errorf("Execute() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteViewer(t *testing.T) {
+	query := `{ viewer { userId apiKeyId roles } }`
+
+	ctx := auth.WithAuthInfo(context.Background(), &auth.AuthInfo{UserID: "alice", Roles: []string{"user"}})
+	want := `{"data":{"viewer":{"apiKeyId":null,"roles":["user"],"userId":"alice"}}}`
+	if got := execute(t, ctx, query, nil); got != want {
+		t.Errorf("Execute() = %s, want %s", got, want)
+	}
+
+	want = `{"data":null,"errors":[{"message":"Authentication required","locations":[{"line":1,"column":3}],"path":["viewer"],"extensions":{"code":"authentication_required"}}]}`
+	if got := execute(t, context.Background(), query, nil); got != want {
+		t.Errorf("Execute() = %s, want %s", got, want)
+	}
+}
+
+func TestExecuteChargesQuota(t *testing.T) {
+	quota := ratelimit.NewQuota(ratelimit.NewLimiter(), "user:alice", 10)
+	ctx := ratelimit.WithQuota(context.Background(), quota)
+
+	query := `{ analyze(sentence: "Hello World") { wordCount } }`
+	want := `{"data":null,"errors":[{"message":"Daily character quota exceeded","locations":[{"line":1,"column":3}],"path":["analyze"],"extensions":{"code":"quota_exceeded"}}]}`
+	if got := execute(t, ctx, query, nil); got != want {
+		t.Errorf("Execute() = %s, want %s", got, want)
+	}
+}
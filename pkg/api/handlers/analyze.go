@@ -1,41 +1,142 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"unicode/utf8"
 
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/render"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
 )
 
-// HandleAnalyzeSentence handles the sentence analysis endpoint
+// HandleAnalyzeSentence handles the sentence analysis endpoint with the default input limits
 func HandleAnalyzeSentence(w http.ResponseWriter, r *http.Request) {
-	// Only allow POST method
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	AnalyzeSentenceHandler(config.DefaultInputLimits())(w, r)
+}
 
-	// Parse request body
-	var req domain.SentenceAnalysisRequest
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+// topWords is how many of the most frequent words the /v2 analysis returns
+const topWords = 10
+
+// AnalyzeSentenceHandler returns a handler for the sentence analysis endpoint
+// that enforces the given input limits
+func AnalyzeSentenceHandler(limits config.InputLimits) http.HandlerFunc {
+	return analyzeHandler(limits, func(ctx context.Context, prose string, excluded *domain.ExcludedContent, analyses map[string]interface{}) (interface{}, domain.SentenceAnalysisResponse, error) {
+		result, err := domain.AnalyzeSentenceContext(ctx, prose)
+		result.Excluded = excluded
+		result.Analyses = analyses
+		return result, result, err
+	})
+}
+
+// AnalyzeSentenceV2Handler returns a handler for the /v2 sentence analysis endpoint, whose
+// response adds character and sentence counts and the most frequent words
+func AnalyzeSentenceV2Handler(limits config.InputLimits) http.HandlerFunc {
+	return analyzeHandler(limits, func(ctx context.Context, prose string, excluded *domain.ExcludedContent, analyses map[string]interface{}) (interface{}, domain.SentenceAnalysisResponse, error) {
+		result, err := domain.AnalyzeDetailed(ctx, prose, topWords)
+		result.Excluded = excluded
+		result.Analyses = analyses
+		return result, domain.SentenceAnalysisResponse{
+			WordCount:      result.WordCount,
+			VowelCount:     result.VowelCount,
+			ConsonantCount: result.ConsonantCount,
+		}, err
+	})
+}
 
-	// Analyze the sentence
-	result := domain.AnalyzeSentence(req.Sentence)
+// analyzeHandler returns a handler that validates and charges a sentence, strips any markup and runs any
+// requested pipeline stages, then writes the response built by analyze from the prose, the report of what
+// was excluded, nil for plain sentences, and the stage outputs, nil when none were requested;
+// analyze also returns the counts for metrics and the client's history, or the error of a failed analysis
+func analyzeHandler(limits config.InputLimits, analyze func(ctx context.Context, prose string, excluded *domain.ExcludedContent, analyses map[string]interface{}) (interface{}, domain.SentenceAnalysisResponse, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+		// Pick the response format before doing any work
+		format, ok := render.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			render.NotAcceptable(w, r)
+			return
+		}
 
-	// Write response
-	encoder := json.NewEncoder(w)
-	if err := encoder.Encode(result); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		// Parse request body
+		_, span := tracing.Start(r.Context(), "request.decode")
+		req, err := decodeAnalysisRequest(w, r, limits.MaxBodyBytes)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if err != nil {
+			writeDecodeError(w, r, err)
+			return
+		}
+
+		// Validate the request
+		if fieldErrs := req.Validate(limits.MaxSentenceLength); len(fieldErrs) > 0 {
+			problem.WriteValidation(w, r, fieldErrs)
+			return
+		}
+
+		// Charge the sentence against the client's daily character quota
+		characters := utf8.RuneCountInString(req.Sentence)
+		if retryAfter, err := ratelimit.ChargeCharacters(r.Context(), characters); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "Daily character quota exceeded")
+			return
+		}
+
+		// Analyze only the prose of marked up sentences
+		prose, excluded, err := domain.Prose(r.Context(), req.Sentence, req.Format)
+		if err != nil {
+			writeAnalysisFailure(w, r, characters, "error stripping markup", "format", req.Format, "error", err)
+			return
+		}
+		var analyses map[string]interface{}
+		if len(req.Analyses) > 0 {
+			if analyses, err = domain.RunAnalyses(r.Context(), prose, req.Analyses); err != nil {
+				writeAnalysisFailure(w, r, characters, "error running analysis stages", "analyses", req.Analyses, "error", err)
+				return
+			}
+		}
+		result, counts, err := analyze(r.Context(), prose, excluded, analyses)
+		if err != nil {
+			writeAnalysisFailure(w, r, characters, "error analyzing sentence", "error", err)
+			return
+		}
+		metrics.ObserveAnalysis(characters, counts.WordCount)
+
+		recordHistory(r.Context(), domain.HistoryEntry{
+			Endpoint:       r.URL.Path,
+			Format:         req.Format,
+			Preview:        req.Sentence,
+			CharacterCount: characters,
+			WordCount:      counts.WordCount,
+			VowelCount:     counts.VowelCount,
+			ConsonantCount: counts.ConsonantCount,
+		})
+
+		// Write response in the negotiated format
+		render.Write(w, r, format, http.StatusOK, "analysis", result)
 	}
 }
+
+// writeAnalysisFailure logs an analysis that failed after its characters were charged, gives them back to the
+// client's daily quota and answers with a 500
+func writeAnalysisFailure(w http.ResponseWriter, r *http.Request, characters int, msg string, args ...interface{}) {
+	ratelimit.RefundCharacters(r.Context(), characters)
+	slog.ErrorContext(r.Context(), msg, args...)
+	problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+}
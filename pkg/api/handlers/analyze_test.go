@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
 )
 
 func TestHandleAnalyzeSentence(t *testing.T) {
@@ -95,3 +100,235 @@ func TestHandleAnalyzeSentence(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzeSentenceHandlerValidation(t *testing.T) {
+	handler := AnalyzeSentenceHandler(config.InputLimits{
+		MaxBodyBytes:      64,
+		MaxSentenceLength: 20,
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+		wantField      string
+	}{
+		{"valid request", `{"sentence":"Hello World"}`, http.StatusOK, ""},
+		{"body too large", `{"sentence":"` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"sentence too long", `{"sentence":"` + strings.Repeat("a", 21) + `"}`, http.StatusUnprocessableEntity, "sentence"},
+		{"missing sentence", `{}`, http.StatusUnprocessableEntity, "sentence"},
+		{"empty sentence", `{"sentence":""}`, http.StatusUnprocessableEntity, "sentence"},
+		{"whitespace sentence", `{"sentence":"   "}`, http.StatusUnprocessableEntity, "sentence"},
+		{"unknown field", `{"sentence":"Hi","lang":"en"}`, http.StatusUnprocessableEntity, "lang"},
+		{"wrong type", `{"sentence":42}`, http.StatusUnprocessableEntity, "sentence"},
+		{"invalid UTF-8", "{\"sentence\":\"Hi \xff\"}", http.StatusUnprocessableEntity, "body"},
+		{"trailing data", `{"sentence":"Hi"} {}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/analyze", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+
+			if tt.wantField != "" {
+				var response problem.Problem
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(response.Errors) != 1 || response.Errors[0].Field != tt.wantField {
+					t.Errorf("Expected an error for field %s, got %+v", tt.wantField, response.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeSentenceHandlerContentNegotiation(t *testing.T) {
+	handler := AnalyzeSentenceHandler(config.DefaultInputLimits())
+
+	tests := []struct {
+		name            string
+		contentType     string
+		accept          string
+		body            string
+		wantStatusCode  int
+		wantContentType string
+		wantBody        string
+	}{
+		{"json by default", "", "", `{"sentence":"Hello World"}`, http.StatusOK, "application/json", `"word_count":2`},
+		{"xml", "application/json", "application/xml", `{"sentence":"Hello World"}`, http.StatusOK, "application/xml", "<word_count>2</word_count>"},
+		{"yaml", "application/json", "application/yaml", `{"sentence":"Hello World"}`, http.StatusOK, "application/yaml", "word_count: 2"},
+		{"csv", "application/json", "text/csv", `{"sentence":"Hello World"}`, http.StatusOK, "text/csv", "word_count,vowel_count,consonant_count\n2,3,7\n"},
+		{"msgpack", "application/json", "application/msgpack", `{"sentence":"Hello World"}`, http.StatusOK, "application/msgpack", "word_count"},
+		{"plain text body", "text/plain; charset=utf-8", "", "Hello World", http.StatusOK, "application/json", `"word_count":2`},
+		{"blank plain text body", "text/plain", "", "  ", http.StatusUnprocessableEntity, problem.ContentType, "sentence"},
+		{"unsupported accept", "application/json", "text/html", `{"sentence":"Hello World"}`, http.StatusNotAcceptable, problem.ContentType, problem.CodeNotAcceptable},
+		{"unsupported content type", "application/xml", "", "<sentence>Hi</sentence>", http.StatusUnsupportedMediaType, problem.ContentType, "application/json, text/html, text/markdown, text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+			if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.wantContentType) {
+				t.Errorf("Expected content type %s, got %s", tt.wantContentType, contentType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestAnalyzeSentenceResponseVersions pins the response body of each API version
+func TestAnalyzeSentenceResponseVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantBody string
+	}{
+		{
+			name:     "v1",
+			handler:  AnalyzeSentenceHandler(config.DefaultInputLimits()),
+			wantBody: `{"word_count":2,"vowel_count":3,"consonant_count":7}` + "\n",
+		},
+		{
+			name:    "v2",
+			handler: AnalyzeSentenceV2Handler(config.DefaultInputLimits()),
+			wantBody: `{"word_count":2,"vowel_count":3,"consonant_count":7,"character_count":11,"sentence_count":1,` +
+				`"top_words":[{"word":"hello","count":1},{"word":"world","count":1}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+tt.name+"/analyze", strings.NewReader(`{"sentence":"Hello World"}`))
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSentenceCustomRules(t *testing.T) {
+	original := rules.Active()
+	defer rules.SetActive(original)
+	set, err := rules.Compile([]rules.Rule{{Name: "hashtags", Pattern: `#\w+`}, {Name: "shouts", Characters: "!"}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	rules.SetActive(set)
+
+	// Rules count the prose only, so markup cannot match them
+	body := `{"sentence":"Ship it! <!-- #draft --> #go","format":"html"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/analyze", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	AnalyzeSentenceHandler(config.DefaultInputLimits())(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response domain.SentenceAnalysisResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if want := map[string]int{"hashtags": 1, "shouts": 1}; !reflect.DeepEqual(response.Custom, want) {
+		t.Errorf("Expected custom counts %v, got %v", want, response.Custom)
+	}
+}
+
+func TestAnalyzeSentenceMarkup(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "markdown format field",
+			contentType: "application/json",
+			body:        `{"sentence":"Say **hi** ` + "`now`" + `","format":"markdown"}`,
+			wantStatus:  http.StatusOK,
+			wantBody: `{"word_count":2,"vowel_count":2,"consonant_count":3,` +
+				`"excluded":{"characters":9,"reasons":[{"reason":"code","characters":5},{"reason":"syntax","characters":4}]}}` + "\n",
+		},
+		{
+			name:        "raw html body",
+			contentType: "text/html; charset=utf-8",
+			body:        `<p>Hi <a href="/x">you</a></p>`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"word_count":2,"vowel_count":3,"consonant_count":2,"excluded":{"characters":23,"reasons":[{"reason":"tag","characters":23}]}}` + "\n",
+		},
+		{
+			name:        "explicit plain format",
+			contentType: "application/json",
+			body:        `{"sentence":"Hi <b>you</b>","format":"plain"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"word_count":2,"vowel_count":3,"consonant_count":4}` + "\n",
+		},
+		{
+			name:        "unknown format",
+			contentType: "application/json",
+			body:        `{"sentence":"Hi","format":"rtf"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "analyses of the prose",
+			contentType: "application/json",
+			body:        `{"sentence":"Hi <b>you</b>","format":"html","analyses":["tokenize","count"]}`,
+			wantStatus:  http.StatusOK,
+			wantBody: `{"word_count":2,"vowel_count":3,"consonant_count":2,"excluded":{"characters":7,"reasons":[{"reason":"tag","characters":7}]},` +
+				`"analyses":{"count":{"word_count":2,"vowel_count":3,"consonant_count":2,"character_count":6,"sentence_count":1},"tokenize":{"words":["Hi","you"]}}}` + "\n",
+		},
+		{
+			name:        "unknown analysis",
+			contentType: "application/json",
+			body:        `{"sentence":"Hi","analyses":["sentiment"]}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/analyze", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			AnalyzeSentenceHandler(config.DefaultInputLimits())(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// APIKeysPath is the admin path used to issue and list API keys
+const APIKeysPath = "/admin/apikeys"
+
+// CreateAPIKeyRequest represents the request body for issuing an API key
+type CreateAPIKeyRequest struct {
+	Name           string     `json:"name"`
+	Roles          []string   `json:"roles"`
+	ExpiresInHours int        `json:"expires_in_hours,omitempty"`
+	Quota          auth.Quota `json:"quota"`
+}
+
+// CreateAPIKeyResponse represents the response body for an issued API key
+// Key holds the raw API key and is only returned once
+type CreateAPIKeyResponse struct {
+	Key    string      `json:"key"`
+	APIKey auth.APIKey `json:"api_key"`
+}
+
+// HandleAPIKeys handles issuing (POST) and listing (GET) API keys
+func HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listAPIKeys(w, r)
+	case http.MethodPost:
+		createAPIKey(w, r)
+	default:
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleAPIKey handles revoking (DELETE) a single API key at /admin/apikeys/{id}
+func HandleAPIKey(w http.ResponseWriter, r *http.Request) {
+	// Only allow DELETE method
+	if r.Method != http.MethodDelete {
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, ok := pathID(r.URL.Path, APIKeysPath, "")
+	if !ok {
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "API key not found")
+		return
+	}
+
+	if err := auth.RevokeAPIKey(id); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "API key not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "error revoking API key", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate checks the fields of a CreateAPIKeyRequest
+func (req CreateAPIKeyRequest) validate() []domain.FieldError {
+	var errs []domain.FieldError
+	if strings.TrimSpace(req.Name) == "" {
+		errs = append(errs, domain.FieldError{Field: "name", Message: "is required"})
+	}
+	if req.ExpiresInHours < 0 {
+		errs = append(errs, domain.FieldError{Field: "expires_in_hours", Message: "must not be negative"})
+	}
+	if req.Quota.RequestsPerMinute < 0 {
+		errs = append(errs, domain.FieldError{Field: "quota.requests_per_minute", Message: "must not be negative"})
+	}
+	if req.Quota.DailyCharacters < 0 {
+		errs = append(errs, domain.FieldError{Field: "quota.daily_characters", Message: "must not be negative"})
+	}
+	return errs
+}
+
+func createAPIKey(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var req CreateAPIKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if fieldErrs := req.validate(); len(fieldErrs) > 0 {
+		problem.WriteValidation(w, r, fieldErrs)
+		return
+	}
+	if len(req.Roles) == 0 {
+		req.Roles = []string{"user"}
+	}
+
+	// Issue the key
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+	rawKey, key, err := auth.IssueAPIKey(req.Name, req.Roles, ttl, req.Quota)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error issuing API key", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	// Write response
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(CreateAPIKeyResponse{Key: rawKey, APIKey: key}); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+	}
+}
+
+func listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := auth.ListAPIKeys()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error listing API keys", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	// Write response
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(keys); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+)
+
+func TestHandleAPIKeys(t *testing.T) {
+	original := auth.GetAPIKeyStore()
+	auth.SetAPIKeyStore(auth.NewMemoryAPIKeyStore())
+	defer auth.SetAPIKeyStore(original)
+
+	// Issue a key
+	reqBody, _ := json.Marshal(CreateAPIKeyRequest{
+		Name:           "etl-job",
+		ExpiresInHours: 24,
+		Quota:          auth.Quota{RequestsPerMinute: 10},
+	})
+	req, err := http.NewRequest(http.MethodPost, APIKeysPath, bytes.NewBuffer(reqBody))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	HandleAPIKeys(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	var created CreateAPIKeyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if created.Key == "" {
+		t.Error("Expected the raw key in the response")
+	}
+	if len(created.APIKey.Roles) != 1 || created.APIKey.Roles[0] != "user" {
+		t.Errorf("Expected default roles [user], got %v", created.APIKey.Roles)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte(auth.HashAPIKey(created.Key))) {
+		t.Error("Response must not contain the key hash")
+	}
+
+	// List keys
+	req, _ = http.NewRequest(http.MethodGet, APIKeysPath, nil)
+	rr = httptest.NewRecorder()
+	HandleAPIKeys(rr, req)
+
+	var keys []auth.APIKey
+	if err := json.Unmarshal(rr.Body.Bytes(), &keys); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != created.APIKey.ID {
+		t.Errorf("Expected the issued key in the list, got %+v", keys)
+	}
+
+	// Revoke the key
+	req, _ = http.NewRequest(http.MethodDelete, APIKeysPath+"/"+created.APIKey.ID, nil)
+	rr = httptest.NewRecorder()
+	HandleAPIKey(rr, req)
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if _, err := auth.ValidateAPIKey(created.Key); err != auth.ErrRevokedAPIKey {
+		t.Errorf("Expected ErrRevokedAPIKey after revocation, got %v", err)
+	}
+}
+
+func TestHandleAPIKeysErrors(t *testing.T) {
+	original := auth.GetAPIKeyStore()
+	auth.SetAPIKeyStore(auth.NewMemoryAPIKeyStore())
+	defer auth.SetAPIKeyStore(original)
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		path           string
+		body           string
+		wantStatusCode int
+	}{
+		{"missing name", HandleAPIKeys, http.MethodPost, APIKeysPath, `{"roles":["user"]}`, http.StatusUnprocessableEntity},
+		{"negative quota", HandleAPIKeys, http.MethodPost, APIKeysPath, `{"name":"x","quota":{"requests_per_minute":-1}}`, http.StatusUnprocessableEntity},
+		{"invalid body", HandleAPIKeys, http.MethodPost, APIKeysPath, `not json`, http.StatusBadRequest},
+		{"invalid method", HandleAPIKeys, http.MethodPut, APIKeysPath, ``, http.StatusMethodNotAllowed},
+		{"revoke unknown key", HandleAPIKey, http.MethodDelete, APIKeysPath + "/missing", ``, http.StatusNotFound},
+		{"revoke without id", HandleAPIKey, http.MethodDelete, APIKeysPath + "/", ``, http.StatusNotFound},
+		{"revoke invalid method", HandleAPIKey, http.MethodGet, APIKeysPath + "/abc", ``, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/render"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// ComparePath is the path an original text and its rewrite are compared at
+const ComparePath = "/compare"
+
+// CompareHandler returns a handler that analyzes an original text and its rewrite and reports
+// how the counts changed, a word-level diff and how similar the texts are
+// Each text must be valid as a sentence under the given input limits
+func CompareHandler(limits config.InputLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		// Pick the response format before doing any work
+		format, ok := render.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			render.NotAcceptable(w, r)
+			return
+		}
+
+		switch requestMediaType(r) {
+		case "", "application/json":
+		default:
+			problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+				"Supported request types: application/json")
+			return
+		}
+
+		var req domain.CompareRequest
+		if err := decodeJSONBody(w, r, &req, limits.MaxBodyBytes); err != nil {
+			writeDecodeError(w, r, err)
+			return
+		}
+		if fieldErrs := req.Validate(limits.MaxSentenceLength); len(fieldErrs) > 0 {
+			problem.WriteValidation(w, r, fieldErrs)
+			return
+		}
+
+		// Both texts are charged against the client's daily character quota
+		originalCharacters := utf8.RuneCountInString(req.Original)
+		revisedCharacters := utf8.RuneCountInString(req.Revised)
+		if retryAfter, err := ratelimit.ChargeCharacters(r.Context(), originalCharacters+revisedCharacters); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "Daily character quota exceeded")
+			return
+		}
+
+		result, err := domain.Compare(r.Context(), req.Original, req.Revised, topWords)
+		if err != nil {
+			writeAnalysisFailure(w, r, originalCharacters+revisedCharacters, "error comparing texts", "error", err)
+			return
+		}
+		metrics.ObserveAnalysis(originalCharacters, result.Original.WordCount)
+		metrics.ObserveAnalysis(revisedCharacters, result.Revised.WordCount)
+
+		// Each text is an analysis of its own in the client's history
+		recordHistory(r.Context(), domain.HistoryEntry{
+			Endpoint:       r.URL.Path,
+			Preview:        req.Original,
+			CharacterCount: originalCharacters,
+			WordCount:      result.Original.WordCount,
+			VowelCount:     result.Original.VowelCount,
+			ConsonantCount: result.Original.ConsonantCount,
+		})
+		recordHistory(r.Context(), domain.HistoryEntry{
+			Endpoint:       r.URL.Path,
+			Preview:        req.Revised,
+			CharacterCount: revisedCharacters,
+			WordCount:      result.Revised.WordCount,
+			VowelCount:     result.Revised.VowelCount,
+			ConsonantCount: result.Revised.ConsonantCount,
+		})
+
+		// Write response in the negotiated format
+		render.Write(w, r, format, http.StatusOK, "comparison", result)
+	}
+}
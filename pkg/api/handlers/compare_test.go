@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+func TestCompareHandler(t *testing.T) {
+	body := `{"original":"The cat sat.","revised":"The black cat sat down."}`
+	req := httptest.NewRequest(http.MethodPost, ComparePath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	CompareHandler(config.DefaultInputLimits())(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response domain.ComparisonResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Original.WordCount != 3 || response.Revised.WordCount != 5 {
+		t.Errorf("Expected word counts 3 and 5, got %d and %d", response.Original.WordCount, response.Revised.WordCount)
+	}
+	wantDelta := domain.AnalysisDelta{WordCount: 2, VowelCount: 2, ConsonantCount: 7, CharacterCount: 11}
+	if !reflect.DeepEqual(response.Delta, wantDelta) {
+		t.Errorf("Expected delta %+v, got %+v", wantDelta, response.Delta)
+	}
+	wantDiff := []domain.WordDiff{
+		{Op: "equal", Text: "The"},
+		{Op: "insert", Text: "black"},
+		{Op: "equal", Text: "cat"},
+		{Op: "delete", Text: "sat."},
+		{Op: "insert", Text: "sat down."},
+	}
+	if !reflect.DeepEqual(response.Diff, wantDiff) {
+		t.Errorf("Expected diff %+v, got %+v", wantDiff, response.Diff)
+	}
+	if response.Similarity.Jaccard != 0.6 || response.Similarity.EditDistance != 3 {
+		t.Errorf("Expected jaccard 0.6 and edit distance 3, got %+v", response.Similarity)
+	}
+}
+
+func TestCompareHandlerErrors(t *testing.T) {
+	limits := config.InputLimits{MaxBodyBytes: 128, MaxSentenceLength: 10}
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "plain text body", contentType: "text/plain", body: "hi", wantStatus: http.StatusUnsupportedMediaType, wantCode: problem.CodeUnsupportedMedia},
+		{name: "malformed JSON", body: `{"original":`, wantStatus: http.StatusBadRequest, wantCode: problem.CodeInvalidBody},
+		{name: "unknown field", body: `{"original":"a","revised":"b","sentence":"c"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "missing revised", body: `{"original":"a"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "original too long", body: `{"original":"` + strings.Repeat("a", 11) + `","revised":"b"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "body too large", body: `{"original":"` + strings.Repeat("a", 128) + `","revised":"b"}`, wantStatus: http.StatusRequestEntityTooLarge, wantCode: problem.CodeBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(method, ComparePath, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+			CompareHandler(limits)(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, p.Code)
+			}
+		})
+	}
+}
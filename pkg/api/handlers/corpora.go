@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/render"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/corpus"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// CorporaPath is the path the client's corpora are listed at; each corpus is served below it
+const CorporaPath = "/corpora"
+
+// corpusDocumentsSuffix follows a corpus's path in the path its documents are added at
+const corpusDocumentsSuffix = "/documents"
+
+// HandleCorpora handles listing (GET) the corpora of the authenticated client
+func HandleCorpora(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	summaries, err := corpus.GetStore().List(authInfo.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error listing corpora", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	render.Write(w, r, format, http.StatusOK, "corpora", summaries)
+}
+
+// CorpusHandler returns a handler for a single corpus of the authenticated client: adding a document
+// (POST /corpora/{name}/documents), which creates the corpus if needed, reading its statistics
+// (GET /corpora/{name}) and deleting it (DELETE /corpora/{name})
+func CorpusHandler(limits config.InputLimits, cfg config.CorpusConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := pathID(r.URL.Path, CorporaPath, corpusDocumentsSuffix); ok {
+			if r.Method != http.MethodPost {
+				problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			addCorpusDocument(w, r, name, limits, cfg)
+			return
+		}
+
+		name, ok := pathID(r.URL.Path, CorporaPath, "")
+		if !ok || !corpus.ValidName(name) {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Corpus not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			getCorpusStatistics(w, r, name)
+		case http.MethodDelete:
+			deleteCorpus(w, r, name)
+		default:
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// addCorpusDocument analyzes the text in the request body and adds it to the named corpus
+func addCorpusDocument(w http.ResponseWriter, r *http.Request, name string, limits config.InputLimits, cfg config.CorpusConfig) {
+	// Pick the response format before doing any work
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+	if !corpus.ValidName(name) {
+		problem.WriteValidation(w, r, []domain.FieldError{{
+			Field:   "name",
+			Message: "must be 1 to 64 lowercase letters, digits, '-' or '_', starting with a letter or digit",
+		}})
+		return
+	}
+
+	req, err := decodeCorpusDocumentRequest(w, r, limits.MaxBodyBytes)
+	if err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if fieldErrs := req.Validate(); len(fieldErrs) > 0 {
+		problem.WriteValidation(w, r, fieldErrs)
+		return
+	}
+
+	// Charge the text against the client's daily character quota
+	characters := utf8.RuneCountInString(req.Text)
+	if retryAfter, err := ratelimit.ChargeCharacters(r.Context(), characters); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		problem.Write(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "Daily character quota exceeded")
+		return
+	}
+
+	var (
+		document domain.CorpusDocument
+		counts   domain.SentenceAnalysisResponse
+	)
+	_, err = corpus.GetStore().Update(authInfo.UserID, name, cfg.MaxCorpora, func(c *corpus.Corpus) error {
+		if len(c.Documents) >= cfg.MaxDocuments {
+			return corpus.ErrCorpusFull
+		}
+		document, counts, err = c.Add(r.Context(), req.Text, time.Now().UTC())
+		return err
+	})
+	switch {
+	case errors.Is(err, corpus.ErrCorpusFull):
+		// Nothing was analyzed, so the text is not charged
+		ratelimit.RefundCharacters(r.Context(), characters)
+		problem.Write(w, r, http.StatusConflict, problem.CodeCorpusFull,
+			fmt.Sprintf("Corpus already holds %d documents", cfg.MaxDocuments))
+		return
+	case errors.Is(err, corpus.ErrTooManyCorpora):
+		ratelimit.RefundCharacters(r.Context(), characters)
+		problem.Write(w, r, http.StatusConflict, problem.CodeTooManyCorpora,
+			fmt.Sprintf("At most %d corpora are allowed", cfg.MaxCorpora))
+		return
+	case err != nil:
+		writeAnalysisFailure(w, r, characters, "error adding corpus document", "corpus", name, "error", err)
+		return
+	}
+	metrics.ObserveAnalysis(characters, document.WordCount)
+
+	recordHistory(r.Context(), domain.HistoryEntry{
+		Endpoint:       r.URL.Path,
+		Preview:        req.Text,
+		CharacterCount: characters,
+		WordCount:      counts.WordCount,
+		VowelCount:     counts.VowelCount,
+		ConsonantCount: counts.ConsonantCount,
+	})
+
+	// Write response; the corpus is served under the same version prefix as the request
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, corpusDocumentsSuffix))
+	render.Write(w, r, format, http.StatusCreated, "document", document)
+}
+
+// getCorpusStatistics writes the statistics aggregated over the named corpus
+func getCorpusStatistics(w http.ResponseWriter, r *http.Request, name string) {
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	c, err := corpus.GetStore().Get(authInfo.UserID, name)
+	switch {
+	case errors.Is(err, corpus.ErrCorpusNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Corpus not found")
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "error reading corpus", "corpus", name, "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	render.Write(w, r, format, http.StatusOK, "corpus", c.Statistics())
+}
+
+// deleteCorpus deletes the named corpus and its documents
+func deleteCorpus(w http.ResponseWriter, r *http.Request, name string) {
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	err := corpus.GetStore().Delete(authInfo.UserID, name)
+	switch {
+	case errors.Is(err, corpus.ErrCorpusNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Corpus not found")
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "error deleting corpus", "corpus", name, "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeCorpusDocumentRequest decodes a corpus document from a JSON body, or from a
+// text/plain body that holds the raw text
+func decodeCorpusDocumentRequest(w http.ResponseWriter, r *http.Request, maxBytes int64) (domain.CorpusDocumentRequest, error) {
+	var req domain.CorpusDocumentRequest
+
+	switch requestMediaType(r) {
+	case "", "application/json":
+		err := decodeJSONBody(w, r, &req, maxBytes)
+		return req, err
+	case "text/plain":
+		body, err := readBody(w, r, maxBytes)
+		req.Text = string(body)
+		return req, err
+	default:
+		return req, &unsupportedMediaError{Supported: jsonOrPlainText}
+	}
+}
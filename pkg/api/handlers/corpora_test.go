@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/corpus"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+var testCorpusConfig = config.CorpusConfig{Enabled: true, MaxDocuments: 2, MaxCorpora: 2}
+
+// useMemoryCorpora swaps in an empty corpus store for the duration of a test
+func useMemoryCorpora(t *testing.T) {
+	t.Helper()
+
+	original := corpus.GetStore()
+	corpus.SetStore(corpus.NewMemoryStore())
+	t.Cleanup(func() { corpus.SetStore(original) })
+}
+
+// corpusRequest sends a request to the corpus handler as the given user
+func corpusRequest(userID, method, path, contentType, body string) *httptest.ResponseRecorder {
+	handler := asUser(userID, CorpusHandler(config.DefaultInputLimits(), testCorpusConfig))
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+func TestCorpusHandler(t *testing.T) {
+	useMemoryCorpora(t)
+
+	// The first document creates the corpus
+	rr := corpusRequest("user:alice", http.MethodPost, "/v1/corpora/essays/documents", "application/json", `{"text":"The cat sat. The cat ran."}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if location := rr.Header().Get("Location"); location != "/v1/corpora/essays" {
+		t.Errorf("Expected Location /v1/corpora/essays, got %q", location)
+	}
+	var document domain.CorpusDocument
+	if err := json.NewDecoder(rr.Body).Decode(&document); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if document.ID != 1 || document.WordCount != 6 || document.SentenceCount != 2 || document.NewWords != 4 {
+		t.Errorf("Unexpected document %+v", document)
+	}
+
+	// A raw text body is added as is
+	rr = corpusRequest("user:alice", http.MethodPost, "/v1/corpora/essays/documents", "text/plain", "A dog ran.")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	rr = corpusRequest("user:alice", http.MethodGet, "/v1/corpora/essays", "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var stats domain.CorpusStatistics
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if stats.Name != "essays" || stats.DocumentCount != 2 || stats.WordCount != 9 || stats.VocabularySize != 6 {
+		t.Errorf("Unexpected statistics %+v", stats)
+	}
+	if len(stats.VocabularyGrowth) != 2 || stats.VocabularyGrowth[1].VocabularySize != 6 {
+		t.Errorf("Unexpected vocabulary growth %+v", stats.VocabularyGrowth)
+	}
+	if len(stats.LetterDistribution) == 0 || stats.LetterDistribution[0].Letter != "a" {
+		t.Errorf("Expected 'a' to be the most common letter, got %+v", stats.LetterDistribution)
+	}
+
+	// Corpora are private to their owner
+	if rr := corpusRequest("user:bob", http.MethodGet, "/v1/corpora/essays", "", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for another user, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	// The corpus holds at most MaxDocuments documents
+	rr = corpusRequest("user:alice", http.MethodPost, "/v1/corpora/essays/documents", "application/json", `{"text":"One more."}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+	var p problem.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil || p.Code != problem.CodeCorpusFull {
+		t.Errorf("Expected code %q, got %+v (%v)", problem.CodeCorpusFull, p, err)
+	}
+
+	if rr := corpusRequest("user:alice", http.MethodDelete, "/v1/corpora/essays", "", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if rr := corpusRequest("user:alice", http.MethodGet, "/v1/corpora/essays", "", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d after deleting, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestCorpusHandlerRefundsRefusedDocuments(t *testing.T) {
+	useMemoryCorpora(t)
+
+	limiter := ratelimit.NewLimiter()
+	handler := asUser("user:alice", CorpusHandler(config.DefaultInputLimits(), config.CorpusConfig{Enabled: true, MaxDocuments: 1, MaxCorpora: 1}))
+	add := func(text string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/corpora/essays/documents", strings.NewReader(text))
+		req.Header.Set("Content-Type", "text/plain")
+		req = req.WithContext(ratelimit.WithQuota(req.Context(), ratelimit.NewQuota(limiter, "user:alice", 0)))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr.Code
+	}
+
+	if code := add("The cat sat."); code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, code)
+	}
+	// A document the full corpus refuses is not charged
+	if code := add("The dog ran."); code != http.StatusConflict {
+		t.Fatalf("Expected status code %d, got %d", http.StatusConflict, code)
+	}
+	if used := limiter.Used("user:alice"); used != len("The cat sat.") {
+		t.Errorf("Expected only the added document to be charged, got %d characters", used)
+	}
+}
+
+func TestCorpusHandlerMaxCorpora(t *testing.T) {
+	useMemoryCorpora(t)
+
+	for _, name := range []string{"essays", "drafts"} {
+		rr := corpusRequest("user:alice", http.MethodPost, "/v1/corpora/"+name+"/documents", "application/json", `{"text":"One."}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+
+	// A third corpus is refused, but the existing ones still take documents
+	rr := corpusRequest("user:alice", http.MethodPost, "/v1/corpora/notes/documents", "application/json", `{"text":"Two."}`)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+	var p problem.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil || p.Code != problem.CodeTooManyCorpora {
+		t.Errorf("Expected code %q, got %+v (%v)", problem.CodeTooManyCorpora, p, err)
+	}
+	if rr := corpusRequest("user:alice", http.MethodPost, "/v1/corpora/essays/documents", "application/json", `{"text":"Two."}`); rr.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d for an existing corpus, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+}
+
+func TestCorpusHandlerErrors(t *testing.T) {
+	useMemoryCorpora(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "invalid name", method: http.MethodPost, path: "/v1/corpora/My%20Essays/documents", body: `{"text":"Hi."}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "blank text", method: http.MethodPost, path: "/v1/corpora/essays/documents", body: `{"text":"  "}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "unknown field", method: http.MethodPost, path: "/v1/corpora/essays/documents", body: `{"sentence":"Hi."}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "unsupported body", method: http.MethodPost, path: "/v1/corpora/essays/documents", contentType: "text/html", body: "<p>Hi.</p>", wantStatus: http.StatusUnsupportedMediaType, wantCode: problem.CodeUnsupportedMedia},
+		{name: "documents are only added", method: http.MethodGet, path: "/v1/corpora/essays/documents", wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "corpus is not posted to", method: http.MethodPost, path: "/v1/corpora/essays", wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "missing corpus", method: http.MethodGet, path: "/v1/corpora/essays", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+		{name: "missing corpus deleted", method: http.MethodDelete, path: "/v1/corpora/essays", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+		{name: "nested path", method: http.MethodGet, path: "/v1/corpora/essays/other", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType := tt.contentType
+			if contentType == "" && tt.body != "" {
+				contentType = "application/json"
+			}
+			rr := corpusRequest("user:alice", tt.method, tt.path, contentType, tt.body)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, p.Code)
+			}
+		})
+	}
+}
+
+func TestHandleCorpora(t *testing.T) {
+	useMemoryCorpora(t)
+
+	for _, name := range []string{"reports", "essays"} {
+		if rr := corpusRequest("user:alice", http.MethodPost, "/v1/corpora/"+name+"/documents", "text/plain", "Hello there."); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	asUser("user:alice", HandleCorpora)(rr, httptest.NewRequest(http.MethodGet, CorporaPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var summaries []domain.CorpusSummary
+	if err := json.NewDecoder(rr.Body).Decode(&summaries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Name != "essays" || summaries[1].Name != "reports" || summaries[0].WordCount != 2 {
+		t.Errorf("Unexpected corpora %+v", summaries)
+	}
+
+	// Other users see none of them
+	rr = httptest.NewRecorder()
+	asUser("user:bob", HandleCorpora)(rr, httptest.NewRequest(http.MethodGet, CorporaPath, nil))
+	if strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("Expected no corpora for another user, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	asUser("user:alice", HandleCorpora)(rr, httptest.NewRequest(http.MethodPost, CorporaPath, nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
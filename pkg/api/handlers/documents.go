@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/render"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/extract"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// DocumentsPath is the path documents are uploaded to for analysis
+const DocumentsPath = "/analyze/documents"
+
+// uploadField is the multipart form field that holds the document
+const uploadField = "file"
+
+// multipartOverhead is the room left in an upload body for part headers and other form fields
+const multipartOverhead = 64 << 10
+
+// Document upload results, as counted in metrics
+const (
+	documentOK      = "ok"
+	documentInvalid = "invalid"
+	documentEmpty   = "empty"
+)
+
+// errNoDocument is returned for uploads without a file field
+var errNoDocument = errors.New("upload has no file")
+
+// DocumentAnalysisHandler returns a handler that extracts the text of an uploaded
+// .txt, .md, .html, .docx or .pdf document and analyzes it section by section
+func DocumentAnalysisHandler(cfg config.UploadConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		// Pick the response format before doing any work
+		format, ok := render.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			render.NotAcceptable(w, r)
+			return
+		}
+
+		if requestMediaType(r) != "multipart/form-data" {
+			problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+				"Supported request types: multipart/form-data")
+			return
+		}
+
+		filename, contentType, data, err := readUpload(w, r, cfg.MaxFileBytes)
+		switch {
+		case errors.Is(err, errBodyTooLarge):
+			problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge,
+				fmt.Sprintf("Document larger than %d bytes", cfg.MaxFileBytes))
+			return
+		case errors.Is(err, errNoDocument):
+			problem.WriteValidation(w, r, []domain.FieldError{{Field: uploadField, Message: "is required"}})
+			return
+		case err != nil:
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidBody, "Invalid multipart body")
+			return
+		}
+
+		documentFormat, ok := extract.DetectFormat(filename, contentType)
+		if !ok {
+			problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+				"Supported document types: "+strings.Join(extract.Formats(), ", "))
+			return
+		}
+
+		// Extract the text, dropping markup and boilerplate
+		sections, err := extract.Extract(documentFormat, data, cfg.MaxTextBytes)
+		var invalidErr *extract.InvalidDocumentError
+		switch {
+		case errors.Is(err, extract.ErrTextTooLarge):
+			problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge,
+				fmt.Sprintf("Document text larger than %d bytes", cfg.MaxTextBytes))
+			return
+		case errors.As(err, &invalidErr):
+			metrics.DocumentsTotal.WithLabelValues(documentFormat, documentInvalid).Inc()
+			problem.WriteValidation(w, r, []domain.FieldError{{
+				Field:   uploadField,
+				Message: "could not be read as a " + documentFormat + " document",
+			}})
+			return
+		case err != nil:
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		case len(sections) == 0:
+			// Scanned PDFs have images of text rather than text
+			metrics.DocumentsTotal.WithLabelValues(documentFormat, documentEmpty).Inc()
+			problem.WriteValidation(w, r, []domain.FieldError{{Field: uploadField, Message: "must contain text"}})
+			return
+		}
+
+		// Charge the extracted text, not the file, against the client's daily character quota
+		characters := 0
+		for _, section := range sections {
+			characters += utf8.RuneCountInString(section.Text)
+		}
+		if retryAfter, err := ratelimit.ChargeCharacters(r.Context(), characters); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "Daily character quota exceeded")
+			return
+		}
+
+		// Analyze each section
+		results, totals, err := domain.AnalyzeSections(r.Context(), sections)
+		if err != nil {
+			writeAnalysisFailure(w, r, characters, "error analyzing document", "format", documentFormat, "error", err)
+			return
+		}
+		metrics.ObserveAnalysis(characters, totals.WordCount)
+		metrics.DocumentsTotal.WithLabelValues(documentFormat, documentOK).Inc()
+
+		recordHistory(r.Context(), domain.HistoryEntry{
+			Endpoint:       r.URL.Path,
+			Format:         documentFormat,
+			Preview:        sections[0].Text,
+			CharacterCount: characters,
+			WordCount:      totals.WordCount,
+			VowelCount:     totals.VowelCount,
+			ConsonantCount: totals.ConsonantCount,
+		})
+
+		// Write response in the negotiated format
+		render.Write(w, r, format, http.StatusOK, "document", domain.UploadAnalysisResponse{
+			Filename: filename,
+			Format:   documentFormat,
+			Sections: results,
+			Totals:   totals,
+		})
+	}
+}
+
+// readUpload reads the document in the file field of a multipart body, capped at maxBytes
+// Other fields are skipped
+func readUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) (filename, contentType string, data []byte, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", "", nil, errNoDocument
+		}
+		if err != nil {
+			return "", "", nil, uploadError(err)
+		}
+		if part.FormName() != uploadField {
+			continue
+		}
+
+		data, err = io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return "", "", nil, uploadError(err)
+		}
+		if int64(len(data)) > maxBytes {
+			return "", "", nil, errBodyTooLarge
+		}
+		return part.FileName(), part.Header.Get("Content-Type"), data, nil
+	}
+}
+
+// uploadError maps an error reading a multipart body to a request decoding error
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errBodyTooLarge
+	}
+	return fmt.Errorf("%w: %v", errMalformed, err)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+var testUploadConfig = config.UploadConfig{Enabled: true, MaxFileBytes: 256, MaxTextBytes: 128}
+
+// newUpload builds a multipart body with the given file in field, and returns it with its content type
+func newUpload(t *testing.T, field, filename, contentType, content string) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("comment", "ignored"); err != nil {
+		t.Fatalf("Failed to write field: %v", err)
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+filename+`"`)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatalf("Failed to create part: %v", err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write part: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close body: %v", err)
+	}
+	return &body, writer.FormDataContentType()
+}
+
+func TestDocumentAnalysisHandler(t *testing.T) {
+	body, contentType := newUpload(t, "file", "notes.md", "", "Intro.\n\n# Results\n\nIt **works**.")
+	req := httptest.NewRequest(http.MethodPost, DocumentsPath, body)
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	DocumentAnalysisHandler(testUploadConfig)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response domain.UploadAnalysisResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := domain.UploadAnalysisResponse{
+		Filename: "notes.md",
+		Format:   "md",
+		Sections: []domain.SectionAnalysis{
+			{CharacterCount: 6, WordCount: 1, VowelCount: 2, ConsonantCount: 3},
+			{Title: "Results", CharacterCount: 17, WordCount: 3, VowelCount: 4, ConsonantCount: 10},
+		},
+		Totals: domain.SentenceAnalysisResponse{WordCount: 4, VowelCount: 6, ConsonantCount: 13},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("Expected response %+v, got %+v", want, response)
+	}
+}
+
+func TestDocumentAnalysisHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		field       string
+		filename    string
+		contentType string
+		content     string
+		rawBody     string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "not multipart", rawBody: `{"sentence":"hi"}`, wantStatus: http.StatusUnsupportedMediaType, wantCode: problem.CodeUnsupportedMedia},
+		{name: "no file field", field: "document", filename: "a.txt", content: "text", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "unsupported type", field: "file", filename: "a.doc", contentType: "application/msword", content: "text", wantStatus: http.StatusUnsupportedMediaType, wantCode: problem.CodeUnsupportedMedia},
+		{name: "file too large", field: "file", filename: "a.txt", content: strings.Repeat("a", 257), wantStatus: http.StatusRequestEntityTooLarge, wantCode: problem.CodeBodyTooLarge},
+		{name: "text too large", field: "file", filename: "a.html", content: "<p>" + strings.Repeat("a ", 70) + "</p>", wantStatus: http.StatusRequestEntityTooLarge, wantCode: problem.CodeBodyTooLarge},
+		{name: "corrupt document", field: "file", filename: "a.docx", content: "not a zip archive", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "no text", field: "file", filename: "a.html", content: "<script>alert(1)</script>", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			var req *http.Request
+			if tt.field != "" {
+				body, contentType := newUpload(t, tt.field, tt.filename, tt.contentType, tt.content)
+				req = httptest.NewRequest(method, DocumentsPath, body)
+				req.Header.Set("Content-Type", contentType)
+			} else {
+				req = httptest.NewRequest(method, DocumentsPath, strings.NewReader(tt.rawBody))
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rr := httptest.NewRecorder()
+			DocumentAnalysisHandler(testUploadConfig)(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, p.Code)
+			}
+		})
+	}
+}
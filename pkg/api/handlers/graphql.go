@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/gql"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+)
+
+// GraphQLHandler returns a handler for the GraphQL endpoint
+// The body is one operation, or a JSON array of up to cfg.MaxBatchSize operations answered with an array
+func GraphQLHandler(limits config.InputLimits, cfg config.GraphQLConfig) http.HandlerFunc {
+	schema, schemaErr := gql.NewSchema(limits)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if schemaErr != nil {
+			slog.ErrorContext(r.Context(), "error building GraphQL schema", "error", schemaErr)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
+
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if mediaType := requestMediaType(r); mediaType != "" && mediaType != "application/json" {
+			problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia, "Supported request types: application/json")
+			return
+		}
+
+		// Parse request body
+		requests, batch, err := decodeGraphQLRequests(w, r, limits.MaxBodyBytes)
+		if err != nil {
+			writeDecodeError(w, r, err)
+			return
+		}
+		if fieldErrs := validateGraphQLRequests(requests, batch, cfg.MaxBatchSize); len(fieldErrs) > 0 {
+			problem.WriteValidation(w, r, fieldErrs)
+			return
+		}
+
+		// Run the operations in order; errors are reported per operation in the GraphQL response
+		results := make([]interface{}, len(requests))
+		for i, req := range requests {
+			ctx, span := tracing.Start(r.Context(), "graphql.execute")
+			span.SetAttributes(attribute.String("graphql.operation.name", req.OperationName))
+			results[i] = gql.Execute(ctx, schema, req)
+			span.End()
+		}
+
+		// Set response headers
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		// Write response
+		var response interface{} = results
+		if !batch {
+			response = results[0]
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+		}
+	}
+}
+
+// decodeGraphQLRequests decodes one operation, or an array of operations when batch is true
+func decodeGraphQLRequests(w http.ResponseWriter, r *http.Request, maxBytes int64) (requests []gql.Request, batch bool, err error) {
+	body, err := readBody(w, r, maxBytes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, true, errMalformed
+		}
+		return requests, true, nil
+	}
+
+	var req gql.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, errMalformed
+	}
+	return []gql.Request{req}, false, nil
+}
+
+// validateGraphQLRequests checks the batch size and that every operation has a query
+func validateGraphQLRequests(requests []gql.Request, batch bool, maxBatchSize int) []domain.FieldError {
+	if batch && (len(requests) == 0 || len(requests) > maxBatchSize) {
+		return []domain.FieldError{{Field: "body", Message: fmt.Sprintf("must contain between 1 and %d operations", maxBatchSize)}}
+	}
+
+	var errs []domain.FieldError
+	for i, req := range requests {
+		if req.Query == "" {
+			field := "query"
+			if batch {
+				field = fmt.Sprintf("[%d].query", i)
+			}
+			errs = append(errs, domain.FieldError{Field: field, Message: "is required"})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	handler := GraphQLHandler(
+		config.InputLimits{MaxBodyBytes: 512, MaxSentenceLength: 50},
+		config.GraphQLConfig{Enabled: true, MaxBatchSize: 2},
+	)
+
+	query := `{"query":"{ analyze(sentence: \"Hello World\") { wordCount } }"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		wantStatusCode int
+		wantBody       string
+		wantField      string
+	}{
+		{
+			name:           "single operation",
+			method:         http.MethodPost,
+			body:           query,
+			wantStatusCode: http.StatusOK,
+			wantBody:       `{"data":{"analyze":{"wordCount":2}}}`,
+		},
+		{
+			name:           "batch of operations",
+			method:         http.MethodPost,
+			body:           `[` + query + `, {"query":"{ analyze(sentence: \"a\") { vowelCount } }"}]`,
+			wantStatusCode: http.StatusOK,
+			wantBody:       `[{"data":{"analyze":{"wordCount":2}}},{"data":{"analyze":{"vowelCount":1}}}]`,
+		},
+		{
+			name:           "batch too large",
+			method:         http.MethodPost,
+			body:           `[` + query + `,` + query + `,` + query + `]`,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantField:      "body",
+		},
+		{
+			name:           "empty batch",
+			method:         http.MethodPost,
+			body:           `[]`,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantField:      "body",
+		},
+		{
+			name:           "missing query",
+			method:         http.MethodPost,
+			body:           `{"variables":{}}`,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantField:      "query",
+		},
+		{
+			name:           "missing query in batch",
+			method:         http.MethodPost,
+			body:           `[` + query + `, {}]`,
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantField:      "[1].query",
+		},
+		{
+			name:           "malformed body",
+			method:         http.MethodPost,
+			body:           `{"query":`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "body too large",
+			method:         http.MethodPost,
+			body:           `{"query":"` + strings.Repeat(" ", 600) + `"}`,
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "unsupported content type",
+			method:         http.MethodPost,
+			contentType:    "application/graphql",
+			body:           `{ analyze(sentence: "a") { wordCount } }`,
+			wantStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "invalid method",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/graphql", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rr.Body.String()) != tt.wantBody {
+				t.Errorf("handler returned unexpected body: got %s want %s", rr.Body.String(), tt.wantBody)
+			}
+			if tt.wantField != "" {
+				var response problem.Problem
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(response.Errors) != 1 || response.Errors[0].Field != tt.wantField {
+					t.Errorf("Expected an error for field %s, got %+v", tt.wantField, response.Errors)
+				}
+			}
+		})
+	}
+}
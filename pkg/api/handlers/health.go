@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/health"
 )
 
 // HandleHealth handles the health check endpoint
@@ -11,3 +14,30 @@ func HandleHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
 }
+
+// HandleLivez handles the liveness probe; it succeeds as long as the process can serve requests
+func HandleLivez(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, health.Live())
+}
+
+// HandleReadyz handles the readiness probe; it fails when any registered check fails,
+// including while the server is draining for shutdown
+func HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, health.Ready(r.Context()))
+}
+
+func writeHealthReport(w http.ResponseWriter, r *http.Request, report health.Report) {
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	// Probes must always see the current state
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+	}
+}
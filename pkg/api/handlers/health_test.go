@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/health"
+)
+
+func TestHealthProbes(t *testing.T) {
+	defer health.SetDraining(false)
+	defer health.Unregister("failing")
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		setup          func()
+		wantStatusCode int
+	}{
+		{"liveness", HandleLivez, func() {}, http.StatusOK},
+		{"readiness", HandleReadyz, func() {}, http.StatusOK},
+		{"readiness while draining", HandleReadyz, func() { health.SetDraining(true) }, http.StatusServiceUnavailable},
+		{"liveness while draining", HandleLivez, func() { health.SetDraining(true) }, http.StatusOK},
+		{"readiness with failing check", HandleReadyz, func() {
+			health.Register("failing", func(context.Context) error { return errors.New("down") })
+		}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.SetDraining(false)
+			health.Unregister("failing")
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rr := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+
+			var report health.Report
+			if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if report.OK() != (tt.wantStatusCode == http.StatusOK) {
+				t.Errorf("Expected report status to match the status code, got %q", report.Status)
+			}
+			if report.Version.Version == "" {
+				t.Error("Expected version info in the response")
+			}
+			if strings.Contains(rr.Body.String(), "down") {
+				t.Errorf("Expected no check errors in the response, got %s", rr.Body.String())
+			}
+		})
+	}
+}
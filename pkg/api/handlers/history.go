@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/render"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+)
+
+// HistoryPath is the path the client's past analyses are listed at; each entry is served below it
+const HistoryPath = "/history"
+
+// HistoryRetentionPath is the path how long the client's history is kept is served at
+const HistoryRetentionPath = HistoryPath + "/retention"
+
+// Page sizes of the history
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// HistoryHandler returns a handler for the authenticated client's history: listing a page of entries,
+// newest first (GET), and deleting every entry (DELETE); both may be restricted to a date range
+func HistoryHandler(cfg config.HistoryConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			listHistory(w, r, cfg)
+		case http.MethodDelete:
+			clearHistory(w, r)
+		default:
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// HistoryEntryHandler returns a handler for a single entry of the authenticated client's history:
+// reading it (GET /history/{id}) and deleting it (DELETE /history/{id})
+func HistoryEntryHandler(cfg config.HistoryConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		segment, ok := pathID(r.URL.Path, HistoryPath, "")
+		id, err := strconv.ParseInt(segment, 10, 64)
+		if !ok || err != nil || id <= 0 {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "History entry not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			getHistoryEntry(w, r, id, cfg)
+		case http.MethodDelete:
+			deleteHistoryEntry(w, r, id)
+		default:
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// HistoryRetentionHandler returns a handler for how long the authenticated client's history is kept:
+// reading it (GET) and setting it (PUT)
+func HistoryRetentionHandler(limits config.InputLimits, cfg config.HistoryConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			getHistoryRetention(w, r, cfg)
+		case http.MethodPut:
+			setHistoryRetention(w, r, limits, cfg)
+		default:
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// listHistory writes the page of the client's history selected by the query parameters
+// The next page, if any, is linked from the Link header
+func listHistory(w http.ResponseWriter, r *http.Request, cfg config.HistoryConfig) {
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+	query, fieldErrs := historyQuery(r.URL.Query())
+	if len(fieldErrs) > 0 {
+		problem.WriteValidation(w, r, fieldErrs)
+		return
+	}
+
+	l, err := history.GetStore().Get(authInfo.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error reading history", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Entries past the client's retention are hidden until they are pruned
+	if cutoff := l.Cutoff(time.Now().UTC(), cfg.RetentionDays); cutoff.After(query.From) {
+		query.From = cutoff
+	}
+	entries, more := l.Page(query)
+	if more {
+		next := r.URL.Query()
+		next.Set("cursor", strconv.FormatInt(entries[len(entries)-1].ID, 10))
+		w.Header().Add("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+	}
+	render.Write(w, r, format, http.StatusOK, "history", entries)
+}
+
+// clearHistory deletes the entries of the client's history in the date range of the query parameters,
+// every entry when there is none
+func clearHistory(w http.ResponseWriter, r *http.Request) {
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+	from, to, fieldErrs := historyRange(r.URL.Query())
+	if len(fieldErrs) > 0 {
+		problem.WriteValidation(w, r, fieldErrs)
+		return
+	}
+
+	if _, err := history.GetStore().Update(authInfo.UserID, func(l *history.Log) error {
+		l.RemoveRange(from, to)
+		return nil
+	}); err != nil {
+		slog.ErrorContext(r.Context(), "error clearing history", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getHistoryEntry writes an entry of the client's history
+func getHistoryEntry(w http.ResponseWriter, r *http.Request, id int64, cfg config.HistoryConfig) {
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	l, err := history.GetStore().Get(authInfo.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error reading history", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	cutoff := l.Cutoff(time.Now().UTC(), cfg.RetentionDays)
+	for _, entry := range l.Entries {
+		if entry.ID == id && !entry.CreatedAt.Before(cutoff) {
+			render.Write(w, r, format, http.StatusOK, "entry", entry)
+			return
+		}
+	}
+	problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "History entry not found")
+}
+
+// deleteHistoryEntry deletes an entry of the client's history
+func deleteHistoryEntry(w http.ResponseWriter, r *http.Request, id int64) {
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	_, err := history.GetStore().Update(authInfo.UserID, func(l *history.Log) error {
+		return l.Remove(id)
+	})
+	switch {
+	case errors.Is(err, history.ErrEntryNotFound):
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "History entry not found")
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "error deleting history entry", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getHistoryRetention writes how long the client's history is kept
+func getHistoryRetention(w http.ResponseWriter, r *http.Request, cfg config.HistoryConfig) {
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	l, err := history.GetStore().Get(authInfo.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error reading history", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	render.Write(w, r, format, http.StatusOK, "retention", domain.HistoryRetention{
+		RetentionDays:    l.Retention(cfg.RetentionDays),
+		MaxRetentionDays: cfg.RetentionDays,
+	})
+}
+
+// setHistoryRetention sets how long the client's history is kept and drops the entries it no longer keeps
+func setHistoryRetention(w http.ResponseWriter, r *http.Request, limits config.InputLimits, cfg config.HistoryConfig) {
+	format, ok := render.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		render.NotAcceptable(w, r)
+		return
+	}
+	authInfo, ok := auth.GetAuthInfo(r.Context())
+	if !ok {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+		return
+	}
+
+	switch requestMediaType(r) {
+	case "", "application/json":
+	default:
+		problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+			"Supported request types: application/json")
+		return
+	}
+	var req domain.HistoryRetentionRequest
+	if err := decodeJSONBody(w, r, &req, limits.MaxBodyBytes); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if fieldErrs := req.Validate(cfg.RetentionDays); len(fieldErrs) > 0 {
+		problem.WriteValidation(w, r, fieldErrs)
+		return
+	}
+
+	l, err := history.GetStore().Update(authInfo.UserID, func(l *history.Log) error {
+		l.RetentionDays = req.RetentionDays
+		l.Expire(l.Cutoff(time.Now().UTC(), cfg.RetentionDays))
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error setting history retention", "error", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+	render.Write(w, r, format, http.StatusOK, "retention", domain.HistoryRetention{
+		RetentionDays:    l.Retention(cfg.RetentionDays),
+		MaxRetentionDays: cfg.RetentionDays,
+	})
+}
+
+// historyQuery parses the limit, cursor, from and to query parameters of a history page
+func historyQuery(values url.Values) (history.Query, []domain.FieldError) {
+	query := history.Query{Limit: defaultHistoryLimit}
+	var errs []domain.FieldError
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxHistoryLimit {
+			errs = append(errs, domain.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxHistoryLimit)})
+		}
+		query.Limit = limit
+	}
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			errs = append(errs, domain.FieldError{Field: "cursor", Message: "must be a cursor from a previous page"})
+		}
+		query.Before = cursor
+	}
+
+	from, to, rangeErrs := historyRange(values)
+	query.From, query.To = from, to
+	return query, append(errs, rangeErrs...)
+}
+
+// historyRange parses the from and to query parameters, each an RFC 3339 time or a date
+// A from date starts at the beginning of the day and a to date ends at the end of it, both in UTC,
+// so from=2026-10-18&to=2026-10-18 is the whole of that day
+func historyRange(values url.Values) (from, to time.Time, errs []domain.FieldError) {
+	from, fromOK := parseHistoryTime(values.Get("from"), false)
+	if !fromOK {
+		errs = append(errs, domain.FieldError{Field: "from", Message: "must be an RFC 3339 time or a YYYY-MM-DD date"})
+	}
+	to, toOK := parseHistoryTime(values.Get("to"), true)
+	if !toOK {
+		errs = append(errs, domain.FieldError{Field: "to", Message: "must be an RFC 3339 time or a YYYY-MM-DD date"})
+	}
+	if fromOK && toOK && !from.IsZero() && !to.IsZero() && !to.After(from) {
+		errs = append(errs, domain.FieldError{Field: "to", Message: "must be after from"})
+	}
+	return from, to, errs
+}
+
+// parseHistoryTime parses a time or date query parameter; an empty one is the zero time
+// A date is the start of the day, or of the next day when endOfDay is set
+func parseHistoryTime(value string, endOfDay bool) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+// recordHistory adds an analysis to the client's history when the request carries a history recorder
+// A history that cannot be written does not fail the analysis
+func recordHistory(ctx context.Context, entry domain.HistoryEntry) {
+	if err := history.Record(ctx, entry); err != nil {
+		slog.WarnContext(ctx, "error recording analysis history", "error", err)
+	}
+}
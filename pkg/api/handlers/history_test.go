@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+)
+
+var testHistoryConfig = config.HistoryConfig{Enabled: true, RetentionDays: 30, MaxEntries: 100}
+
+// useMemoryHistory swaps in an empty history store for the duration of a test
+func useMemoryHistory(t *testing.T) {
+	t.Helper()
+
+	original := history.GetStore()
+	history.SetStore(history.NewMemoryStore())
+	t.Cleanup(func() { history.SetStore(original) })
+}
+
+// seedHistory adds an entry to alice's history for each of the given days before now, oldest first
+func seedHistory(t *testing.T, daysAgo ...int) {
+	t.Helper()
+
+	now := time.Now().UTC()
+	if _, err := history.GetStore().Update("user:alice", func(l *history.Log) error {
+		for _, days := range daysAgo {
+			l.Add(domain.HistoryEntry{CreatedAt: now.AddDate(0, 0, -days), Endpoint: "/v1/analyze"}, 100)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+}
+
+// historyRequest sends a request to the history handlers as alice
+func historyRequest(method, target, body string) *httptest.ResponseRecorder {
+	handler := HistoryHandler(testHistoryConfig)
+	switch path, _, _ := strings.Cut(target, "?"); {
+	case path == "/v1"+HistoryRetentionPath:
+		handler = HistoryRetentionHandler(config.DefaultInputLimits(), testHistoryConfig)
+	case strings.HasPrefix(path, "/v1"+HistoryPath+"/"):
+		handler = HistoryEntryHandler(testHistoryConfig)
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rr := httptest.NewRecorder()
+	asUser("user:alice", handler)(rr, req)
+	return rr
+}
+
+// decodeHistory decodes a list of history entries and returns their IDs
+func decodeHistory(t *testing.T, rr *httptest.ResponseRecorder) []int64 {
+	t.Helper()
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var entries []domain.HistoryEntry
+	if err := json.NewDecoder(rr.Body).Decode(&entries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	ids := []int64{}
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestAnalyzeRecordsHistory(t *testing.T) {
+	useMemoryHistory(t)
+
+	handler := asUser("user:alice", func(w http.ResponseWriter, r *http.Request) {
+		rec := history.NewRecorder("user:alice", 30, 100)
+		AnalyzeSentenceV2Handler(config.DefaultInputLimits())(w, r.WithContext(history.WithRecorder(r.Context(), rec)))
+	})
+	req := httptest.NewRequest(http.MethodPost, "/v2/analyze", strings.NewReader(`{"sentence":"Hello **world**","format":"markdown"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	l, _ := history.GetStore().Get("user:alice")
+	if len(l.Entries) != 1 {
+		t.Fatalf("Expected one recorded analysis, got %d", len(l.Entries))
+	}
+	entry := l.Entries[0]
+	want := domain.HistoryEntry{
+		ID:             1,
+		CreatedAt:      entry.CreatedAt,
+		Endpoint:       "/v2/analyze",
+		Format:         "markdown",
+		Preview:        "Hello **world**",
+		CharacterCount: 15,
+		WordCount:      2,
+		VowelCount:     3,
+		ConsonantCount: 7,
+	}
+	if entry != want {
+		t.Errorf("Recorded %+v, want %+v", entry, want)
+	}
+
+	// Analyses are not recorded without a Recorder, as when history is disabled
+	rr = httptest.NewRecorder()
+	AnalyzeSentenceHandler(config.DefaultInputLimits())(rr, httptest.NewRequest(http.MethodPost, "/v1/analyze", strings.NewReader(`{"sentence":"Hi"}`)))
+	if l, _ := history.GetStore().Get("user:alice"); len(l.Entries) != 1 {
+		t.Errorf("Expected no entry recorded without a Recorder, got %d entries", len(l.Entries))
+	}
+}
+
+func TestAnalysisEndpointsRecordHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		body    string
+		want    []string
+	}{
+		{
+			name:    "compare records both texts",
+			handler: CompareHandler(config.DefaultInputLimits()),
+			path:    "/v1" + ComparePath,
+			body:    `{"original":"Hello world","revised":"Hello there world"}`,
+			want:    []string{"Hello world", "Hello there world"},
+		},
+		{
+			name:    "corpus document",
+			handler: CorpusHandler(config.DefaultInputLimits(), testCorpusConfig),
+			path:    "/v1" + CorporaPath + "/essays" + corpusDocumentsSuffix,
+			body:    `{"text":"The cat sat."}`,
+			want:    []string{"The cat sat."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemoryHistory(t)
+			useMemoryCorpora(t)
+
+			handler := asUser("user:alice", func(w http.ResponseWriter, r *http.Request) {
+				rec := history.NewRecorder("user:alice", 30, 100)
+				tt.handler(w, r.WithContext(history.WithRecorder(r.Context(), rec)))
+			})
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			if rr.Code >= 300 {
+				t.Fatalf("Expected success, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			l, _ := history.GetStore().Get("user:alice")
+			var previews []string
+			for _, entry := range l.Entries {
+				if entry.Endpoint != tt.path || entry.WordCount == 0 {
+					t.Errorf("Recorded %+v, want an analysis of %s", entry, tt.path)
+				}
+				previews = append(previews, entry.Preview)
+			}
+			if !reflect.DeepEqual(previews, tt.want) {
+				t.Errorf("Recorded previews %q, want %q", previews, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryHandlerPages(t *testing.T) {
+	useMemoryHistory(t)
+	// Entries 1 and 2 are past the 30-day retention and no longer listed
+	seedHistory(t, 40, 31, 3, 2, 1, 0)
+
+	rr := historyRequest(http.MethodGet, "/v1/history?limit=2", "")
+	if got := decodeHistory(t, rr); !reflect.DeepEqual(got, []int64{6, 5}) {
+		t.Errorf("Expected the newest entries 6 and 5, got %v", got)
+	}
+	next := rr.Header().Get("Link")
+	if next != `</v1/history?cursor=5&limit=2>; rel="next"` {
+		t.Fatalf("Expected a link to the next page, got %q", next)
+	}
+
+	rr = historyRequest(http.MethodGet, strings.TrimSuffix(strings.TrimPrefix(next, "<"), `>; rel="next"`), "")
+	if got := decodeHistory(t, rr); !reflect.DeepEqual(got, []int64{4, 3}) {
+		t.Errorf("Expected entries 4 and 3 on the last page, got %v", got)
+	}
+	if link := rr.Header().Get("Link"); link != "" {
+		t.Errorf("Expected no link after the last page, got %q", link)
+	}
+
+	// A date range ending on a day includes all of it
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	rr = historyRequest(http.MethodGet, "/v1/history?from="+yesterday+"&to="+yesterday, "")
+	if got := decodeHistory(t, rr); !reflect.DeepEqual(got, []int64{5}) {
+		t.Errorf("Expected yesterday's entry 5, got %v", got)
+	}
+
+	rr = historyRequest(http.MethodGet, "/v1/history/4", "")
+	var entry domain.HistoryEntry
+	if err := json.NewDecoder(rr.Body).Decode(&entry); err != nil || rr.Code != http.StatusOK || entry.ID != 4 {
+		t.Errorf("Expected entry 4, got status %d: %+v", rr.Code, entry)
+	}
+	if rr := historyRequest(http.MethodGet, "/v1/history/1", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected an expired entry to be not found, got status %d", rr.Code)
+	}
+}
+
+func TestHistoryHandlerDeletes(t *testing.T) {
+	useMemoryHistory(t)
+	seedHistory(t, 3, 2, 1, 0)
+
+	if rr := historyRequest(http.MethodDelete, "/v1/history/4", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if rr := historyRequest(http.MethodDelete, "/v1/history/4", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected deleting twice to be not found, got status %d", rr.Code)
+	}
+
+	// Clearing a range keeps the entries outside it
+	to := time.Now().UTC().AddDate(0, 0, -2).Format(time.DateOnly)
+	if rr := historyRequest(http.MethodDelete, "/v1/history?to="+to, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if got := decodeHistory(t, historyRequest(http.MethodGet, "/v1/history", "")); !reflect.DeepEqual(got, []int64{3}) {
+		t.Errorf("Expected only entry 3 to be left, got %v", got)
+	}
+
+	if rr := historyRequest(http.MethodDelete, "/v1/history", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if got := decodeHistory(t, historyRequest(http.MethodGet, "/v1/history", "")); len(got) != 0 {
+		t.Errorf("Expected an empty history, got %v", got)
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	useMemoryHistory(t)
+	seedHistory(t, 10, 1)
+
+	decode := func(rr *httptest.ResponseRecorder) domain.HistoryRetention {
+		t.Helper()
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var retention domain.HistoryRetention
+		if err := json.NewDecoder(rr.Body).Decode(&retention); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return retention
+	}
+
+	if got := decode(historyRequest(http.MethodGet, "/v1/history/retention", "")); got != (domain.HistoryRetention{RetentionDays: 30, MaxRetentionDays: 30}) {
+		t.Errorf("Expected the server's retention by default, got %+v", got)
+	}
+
+	// Shortening the retention drops the entries it no longer keeps
+	if got := decode(historyRequest(http.MethodPut, "/v1/history/retention", `{"retention_days":7}`)); got.RetentionDays != 7 {
+		t.Errorf("Expected a 7-day retention, got %+v", got)
+	}
+	if l, _ := history.GetStore().Get("user:alice"); len(l.Entries) != 1 || l.Entries[0].ID != 2 {
+		t.Errorf("Expected only entry 2 to be kept, got %+v", l.Entries)
+	}
+	if got := decode(historyRequest(http.MethodGet, "/v1/history/retention", "")); got.RetentionDays != 7 {
+		t.Errorf("Expected the 7-day retention to be kept, got %+v", got)
+	}
+}
+
+func TestHistoryHandlerErrors(t *testing.T) {
+	useMemoryHistory(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "limit too large", method: http.MethodGet, target: "/v1/history?limit=101", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "invalid cursor", method: http.MethodGet, target: "/v1/history?cursor=abc", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "invalid date", method: http.MethodGet, target: "/v1/history?from=yesterday", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "range ends before it starts", method: http.MethodDelete, target: "/v1/history?from=2026-10-19&to=2026-10-18", wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "list wrong method", method: http.MethodPost, target: "/v1/history", wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "unknown entry", method: http.MethodGet, target: "/v1/history/7", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+		{name: "invalid entry ID", method: http.MethodGet, target: "/v1/history/abc", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+		{name: "nested path", method: http.MethodGet, target: "/v1/history/retention/7", wantStatus: http.StatusNotFound, wantCode: problem.CodeNotFound},
+		{name: "entry wrong method", method: http.MethodPut, target: "/v1/history/1", wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "retention wrong method", method: http.MethodDelete, target: "/v1/history/retention", wantStatus: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "retention above maximum", method: http.MethodPut, target: "/v1/history/retention", body: `{"retention_days":31}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "retention missing", method: http.MethodPut, target: "/v1/history/retention", body: `{}`, wantStatus: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "retention malformed", method: http.MethodPut, target: "/v1/history/retention", body: `{"retention_days":`, wantStatus: http.StatusBadRequest, wantCode: problem.CodeInvalidBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := historyRequest(tt.method, tt.target, tt.body)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, p.Code)
+			}
+		})
+	}
+}
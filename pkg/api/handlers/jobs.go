@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/jobs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// JobsPath is the path long-running analyses are started on; a job's events are at JobsPath/{id}/events
+// Like every API path it is also served under a version prefix
+const JobsPath = "/analyze/jobs"
+
+// Job event types sent on the event stream
+const (
+	JobEventProgress = "progress"
+	JobEventResult   = "result"
+	JobEventFailed   = "failed"
+)
+
+// progressEvents bounds the progress events a job publishes, however many sentences it has
+const progressEvents = 100
+
+// jobRetryMillis is the reconnection delay suggested to EventSource clients
+const jobRetryMillis = 2000
+
+// AnalysisJobResponse represents the response body for a started job
+type AnalysisJobResponse struct {
+	ID        string `json:"id"`
+	EventsURL string `json:"events_url"`
+}
+
+// JobFailure is the data of a failed event
+type JobFailure struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// runningJobs holds the cancel functions of the jobs still running so they can be stopped on shutdown
+var runningJobs = struct {
+	sync.Mutex
+	cancels map[string]context.CancelFunc
+}{cancels: make(map[string]context.CancelFunc)}
+
+// AnalysisJobsHandler returns a handler that starts a long-running analysis of a document or a
+// batch of sentences; the client follows it on the job's event stream
+func AnalysisJobsHandler(limits config.InputLimits, cfg config.JobsConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		authInfo, ok := auth.GetAuthInfo(r.Context())
+		if !ok {
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeNoToken, "Authentication required")
+			return
+		}
+
+		// Parse request body; jobs take larger bodies than /analyze
+		req, err := decodeAnalysisJobRequest(w, r, cfg.MaxBodyBytes)
+		if err != nil {
+			writeDecodeError(w, r, err)
+			return
+		}
+
+		// Validate the request
+		if fieldErrs := req.Validate(limits.MaxSentenceLength, cfg.MaxSentences); len(fieldErrs) > 0 {
+			problem.WriteValidation(w, r, fieldErrs)
+			return
+		}
+
+		// Charge the whole job against the client's daily character quota up front
+		characters := utf8.RuneCountInString(req.Text)
+		for _, sentence := range req.Sentences {
+			characters += utf8.RuneCountInString(sentence)
+		}
+		if retryAfter, err := ratelimit.ChargeCharacters(r.Context(), characters); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "Daily character quota exceeded")
+			return
+		}
+
+		// Each client may only have so many jobs running, and kept for replay, at once
+		job, err := jobs.New(authInfo.UserID)
+		if err == nil {
+			err = jobs.GetStore().Create(job, jobs.Limits{Running: cfg.MaxRunning, Retained: cfg.MaxRetained})
+		}
+		switch {
+		case errors.Is(err, jobs.ErrTooManyJobs):
+			ratelimit.RefundCharacters(r.Context(), characters)
+			problem.Write(w, r, http.StatusTooManyRequests, problem.CodeTooManyJobs,
+				fmt.Sprintf("At most %d running and %d kept jobs are allowed", cfg.MaxRunning, cfg.MaxRetained))
+			return
+		case err != nil:
+			writeAnalysisFailure(w, r, characters, "error creating analysis job", "error", err)
+			return
+		}
+
+		sentences := req.Sentences
+		if req.Text != "" {
+			sentences = domain.SplitSentences(r.Context(), req.Text)
+		}
+
+		// The job outlives the request but keeps its trace and client
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		runningJobs.Lock()
+		runningJobs.cancels[job.ID] = cancel
+		runningJobs.Unlock()
+		go runAnalysisJob(ctx, job, sentences, domain.HistoryEntry{
+			Endpoint:       r.URL.Path,
+			Preview:        strings.Join(sentences, " "),
+			CharacterCount: characters,
+		})
+
+		// Write response; the events are served under the same version prefix as the request
+		eventsURL := strings.TrimSuffix(r.URL.Path, "/") + "/" + job.ID + "/events"
+		w.Header().Set("Location", eventsURL)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(AnalysisJobResponse{ID: job.ID, EventsURL: eventsURL}); err != nil {
+			slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+		}
+	}
+}
+
+// runAnalysisJob analyzes the sentences of a job, publishing progress as it goes and the result at the end
+// entry describes the job for the client's history; its counts are filled in once the job has finished
+func runAnalysisJob(ctx context.Context, job *jobs.Job, sentences []string, entry domain.HistoryEntry) {
+	metrics.JobsRunning.Inc()
+	defer func() {
+		runningJobs.Lock()
+		delete(runningJobs.cancels, job.ID)
+		runningJobs.Unlock()
+		metrics.JobsRunning.Dec()
+	}()
+
+	step := len(sentences)/progressEvents + 1
+	result, err := domain.AnalyzeSentences(ctx, sentences, func(progress domain.AnalysisProgress) {
+		if progress.SentencesDone%step == 0 || progress.SentencesDone == progress.SentencesTotal {
+			job.Publish(JobEventProgress, progress)
+		}
+	})
+	if err != nil {
+		// The client is not charged for a job that did not finish
+		ratelimit.RefundCharacters(ctx, entry.CharacterCount)
+		slog.WarnContext(ctx, "analysis job stopped", "job_id", job.ID, "error", err)
+		job.Finish(JobEventFailed, JobFailure{Code: problem.CodeInternal, Message: "Analysis was interrupted"})
+		metrics.JobsTotal.WithLabelValues(JobEventFailed).Inc()
+		return
+	}
+
+	metrics.ObserveAnalysis(entry.CharacterCount, result.Totals.WordCount)
+	entry.WordCount = result.Totals.WordCount
+	entry.VowelCount = result.Totals.VowelCount
+	entry.ConsonantCount = result.Totals.ConsonantCount
+	recordHistory(ctx, entry)
+	job.Finish(JobEventResult, result)
+	metrics.JobsTotal.WithLabelValues(JobEventResult).Inc()
+}
+
+// CancelAnalysisJobs stops the running jobs so their event streams end and shutdown is not held up
+func CancelAnalysisJobs() {
+	runningJobs.Lock()
+	defer runningJobs.Unlock()
+
+	for _, cancel := range runningJobs.cancels {
+		cancel()
+	}
+}
+
+// AnalysisJobEventsHandler returns a handler that streams a job's events as Server-Sent Events
+// at JobsPath/{id}/events, for clients that cannot keep a WebSocket open through proxies
+// Events after the Last-Event-ID header, or the last_event_id query parameter, are replayed first
+func AnalysisJobEventsHandler(cfg config.JobsConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow GET method
+		if r.Method != http.MethodGet {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		id, ok := pathID(r.URL.Path, JobsPath, "/events")
+		if !ok {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Job not found")
+			return
+		}
+
+		// Another client's job is reported as missing so job IDs cannot be probed
+		job, err := jobs.GetStore().Get(id)
+		if err != nil && !errors.Is(err, jobs.ErrJobNotFound) {
+			slog.ErrorContext(r.Context(), "error getting analysis job", "error", err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
+		authInfo, authenticated := auth.GetAuthInfo(r.Context())
+		if !authenticated || job == nil || job.Owner != authInfo.UserID {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Job not found")
+			return
+		}
+
+		lastID, fieldErrs := lastEventID(r)
+		if len(fieldErrs) > 0 {
+			problem.WriteValidation(w, r, fieldErrs)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			slog.ErrorContext(r.Context(), "response writer does not support streaming")
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
+
+		// A client that already has every event of a finished job is told not to reconnect
+		events, finished, changed := job.EventsAfter(lastID)
+		if finished && len(events) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Ask proxies such as nginx not to buffer the stream
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", jobRetryMillis)
+
+		metrics.EventStreams.Inc()
+		defer metrics.EventStreams.Dec()
+
+		keepAlive := time.NewTicker(cfg.KeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			for _, event := range events {
+				if err := writeEvent(w, event); err != nil {
+					slog.DebugContext(r.Context(), "error writing job event", "error", err)
+					return
+				}
+				lastID = event.ID
+			}
+			flusher.Flush()
+			if finished {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				// A comment keeps idle connections open through proxies with read timeouts
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-changed:
+			}
+			events, finished, changed = job.EventsAfter(lastID)
+		}
+	}
+}
+
+// lastEventID returns the ID of the last event the client received, or 0 for a new stream
+func lastEventID(r *http.Request) (int64, []domain.FieldError) {
+	field, value := "Last-Event-ID", r.Header.Get("Last-Event-ID")
+	if value == "" {
+		field, value = "last_event_id", r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, []domain.FieldError{{Field: field, Message: "must be a non-negative integer"}}
+	}
+	return id, nil
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, event jobs.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// decodeAnalysisJobRequest decodes a job request from a JSON body, or from a
+// text/plain body that holds the raw document
+func decodeAnalysisJobRequest(w http.ResponseWriter, r *http.Request, maxBytes int64) (domain.AnalysisJobRequest, error) {
+	var req domain.AnalysisJobRequest
+
+	switch requestMediaType(r) {
+	case "", "application/json":
+		err := decodeJSONBody(w, r, &req, maxBytes)
+		return req, err
+	case "text/plain":
+		body, err := readBody(w, r, maxBytes)
+		req.Text = string(body)
+		return req, err
+	default:
+		return req, &unsupportedMediaError{Supported: jsonOrPlainText}
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/jobs"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+var testJobsConfig = config.JobsConfig{
+	Enabled:      true,
+	MaxBodyBytes: 1024,
+	MaxSentences: 3,
+	Retention:    time.Minute,
+	KeepAlive:    time.Minute,
+}
+
+// sseEvent is one event read from an event stream
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// asUser runs handler as the given authenticated user
+func asUser(userID string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(auth.WithAuthInfo(r.Context(), &auth.AuthInfo{UserID: userID})))
+	}
+}
+
+// startJob starts a job as alice and returns its events URL
+func startJob(t *testing.T, body string) string {
+	t.Helper()
+
+	handler := asUser("user:alice", AnalysisJobsHandler(config.InputLimits{MaxBodyBytes: 16, MaxSentenceLength: 20}, testJobsConfig))
+	req := httptest.NewRequest(http.MethodPost, JobsPath, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+	var response AnalysisJobResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.EventsURL != JobsPath+"/"+response.ID+"/events" || rr.Header().Get("Location") != response.EventsURL {
+		t.Errorf("Unexpected events URL %q (Location %q)", response.EventsURL, rr.Header().Get("Location"))
+	}
+	return response.EventsURL
+}
+
+// readEvents follows an event stream as the given user until the server ends it
+func readEvents(t *testing.T, userID, eventsURL, lastEventID string) (*http.Response, []sseEvent) {
+	t.Helper()
+
+	server := httptest.NewServer(asUser(userID, AnalysisJobEventsHandler(testJobsConfig)))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+eventsURL, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return resp, nil
+	}
+
+	var events []sseEvent
+	var event sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		field, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Type = value
+		case "data":
+			event.Data = value
+		case "":
+			if event.Type != "" {
+				events = append(events, event)
+			}
+			event = sseEvent{}
+		}
+	}
+	return resp, events
+}
+
+func TestAnalysisJobEvents(t *testing.T) {
+	eventsURL := startJob(t, `{"text":"Hello World. Hi!"}`)
+
+	resp, events := readEvents(t, "user:alice", eventsURL, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected content type text/event-stream, got %q", got)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected two progress events and a result, got %+v", events)
+	}
+	for i, wantType := range []string{JobEventProgress, JobEventProgress, JobEventResult} {
+		if events[i].Type != wantType || events[i].ID != strconv.Itoa(i+1) {
+			t.Errorf("Expected event %d to be %s, got %+v", i+1, wantType, events[i])
+		}
+	}
+
+	var progress domain.AnalysisProgress
+	if err := json.Unmarshal([]byte(events[0].Data), &progress); err != nil {
+		t.Fatalf("Failed to decode progress: %v", err)
+	}
+	wantProgress := domain.AnalysisProgress{BytesProcessed: 12, BytesTotal: 15, SentencesDone: 1, SentencesTotal: 2,
+		Totals: domain.SentenceAnalysisResponse{WordCount: 2, VowelCount: 3, ConsonantCount: 7}}
+	if !reflect.DeepEqual(progress, wantProgress) {
+		t.Errorf("Expected progress %+v, got %+v", wantProgress, progress)
+	}
+
+	var result domain.DocumentAnalysisResponse
+	if err := json.Unmarshal([]byte(events[2].Data), &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if want := (domain.SentenceAnalysisResponse{WordCount: 3, VowelCount: 4, ConsonantCount: 8}); !reflect.DeepEqual(result.Totals, want) {
+		t.Errorf("Expected totals %+v, got %+v", want, result.Totals)
+	}
+
+	// Resuming replays only the events after the last one received
+	_, events = readEvents(t, "user:alice", eventsURL, "2")
+	if len(events) != 1 || events[0].Type != JobEventResult {
+		t.Errorf("Expected only the result after event 2, got %+v", events)
+	}
+
+	// A client with every event is told to stop reconnecting
+	if resp, _ := readEvents(t, "user:alice", eventsURL, "3"); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	// Other clients cannot follow the job
+	if resp, _ := readEvents(t, "user:bob", eventsURL, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	if resp, _ := readEvents(t, "user:alice", eventsURL, "abc"); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	if resp, _ := readEvents(t, "user:alice", JobsPath+"/missing/events", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestAnalysisJobsHandlerBatch(t *testing.T) {
+	eventsURL := startJob(t, `{"sentences":["Hello World","Hi"]}`)
+
+	_, events := readEvents(t, "user:alice", eventsURL, "")
+	if len(events) == 0 || events[len(events)-1].Type != JobEventResult {
+		t.Fatalf("Expected the stream to end with a result, got %+v", events)
+	}
+	var result domain.DocumentAnalysisResponse
+	if err := json.Unmarshal([]byte(events[len(events)-1].Data), &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if len(result.Sentences) != 2 || result.Totals.WordCount != 3 {
+		t.Errorf("Expected 2 sentences and 3 words, got %+v", result)
+	}
+}
+
+func TestAnalysisJobsHandlerErrors(t *testing.T) {
+	handler := asUser("user:alice", AnalysisJobsHandler(config.InputLimits{MaxBodyBytes: 16, MaxSentenceLength: 20}, testJobsConfig))
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		wantStatusCode int
+		wantCode       string
+	}{
+		{name: "wrong method", method: http.MethodGet, wantStatusCode: http.StatusMethodNotAllowed, wantCode: problem.CodeMethodNotAllowed},
+		{name: "empty request", method: http.MethodPost, body: `{}`, wantStatusCode: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "too many sentences", method: http.MethodPost, body: `{"sentences":["a","b","c","d"]}`, wantStatusCode: http.StatusUnprocessableEntity, wantCode: problem.CodeValidationFailed},
+		{name: "body over the job limit", method: http.MethodPost, contentType: "text/plain", body: strings.Repeat("a ", 600), wantStatusCode: http.StatusRequestEntityTooLarge, wantCode: problem.CodeBodyTooLarge},
+		{name: "unsupported type", method: http.MethodPost, contentType: "application/xml", body: `<text/>`, wantStatusCode: http.StatusUnsupportedMediaType, wantCode: problem.CodeUnsupportedMedia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, JobsPath, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatusCode, rr.Code, rr.Body.String())
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %s, got %s", tt.wantCode, p.Code)
+			}
+		})
+	}
+}
+
+func TestAnalysisJobsHandlerLimits(t *testing.T) {
+	original := jobs.GetStore()
+	defer jobs.SetStore(original)
+	jobs.SetStore(jobs.NewMemoryStore())
+
+	cfg := testJobsConfig
+	cfg.MaxRunning = 1
+	cfg.MaxRetained = 5
+	handler := asUser("user:alice", AnalysisJobsHandler(config.DefaultInputLimits(), cfg))
+
+	// Alice already has a job running
+	running, _ := jobs.New("user:alice")
+	if err := jobs.GetStore().Create(running, jobs.Limits{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	limiter := ratelimit.NewLimiter()
+	req := httptest.NewRequest(http.MethodPost, JobsPath, strings.NewReader(`{"text":"Hello World."}`))
+	req = req.WithContext(ratelimit.WithQuota(req.Context(), ratelimit.NewQuota(limiter, "user:alice", 0)))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusTooManyRequests, rr.Code, rr.Body.String())
+	}
+	var p problem.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil || p.Code != problem.CodeTooManyJobs {
+		t.Errorf("Expected code %q, got %+v (%v)", problem.CodeTooManyJobs, p, err)
+	}
+	if used := limiter.Used("user:alice"); used != 0 {
+		t.Errorf("Expected a refused job not to be charged, got %d characters", used)
+	}
+
+	// Other clients are not held back by alice's jobs
+	bob := asUser("user:bob", AnalysisJobsHandler(config.DefaultInputLimits(), cfg))
+	rr = httptest.NewRecorder()
+	bob(rr, httptest.NewRequest(http.MethodPost, JobsPath, strings.NewReader(`{"text":"Hello World."}`)))
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("Expected status code %d for another client, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+}
+
+func TestCancelAnalysisJobs(t *testing.T) {
+	job, err := jobs.New("user:alice")
+	if err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runningJobs.Lock()
+	runningJobs.cancels[job.ID] = cancel
+	runningJobs.Unlock()
+
+	CancelAnalysisJobs()
+	runAnalysisJob(ctx, job, []string{"Hello"}, domain.HistoryEntry{CharacterCount: 5})
+
+	events, finished, _ := job.EventsAfter(0)
+	if !finished || len(events) != 1 || events[0].Type != JobEventFailed {
+		t.Errorf("Expected a cancelled job to end with a failed event, got %+v", events)
+	}
+}
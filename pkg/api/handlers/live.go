@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+)
+
+// LiveSubprotocol is the WebSocket subprotocol spoken by the live analysis endpoint
+const LiveSubprotocol = "analysis.v1"
+
+// Live analysis message types
+const (
+	LiveMessageReset    = "reset"
+	LiveMessageEdit     = "edit"
+	LiveMessageAnalysis = "analysis"
+	LiveMessageError    = "error"
+)
+
+// liveWriteTimeout bounds how long a message to a slow client may take
+const liveWriteTimeout = 10 * time.Second
+
+// LiveRequest is a message from a live analysis client
+// A reset replaces the whole text; an edit applies Edits in order to the current text
+type LiveRequest struct {
+	Type    string            `json:"type"`
+	Version int64             `json:"version"`
+	Text    string            `json:"text,omitempty"`
+	Edits   []domain.TextEdit `json:"edits,omitempty"`
+}
+
+// LiveResponse is the analysis of the text after a message, or the error that message caused
+// Version echoes the message it answers; Length is the text length in characters so clients can detect drift
+type LiveResponse struct {
+	Type     string                           `json:"type"`
+	Version  int64                            `json:"version"`
+	Length   int                              `json:"length"`
+	Analysis *domain.SentenceAnalysisResponse `json:"analysis,omitempty"`
+	Code     string                           `json:"code,omitempty"`
+	Message  string                           `json:"message,omitempty"`
+	Errors   []domain.FieldError              `json:"errors,omitempty"`
+}
+
+// liveSessions holds the open live analysis connections so they can be closed on shutdown
+var liveSessions = struct {
+	sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}{conns: make(map[*websocket.Conn]struct{})}
+
+// LiveAnalysisHandler returns a handler that upgrades to a WebSocket on which a client sends
+// edits as the user types and receives updated counts after each message
+// Only the regions touched by an edit are recounted, so clients no longer need to resend the whole text
+func LiveAnalysisHandler(limits config.InputLimits, cfg config.WebSocketConfig) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{LiveSubprotocol},
+		CheckOrigin:  checkOrigin(cfg.AllowedOrigins),
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			switch status {
+			case http.StatusForbidden:
+				problem.Write(w, r, status, problem.CodeForbidden, "Origin not allowed")
+			case http.StatusMethodNotAllowed:
+				problem.Write(w, r, status, problem.CodeMethodNotAllowed, "Method not allowed")
+			default:
+				problem.Write(w, r, status, problem.CodeUpgradeRequired, "Invalid WebSocket handshake")
+			}
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			w.Header().Set("Upgrade", "websocket")
+			problem.Write(w, r, http.StatusUpgradeRequired, problem.CodeUpgradeRequired, "WebSocket upgrade required")
+			return
+		}
+
+		// The upgrader writes the error response itself
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		session := &liveSession{
+			conn:        conn,
+			endpoint:    r.URL.Path,
+			limits:      limits,
+			idleTimeout: cfg.IdleTimeout,
+			doc:         domain.NewDocument(""),
+		}
+		session.run(r.Context())
+	}
+}
+
+// CloseLiveSessions tells every open live analysis client that the server is going away
+// Each connection is closed once its client acknowledges, or after liveWriteTimeout
+func CloseLiveSessions() {
+	liveSessions.Lock()
+	defer liveSessions.Unlock()
+
+	deadline := time.Now().Add(liveWriteTimeout)
+	for conn := range liveSessions.conns {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), deadline)
+		conn.SetReadDeadline(deadline)
+	}
+}
+
+// liveSession is one live analysis connection and the document it is editing
+type liveSession struct {
+	conn        *websocket.Conn
+	endpoint    string
+	limits      config.InputLimits
+	idleTimeout time.Duration
+	doc         *domain.Document
+	// edited is set once a message has changed the document
+	edited bool
+}
+
+// run answers messages until the client disconnects or stays idle too long
+func (s *liveSession) run(ctx context.Context) {
+	defer s.conn.Close()
+
+	liveSessions.Lock()
+	liveSessions.conns[s.conn] = struct{}{}
+	liveSessions.Unlock()
+	metrics.LiveSessions.Inc()
+	defer func() {
+		liveSessions.Lock()
+		delete(liveSessions.conns, s.conn)
+		liveSessions.Unlock()
+		metrics.LiveSessions.Dec()
+	}()
+	// The session is one analysis in the client's history, of the text as it was left
+	defer s.recordHistory(ctx)
+
+	// Messages are capped like request bodies; a larger one closes the connection
+	s.conn.SetReadLimit(s.limits.MaxBodyBytes)
+	s.conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.ping(done)
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.DebugContext(ctx, "live analysis connection closed", "error", err)
+			}
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+
+		response := s.handle(ctx, data)
+
+		s.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		if err := s.conn.WriteJSON(response); err != nil {
+			slog.DebugContext(ctx, "error writing live analysis response", "error", err)
+			return
+		}
+	}
+}
+
+// ping keeps the connection alive through proxies and detects clients that went away
+func (s *liveSession) ping(done <-chan struct{}) {
+	ticker := time.NewTicker(s.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle applies one client message to the document and returns the response for it
+// A rejected message leaves the document unchanged
+func (s *liveSession) handle(ctx context.Context, data []byte) (response LiveResponse) {
+	var req LiveRequest
+	defer func() {
+		result := "ok"
+		if response.Type == LiveMessageError {
+			result = "error"
+		}
+		metrics.LiveMessagesTotal.WithLabelValues(messageTypeLabel(req.Type), result).Inc()
+	}()
+
+	if err := decodeLiveRequest(data, &req); err != nil {
+		return s.errorResponse(req.Version, problem.CodeInvalidBody, "Invalid message", nil)
+	}
+
+	ctx, span := tracing.Start(ctx, "live.message")
+	defer span.End()
+	span.SetAttributes(attribute.String("live.message_type", messageTypeLabel(req.Type)))
+
+	var characters int
+	switch req.Type {
+	case LiveMessageReset:
+		if fieldErrs := validateLiveText(req.Text, s.limits.MaxSentenceLength); len(fieldErrs) > 0 {
+			return s.errorResponse(req.Version, problem.CodeValidationFailed, "Validation failed", fieldErrs)
+		}
+		characters = utf8.RuneCountInString(req.Text)
+	case LiveMessageEdit:
+		if fieldErrs := domain.ValidateEdits(req.Edits, s.doc.Len(), s.limits.MaxSentenceLength); len(fieldErrs) > 0 {
+			return s.errorResponse(req.Version, problem.CodeValidationFailed, "Validation failed", fieldErrs)
+		}
+		for _, edit := range req.Edits {
+			characters += utf8.RuneCountInString(edit.Insert)
+		}
+	default:
+		return s.errorResponse(req.Version, problem.CodeValidationFailed, "Validation failed", []domain.FieldError{{
+			Field:   "type",
+			Message: fmt.Sprintf("must be %q or %q", LiveMessageReset, LiveMessageEdit),
+		}})
+	}
+
+	// Only new characters are charged against the daily quota
+	if _, err := ratelimit.ChargeCharacters(ctx, characters); err != nil {
+		return s.errorResponse(req.Version, problem.CodeQuotaExceeded, "Daily character quota exceeded", nil)
+	}
+
+	if req.Type == LiveMessageReset {
+		s.doc = domain.NewDocument(req.Text)
+	} else if err := s.doc.Apply(req.Edits); err != nil {
+		// ValidateEdits has already checked the edits, so this is a bug
+		ratelimit.RefundCharacters(ctx, characters)
+		slog.ErrorContext(ctx, "error applying live edits", "error", err)
+		return s.errorResponse(req.Version, problem.CodeInternal, "Internal server error", nil)
+	}
+
+	s.edited = true
+	result := s.doc.Result()
+	return LiveResponse{Type: LiveMessageAnalysis, Version: req.Version, Length: s.doc.Len(), Analysis: &result}
+}
+
+// recordHistory adds the document the session ended with to the client's history
+// Sessions that never changed the document, or left it empty, are not recorded
+func (s *liveSession) recordHistory(ctx context.Context) {
+	if !s.edited || s.doc.Len() == 0 {
+		return
+	}
+	result := s.doc.Result()
+	recordHistory(ctx, domain.HistoryEntry{
+		Endpoint:       s.endpoint,
+		Preview:        s.doc.String(),
+		CharacterCount: s.doc.Len(),
+		WordCount:      result.WordCount,
+		VowelCount:     result.VowelCount,
+		ConsonantCount: result.ConsonantCount,
+	})
+}
+
+func (s *liveSession) errorResponse(version int64, code, message string, fieldErrs []domain.FieldError) LiveResponse {
+	return LiveResponse{
+		Type:    LiveMessageError,
+		Version: version,
+		Length:  s.doc.Len(),
+		Code:    code,
+		Message: message,
+		Errors:  fieldErrs,
+	}
+}
+
+// decodeLiveRequest decodes a single JSON message, rejecting unknown fields
+func decodeLiveRequest(data []byte, req *LiveRequest) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("trailing data after message")
+	}
+	return nil
+}
+
+// validateLiveText checks the text of a reset; unlike /analyze an empty text is allowed
+func validateLiveText(text string, maxLength int) []domain.FieldError {
+	switch {
+	case !utf8.ValidString(text):
+		return []domain.FieldError{{Field: "text", Message: "must be valid UTF-8"}}
+	case maxLength > 0 && utf8.RuneCountInString(text) > maxLength:
+		return []domain.FieldError{{Field: "text", Message: fmt.Sprintf("must be at most %d characters", maxLength)}}
+	}
+	return nil
+}
+
+// messageTypeLabel returns the message type for metrics and spans, bounding the label values
+func messageTypeLabel(messageType string) string {
+	if messageType == LiveMessageReset || messageType == LiveMessageEdit {
+		return messageType
+	}
+	return "invalid"
+}
+
+// checkOrigin allows clients without an Origin header, the service's own origin and the configured origins
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowedOrigin := range allowed {
+			if strings.EqualFold(origin, allowedOrigin) {
+				return true
+			}
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// dialLive starts a live analysis server and connects to it
+func dialLive(t *testing.T, handler http.HandlerFunc, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{LiveSubprotocol}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func TestLiveAnalysisHandler(t *testing.T) {
+	handler := LiveAnalysisHandler(
+		config.InputLimits{MaxBodyBytes: 1024, MaxSentenceLength: 20},
+		config.WebSocketConfig{Enabled: true, IdleTimeout: time.Minute},
+	)
+
+	conn, resp, err := dialLive(t, handler, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != LiveSubprotocol {
+		t.Errorf("Expected subprotocol %s, got %q", LiveSubprotocol, got)
+	}
+
+	tests := []struct {
+		name         string
+		request      LiveRequest
+		wantType     string
+		wantLength   int
+		wantAnalysis domain.SentenceAnalysisResponse
+		wantCode     string
+	}{
+		{
+			name:         "reset",
+			request:      LiveRequest{Type: LiveMessageReset, Version: 1, Text: "Hello"},
+			wantType:     LiveMessageAnalysis,
+			wantLength:   5,
+			wantAnalysis: domain.SentenceAnalysisResponse{WordCount: 1, VowelCount: 2, ConsonantCount: 3},
+		},
+		{
+			name:         "edit",
+			request:      LiveRequest{Type: LiveMessageEdit, Version: 2, Edits: []domain.TextEdit{{Offset: 5, Insert: " World"}}},
+			wantType:     LiveMessageAnalysis,
+			wantLength:   11,
+			wantAnalysis: domain.SentenceAnalysisResponse{WordCount: 2, VowelCount: 3, ConsonantCount: 7},
+		},
+		{
+			name:       "edit out of range leaves the text unchanged",
+			request:    LiveRequest{Type: LiveMessageEdit, Version: 3, Edits: []domain.TextEdit{{Offset: 20, Insert: "!"}}},
+			wantType:   LiveMessageError,
+			wantLength: 11,
+			wantCode:   problem.CodeValidationFailed,
+		},
+		{
+			name:       "text too long",
+			request:    LiveRequest{Type: LiveMessageEdit, Version: 4, Edits: []domain.TextEdit{{Offset: 11, Insert: strings.Repeat("a", 10)}}},
+			wantType:   LiveMessageError,
+			wantLength: 11,
+			wantCode:   problem.CodeValidationFailed,
+		},
+		{
+			name:       "unknown type",
+			request:    LiveRequest{Type: "replace", Version: 5},
+			wantType:   LiveMessageError,
+			wantLength: 11,
+			wantCode:   problem.CodeValidationFailed,
+		},
+		{
+			name:         "join words",
+			request:      LiveRequest{Type: LiveMessageEdit, Version: 6, Edits: []domain.TextEdit{{Offset: 5, Delete: 1}}},
+			wantType:     LiveMessageAnalysis,
+			wantLength:   10,
+			wantAnalysis: domain.SentenceAnalysisResponse{WordCount: 1, VowelCount: 3, ConsonantCount: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.WriteJSON(tt.request); err != nil {
+				t.Fatalf("Failed to send: %v", err)
+			}
+			var response LiveResponse
+			if err := conn.ReadJSON(&response); err != nil {
+				t.Fatalf("Failed to receive: %v", err)
+			}
+
+			if response.Type != tt.wantType || response.Version != tt.request.Version || response.Length != tt.wantLength {
+				t.Errorf("Expected %s for version %d with length %d, got %+v", tt.wantType, tt.request.Version, tt.wantLength, response)
+			}
+			if tt.wantType == LiveMessageAnalysis && (response.Analysis == nil || !reflect.DeepEqual(*response.Analysis, tt.wantAnalysis)) {
+				t.Errorf("Expected analysis %+v, got %+v", tt.wantAnalysis, response.Analysis)
+			}
+			if response.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, response.Code)
+			}
+		})
+	}
+
+	// A malformed message is answered without closing the connection
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":`)); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	var response LiveResponse
+	if err := conn.ReadJSON(&response); err != nil || response.Code != problem.CodeInvalidBody {
+		t.Errorf("Expected an %s error, got %+v (%v)", problem.CodeInvalidBody, response, err)
+	}
+}
+
+func TestLiveAnalysisHandlerQuota(t *testing.T) {
+	handler := LiveAnalysisHandler(config.DefaultInputLimits(), config.WebSocketConfig{Enabled: true, IdleTimeout: time.Minute})
+	quota := ratelimit.NewQuota(ratelimit.NewLimiter(), "user:alice", 8)
+	withQuota := func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(ratelimit.WithQuota(r.Context(), quota)))
+	}
+
+	conn, _, err := dialLive(t, withQuota, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Only inserted characters are charged: 5 + 3 fit the quota, the next one does not
+	messages := []struct {
+		request  LiveRequest
+		wantCode string
+	}{
+		{LiveRequest{Type: LiveMessageReset, Text: "Hello"}, ""},
+		{LiveRequest{Type: LiveMessageEdit, Edits: []domain.TextEdit{{Offset: 0, Delete: 5, Insert: "Hey"}}}, ""},
+		{LiveRequest{Type: LiveMessageEdit, Edits: []domain.TextEdit{{Offset: 3, Insert: "!"}}}, problem.CodeQuotaExceeded},
+	}
+	for i, message := range messages {
+		conn.WriteJSON(message.request)
+		var response LiveResponse
+		if err := conn.ReadJSON(&response); err != nil {
+			t.Fatalf("message %d: failed to receive: %v", i, err)
+		}
+		if response.Code != message.wantCode {
+			t.Errorf("message %d: expected code %q, got %+v", i, message.wantCode, response)
+		}
+	}
+}
+
+func TestLiveAnalysisHandlerHandshake(t *testing.T) {
+	handler := LiveAnalysisHandler(
+		config.DefaultInputLimits(),
+		config.WebSocketConfig{Enabled: true, IdleTimeout: time.Minute, AllowedOrigins: []string{"https://editor.example.com"}},
+	)
+
+	// A plain request is told to upgrade
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/analyze/live", nil))
+	if rr.Code != http.StatusUpgradeRequired {
+		t.Errorf("Expected status %d, got %d", http.StatusUpgradeRequired, rr.Code)
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil || p.Code != problem.CodeUpgradeRequired {
+		t.Errorf("Expected problem code %s, got %+v (%v)", problem.CodeUpgradeRequired, p, err)
+	}
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+	}{
+		{"allowed origin", "https://editor.example.com", http.StatusSwitchingProtocols},
+		{"other origin", "https://evil.example.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp, _ := dialLive(t, handler, http.Header{"Origin": {tt.origin}})
+			if resp == nil || resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %+v", tt.wantStatus, resp)
+			}
+		})
+	}
+}
+
+func TestCloseLiveSessions(t *testing.T) {
+	handler := LiveAnalysisHandler(config.DefaultInputLimits(), config.WebSocketConfig{Enabled: true, IdleTimeout: time.Minute})
+
+	conn, _, err := dialLive(t, handler, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	// Wait for the session to be registered
+	conn.WriteJSON(LiveRequest{Type: LiveMessageReset, Text: "Hi"})
+	var response LiveResponse
+	conn.ReadJSON(&response)
+
+	CloseLiveSessions()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going away close, got %v", err)
+	}
+}
@@ -2,11 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
 	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
 )
 
 // LoginRequest represents the login request body
@@ -20,59 +27,106 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
-// HandleLogin handles the login endpoint
+// HandleLogin handles the login endpoint with the default login throttling
 func HandleLogin(w http.ResponseWriter, r *http.Request) {
-	// Only allow POST method
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	LoginHandler(config.DefaultLoginConfig())(w, r)
+}
 
-	// Parse request body
-	var req LoginRequest
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+// LoginHandler returns a handler for the login endpoint that throttles failed logins as cfg configures
+func LoginHandler(cfg config.LoginConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow POST method
+		if r.Method != http.MethodPost {
+			problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
 
-	// Get credentials from environment variables
-	expectedUsername := os.Getenv("LOGIN_USERNAME")
-	if expectedUsername == "" {
-		expectedUsername = "admin" // Default username
-	}
+		// Parse request body
+		var req LoginRequest
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&req); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidBody, "Invalid request body")
+			return
+		}
 
-	expectedPassword := os.Getenv("LOGIN_PASSWORD")
-	if expectedPassword == "" {
-		expectedPassword = "password" // Default password
-	}
+		// Reject the attempt early while the username or client IP is throttled
+		clientIP := auth.ClientIP(r)
+		retryAfter, err := auth.CheckLoginAllowed(req.Username, clientIP)
+		if err != nil {
+			if errors.Is(err, auth.ErrLoginLocked) {
+				metrics.LoginsTotal.WithLabelValues(metrics.LoginLocked).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				problem.Write(w, r, http.StatusTooManyRequests, problem.CodeLoginLocked, "Too many failed login attempts")
+				return
+			}
+			slog.ErrorContext(r.Context(), "error checking login attempts", "error", err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
 
-	// Validate credentials
-	if req.Username != expectedUsername || req.Password != expectedPassword {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-		return
-	}
+		// Get credentials from environment variables
+		expectedUsername := os.Getenv("LOGIN_USERNAME")
+		if expectedUsername == "" {
+			expectedUsername = "admin" // Default username
+		}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(req.Username, []string{"user"})
-	if err != nil {
-		log.Printf("Error generating token: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+		expectedPassword := os.Getenv("LOGIN_PASSWORD")
+		if expectedPassword == "" {
+			expectedPassword = "password" // Default password
+		}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+		// Validate credentials
+		if req.Username != expectedUsername || req.Password != expectedPassword {
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+			if err := auth.RecordLoginFailure(cfg, req.Username, clientIP); err != nil {
+				slog.ErrorContext(r.Context(), "error recording failed login", "error", err)
+			}
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidCredentials, "Invalid credentials")
+			return
+		}
 
-	// Write response
-	response := LoginResponse{
-		Token: token,
+		metrics.LoginsTotal.WithLabelValues(metrics.LoginSuccess).Inc()
+		if err := auth.RecordLoginSuccess(req.Username, clientIP); err != nil {
+			slog.ErrorContext(r.Context(), "error recording successful login", "error", err)
+		}
+
+		// Get roles from environment variables, e.g. "user,admin"
+		roles := []string{"user"}
+		if rolesStr := os.Getenv("LOGIN_ROLES"); rolesStr != "" {
+			roles = splitList(rolesStr)
+		}
+
+		// Generate JWT token
+		token, err := auth.GenerateToken(req.Username, roles)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error generating token", "error", err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
+
+		// Set response headers
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		// Write response
+		response := LoginResponse{
+			Token: token,
+		}
+		encoder := json.NewEncoder(w)
+		if err := encoder.Encode(response); err != nil {
+			slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+			return
+		}
 	}
-	encoder := json.NewEncoder(w)
-	if err := encoder.Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -7,6 +7,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
 )
 
 func TestHandleLogin(t *testing.T) {
@@ -146,3 +149,49 @@ func TestHandleLoginWithDefaultCredentials(t *testing.T) {
 		t.Errorf("Expected a token in the response, got empty string")
 	}
 }
+
+func TestHandleLoginLockout(t *testing.T) {
+	os.Setenv("LOGIN_USERNAME", "testuser")
+	os.Setenv("LOGIN_PASSWORD", "testpass")
+	defer func() {
+		os.Unsetenv("LOGIN_USERNAME")
+		os.Unsetenv("LOGIN_PASSWORD")
+	}()
+
+	cfg := config.DefaultLoginConfig()
+	cfg.Username.MaxAttempts = 2
+
+	// Use a fresh attempt store and discard audit events
+	originalStore := auth.GetLoginAttemptStore()
+	originalSink := auth.AuditSink
+	auth.SetLoginAttemptStore(auth.NewMemoryLoginAttemptStore())
+	auth.AuditSink = func(auth.AuditEvent) {}
+	defer func() {
+		auth.SetLoginAttemptStore(originalStore)
+		auth.AuditSink = originalSink
+	}()
+
+	login := func(password string) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(LoginRequest{Username: "testuser", Password: password})
+		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(reqBody))
+		req.RemoteAddr = "192.0.2.1:5555"
+		rr := httptest.NewRecorder()
+		LoginHandler(cfg)(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := login("wrong"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: got status %v want %v", i+1, rr.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Even the correct password is rejected while the account is locked
+	rr := login("testpass")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
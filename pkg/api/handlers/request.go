@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// Request decoding errors
+var (
+	errBodyTooLarge     = errors.New("request body too large")
+	errMalformed        = errors.New("malformed request body")
+	errUnsupportedMedia = errors.New("unsupported request content type")
+)
+
+// invalidFieldsError reports request fields that are unknown or of the wrong type
+type invalidFieldsError struct {
+	Fields []domain.FieldError
+}
+
+func (e *invalidFieldsError) Error() string {
+	return "invalid request fields"
+}
+
+// unsupportedMediaError reports a request body of a media type the endpoint does not accept
+type unsupportedMediaError struct {
+	Supported []string
+}
+
+func (e *unsupportedMediaError) Error() string {
+	return errUnsupportedMedia.Error()
+}
+
+func (e *unsupportedMediaError) Unwrap() error {
+	return errUnsupportedMedia
+}
+
+// jsonOrPlainText lists the media types of endpoints that take JSON or the raw text as text/plain
+var jsonOrPlainText = []string{"application/json", "text/plain"}
+
+// readBody reads the request body, capped at maxBytes, and checks that it is valid UTF-8
+func readBody(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errBodyTooLarge
+		}
+		return nil, errMalformed
+	}
+
+	// encoding/json silently replaces invalid UTF-8 with U+FFFD, so check the raw bytes
+	if !utf8.Valid(body) {
+		return nil, &invalidFieldsError{Fields: []domain.FieldError{{Field: "body", Message: "must be valid UTF-8"}}}
+	}
+	return body, nil
+}
+
+// rawFormats maps the media types of raw request bodies to the input format of the sentence they hold
+var rawFormats = map[string]string{
+	"text/plain":    domain.FormatPlain,
+	"text/markdown": domain.FormatMarkdown,
+	"text/html":     domain.FormatHTML,
+}
+
+// analysisMediaTypes lists the media types accepted for analysis requests: JSON and each raw format
+var analysisMediaTypes = func() []string {
+	types := make([]string, 0, len(rawFormats))
+	for mediaType := range rawFormats {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	return append([]string{"application/json"}, types...)
+}()
+
+// decodeAnalysisRequest decodes an analysis request from a JSON body, or from a
+// text/plain, text/markdown or text/html body that holds the raw sentence
+func decodeAnalysisRequest(w http.ResponseWriter, r *http.Request, maxBytes int64) (domain.SentenceAnalysisRequest, error) {
+	var req domain.SentenceAnalysisRequest
+
+	mediaType := requestMediaType(r)
+	if mediaType == "" || mediaType == "application/json" {
+		err := decodeJSONBody(w, r, &req, maxBytes)
+		return req, err
+	}
+
+	format, ok := rawFormats[mediaType]
+	if !ok {
+		return req, &unsupportedMediaError{Supported: analysisMediaTypes}
+	}
+	body, err := readBody(w, r, maxBytes)
+	req.Sentence = string(body)
+	req.Format = format
+	return req, err
+}
+
+// pathID returns the {id} segment of a path ending in base/{id}suffix
+// Routes are served under a version prefix as well as unversioned, so only the end of the path is matched
+func pathID(path, base, suffix string) (string, bool) {
+	i := strings.LastIndex(path, base+"/")
+	if i < 0 {
+		return "", false
+	}
+	id, ok := strings.CutSuffix(path[i+len(base)+1:], suffix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// requestMediaType returns the media type of the request body without parameters
+func requestMediaType(r *http.Request) string {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mediaType
+}
+
+// decodeJSONBody decodes a single JSON object from the request body into dst
+// The body is capped at maxBytes, must be valid UTF-8 and may not contain unknown fields
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	body, err := readBody(w, r, maxBytes)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			return &invalidFieldsError{Fields: []domain.FieldError{{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+			}}}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			// encoding/json has no typed error for unknown fields
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			return &invalidFieldsError{Fields: []domain.FieldError{{Field: field, Message: "is not a known field"}}}
+		default:
+			return errMalformed
+		}
+	}
+
+	// Reject trailing data after the JSON object
+	if decoder.More() {
+		return errMalformed
+	}
+
+	return nil
+}
+
+// writeDecodeError writes the problem response matching an error returned by decodeJSONBody
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldsErr *invalidFieldsError
+	var mediaErr *unsupportedMediaError
+	switch {
+	case errors.As(err, &mediaErr):
+		problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+			"Supported request types: "+strings.Join(mediaErr.Supported, ", "))
+	case errors.Is(err, errBodyTooLarge):
+		problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge, "Request body too large")
+	case errors.As(err, &fieldsErr):
+		problem.WriteValidation(w, r, fieldsErr.Fields)
+	default:
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidBody, "Invalid request body")
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import "testing"
+
+func TestPathID(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		suffix string
+		wantID string
+		wantOK bool
+	}{
+		{name: "unversioned", path: "/analyze/jobs/abc/events", suffix: "/events", wantID: "abc", wantOK: true},
+		{name: "versioned", path: "/v1/analyze/jobs/abc/events", suffix: "/events", wantID: "abc", wantOK: true},
+		{name: "no suffix", path: "/v1/analyze/jobs/abc", suffix: "", wantID: "abc", wantOK: true},
+		{name: "missing suffix", path: "/analyze/jobs/abc", suffix: "/events"},
+		{name: "empty ID", path: "/analyze/jobs//events", suffix: "/events"},
+		{name: "nested ID", path: "/analyze/jobs/a/b/events", suffix: "/events"},
+		{name: "other path", path: "/analyze", suffix: "/events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := pathID(tt.path, JobsPath, tt.suffix)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("pathID(%q) = %q, %v, want %q, %v", tt.path, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+// HandleVersion handles the version endpoint, reporting which build is running
+func HandleVersion(w http.ResponseWriter, r *http.Request) {
+	// Only allow GET method
+	if r.Method != http.MethodGet {
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	// Write response
+	if err := json.NewEncoder(w).Encode(version.Get()); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding response", "error", err)
+	}
+}
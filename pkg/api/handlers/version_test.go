@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		wantStatusCode int
+	}{
+		{"get version", http.MethodGet, http.StatusOK},
+		{"invalid method", http.MethodPost, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/version", nil)
+			rr := httptest.NewRecorder()
+			HandleVersion(rr, req)
+
+			if status := rr.Code; status != tt.wantStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.wantStatusCode)
+			}
+			if tt.wantStatusCode != http.StatusOK {
+				return
+			}
+
+			var info version.Info
+			if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if info.Version != version.Version || info.GoVersion == "" {
+				t.Errorf("Unexpected version info: %+v", info)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+)
+
+// ContentType is the media type of RFC 7807 problem details
+const ContentType = "application/problem+json"
+
+// Machine-readable error codes returned in the code member
+const (
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeNotAcceptable      = "not_acceptable"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeUpgradeRequired    = "upgrade_required"
+	CodeInvalidBody        = "invalid_body"
+	CodeBodyTooLarge       = "body_too_large"
+	CodeValidationFailed   = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeNoToken            = "authentication_required"
+	CodeExpiredToken       = "token_expired"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidAPIKey      = "invalid_api_key"
+	CodeExpiredAPIKey      = "api_key_expired"
+	CodeRevokedAPIKey      = "api_key_revoked"
+	CodeForbidden          = "forbidden"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeLoginLocked        = "login_locked"
+	CodeRateLimited        = "rate_limited"
+	CodeQuotaExceeded      = "quota_exceeded"
+	CodeCorpusFull         = "corpus_full"
+	CodeTooManyCorpora     = "too_many_corpora"
+	CodeTooManyJobs        = "too_many_jobs"
+	CodeInternal           = "internal_error"
+)
+
+// Problem is the error envelope returned by every endpoint (RFC 7807 problem details)
+// Type, Title, Status, Detail and Instance are the standard members; Code, Message,
+// RequestID and Errors are extension members
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	Message   string              `json:"message"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// New creates a Problem for the request with the given status, code and message
+// The request ID is taken from the context, falling back to the X-Request-ID header
+func New(r *http.Request, status int, code, message string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Instance:  r.URL.Path,
+		Code:      code,
+		Message:   message,
+		RequestID: requestID(r),
+	}
+}
+
+func requestID(r *http.Request) string {
+	if id := logging.RequestID(r.Context()); id != "" {
+		return id
+	}
+	return r.Header.Get(logging.RequestIDHeader)
+}
+
+// Write writes a problem+json response with the given status, code and message
+func Write(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	WriteProblem(w, r, New(r, status, code, message))
+}
+
+// WriteValidation writes a 422 problem+json response listing the invalid fields
+func WriteValidation(w http.ResponseWriter, r *http.Request, fields []domain.FieldError) {
+	p := New(r, http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed")
+	p.Errors = fields
+	WriteProblem(w, r, p)
+}
+
+// WriteProblem writes p as a problem+json response
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding problem response", "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+func TestWrite(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/analyze", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("X-Request-ID", "req-123")
+
+	rr := httptest.NewRecorder()
+	Write(rr, req, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", ContentType, contentType)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to unmarshal problem: %v", err)
+	}
+
+	want := Problem{
+		Type:      "about:blank",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Detail:    "Invalid request body",
+		Instance:  "/analyze",
+		Code:      CodeInvalidBody,
+		Message:   "Invalid request body",
+		RequestID: "req-123",
+	}
+	if p.Type != want.Type || p.Title != want.Title || p.Status != want.Status || p.Detail != want.Detail ||
+		p.Instance != want.Instance || p.Code != want.Code || p.Message != want.Message || p.RequestID != want.RequestID {
+		t.Errorf("Unexpected problem: got %+v want %+v", p, want)
+	}
+}
+
+func TestWriteValidation(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/analyze", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	WriteValidation(rr, req, []domain.FieldError{{Field: "sentence", Message: "is required"}})
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to unmarshal problem: %v", err)
+	}
+	if p.Code != CodeValidationFailed {
+		t.Errorf("Expected code %s, got %s", CodeValidationFailed, p.Code)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "sentence" {
+		t.Errorf("Expected a field error for sentence, got %+v", p.Errors)
+	}
+}
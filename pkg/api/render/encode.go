@@ -0,0 +1,326 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// encoder renders the JSON encoding of a value in another format
+type encoder func(root string, data []byte) ([]byte, error)
+
+// member is one key of a JSON object, kept in document order
+type member struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object whose keys keep their order, so every format lists fields as JSON does
+type object []member
+
+// decodeOrdered decodes JSON into object, []interface{}, json.Number, string, bool or nil values
+func decodeOrdered(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeValue(decoder)
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		var obj object
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err := decoder.Token() // closing brace
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token() // closing bracket
+		return list, err
+	default:
+		return token, nil
+	}
+}
+
+func encodeJSON(root string, data []byte) ([]byte, error) {
+	// Match json.Encoder output, which the JSON API has always returned
+	return append(data, '\n'), nil
+}
+
+// encodeXML renders objects as elements named after their keys and list entries as <item> elements
+func encodeXML(root string, data []byte) ([]byte, error) {
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := writeXMLElement(encoder, root, value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case object:
+		for _, m := range v {
+			if err := writeXMLElement(encoder, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXMLElement(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+		// An empty element represents null
+	default:
+		if err := encoder.EncodeToken(xml.CharData(scalarString(v))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// encodeYAML renders a block-style YAML document
+func encodeYAML(root string, data []byte) ([]byte, error) {
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(yamlNode(value))
+}
+
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case object:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, m := range v {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.key},
+				yamlNode(m.value),
+			)
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: scalarString(v)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: scalarString(v)}
+	}
+}
+
+// errNotTabular is returned when a value cannot be laid out as CSV rows
+var errNotTabular = errors.New("value cannot be rendered as CSV")
+
+// encodeCSV renders an object as a header and one row, or a list of objects as a header and one row each
+// Nested objects are flattened into dotted column names and nested lists are written as JSON
+func encodeCSV(root string, data []byte) ([]byte, error) {
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []object
+	switch v := value.(type) {
+	case object:
+		rows = []object{flatten("", v)}
+	case []interface{}:
+		for _, item := range v {
+			obj, ok := item.(object)
+			if !ok {
+				return nil, errNotTabular
+			}
+			rows = append(rows, flatten("", obj))
+		}
+	default:
+		return nil, errNotTabular
+	}
+
+	// Columns are the union of all keys in first-seen order
+	var columns []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, m := range row {
+			if !seen[m.key] {
+				seen[m.key] = true
+				columns = append(columns, m.key)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(columns)
+	for _, row := range rows {
+		cells := make(map[string]string, len(row))
+		for _, m := range row {
+			cells[m.key] = m.value.(string)
+		}
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = cells[column]
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// flatten turns nested objects into dotted keys with string values
+func flatten(prefix string, obj object) object {
+	var flat object
+	for _, m := range obj {
+		key := prefix + m.key
+		switch v := m.value.(type) {
+		case object:
+			flat = append(flat, flatten(key+".", v)...)
+		case []interface{}:
+			data, _ := json.Marshal(plain(v))
+			flat = append(flat, member{key: key, value: string(data)})
+		default:
+			flat = append(flat, member{key: key, value: scalarString(v)})
+		}
+	}
+	return flat
+}
+
+// encodeMessagePack renders a MessagePack document, keeping object keys in order
+func encodeMessagePack(root string, data []byte) ([]byte, error) {
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeMessagePack(msgpack.NewEncoder(&buf), value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMessagePack(encoder *msgpack.Encoder, value interface{}) error {
+	switch v := value.(type) {
+	case object:
+		if err := encoder.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, m := range v {
+			if err := encoder.EncodeString(m.key); err != nil {
+				return err
+			}
+			if err := writeMessagePack(encoder, m.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := encoder.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeMessagePack(encoder, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return encoder.EncodeInt(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return encoder.EncodeFloat64(f)
+	default:
+		return encoder.Encode(v)
+	}
+}
+
+// plain converts ordered values back into values encoding/json can marshal
+func plain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case object:
+		m := make(map[string]interface{}, len(v))
+		for _, member := range v {
+			m[member.key] = plain(member.value)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = plain(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
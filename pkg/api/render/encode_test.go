@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// sample has the shapes the encoders must handle: scalars, a nested object and a list
+type sample struct {
+	Name   string         `json:"name"`
+	Count  int            `json:"count"`
+	Ratio  float64        `json:"ratio"`
+	Nested map[string]int `json:"nested"`
+	Tags   []string       `json:"tags"`
+}
+
+var testSample = sample{Name: "a<b", Count: 3, Ratio: 0.5, Nested: map[string]int{"x": 1}, Tags: []string{"t1", "t2"}}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		value     interface{}
+		want      string
+	}{
+		{MediaTypeJSON, testSample, `{"name":"a\u003cb","count":3,"ratio":0.5,"nested":{"x":1},"tags":["t1","t2"]}` + "\n"},
+		{MediaTypeXML, testSample, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<sample><name>a&lt;b</name><count>3</count><ratio>0.5</ratio><nested><x>1</x></nested>` +
+			`<tags><item>t1</item><item>t2</item></tags></sample>` + "\n"},
+		{MediaTypeYAML, testSample, "name: a<b\ncount: 3\nratio: 0.5\nnested:\n    x: 1\ntags:\n    - t1\n    - t2\n"},
+		{MediaTypeCSV, testSample, "name,count,ratio,nested.x,tags\na<b,3,0.5,1,\"[\"\"t1\"\",\"\"t2\"\"]\"\n"},
+		{MediaTypeCSV, []sample{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+			"name,count,ratio,nested,tags\na,1,0,,\nb,2,0,,\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			format, _ := Negotiate(tt.mediaType)
+			got, err := format.Encode("sample", tt.value)
+			if err != nil {
+				t.Fatalf("Encode returned error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMessagePack(t *testing.T) {
+	format, _ := Negotiate(MediaTypeMessagePack)
+	data, err := format.Encode("sample", testSample)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	// Keys are the JSON field names
+	var decoded sample
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(&decoded); err != nil {
+		t.Fatalf("Failed to decode MessagePack: %v", err)
+	}
+	if decoded.Count != 3 || decoded.Nested["x"] != 1 || len(decoded.Tags) != 2 {
+		t.Errorf("Unexpected decoded value %+v", decoded)
+	}
+}
+
+func TestEncodeCSVNotTabular(t *testing.T) {
+	format, _ := Negotiate(MediaTypeCSV)
+	if _, err := format.Encode("value", []int{1, 2}); err != errNotTabular {
+		t.Errorf("Expected errNotTabular, got %v", err)
+	}
+}
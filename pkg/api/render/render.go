@@ -0,0 +1,177 @@
+package render
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+)
+
+// Media types that responses can be rendered as
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeXML         = "application/xml"
+	MediaTypeYAML        = "application/yaml"
+	MediaTypeCSV         = "text/csv"
+	MediaTypeMessagePack = "application/msgpack"
+)
+
+// Format renders a response body in one media type
+type Format struct {
+	// MediaType is sent as the Content-Type of the response
+	MediaType string
+	// aliases are other media types clients use for the same format
+	aliases []string
+	encode  encoder
+}
+
+// Formats lists the supported formats in order of server preference
+var Formats = []Format{
+	{MediaType: MediaTypeJSON, encode: encodeJSON},
+	{MediaType: MediaTypeXML, aliases: []string{"text/xml"}, encode: encodeXML},
+	{MediaType: MediaTypeYAML, aliases: []string{"application/x-yaml", "text/yaml"}, encode: encodeYAML},
+	{MediaType: MediaTypeCSV, encode: encodeCSV},
+	{MediaType: MediaTypeMessagePack, aliases: []string{"application/x-msgpack", "application/vnd.msgpack"}, encode: encodeMessagePack},
+}
+
+// MediaTypes returns the media types of all supported formats
+func MediaTypes() []string {
+	types := make([]string, len(Formats))
+	for i, format := range Formats {
+		types[i] = format.MediaType
+	}
+	return types
+}
+
+// acceptRange is one entry of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+	index     int
+}
+
+// Negotiate picks the format that best matches an Accept header
+// An empty header accepts anything, and JSON is preferred when the client has no preference
+// It reports false when none of the supported formats is acceptable
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return Formats[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ, bestIndex := -1, 0.0, 0
+	for i, format := range Formats {
+		q, index := format.quality(ranges)
+		if q <= 0 {
+			continue
+		}
+		// Prefer the higher quality, then the range the client listed first, then server order
+		if best < 0 || q > bestQ || (q == bestQ && index < bestIndex) {
+			best, bestQ, bestIndex = i, q, index
+		}
+	}
+
+	if best < 0 {
+		return Format{}, false
+	}
+	return Formats[best], true
+}
+
+// quality returns the q-value the client gives this format and the position of the
+// matching range, using the most specific matching range as RFC 9110 requires
+func (f Format) quality(ranges []acceptRange) (float64, int) {
+	q, index, specificity := 0.0, 0, -1
+	for _, r := range ranges {
+		s := f.match(r.mediaType)
+		if s > specificity {
+			q, index, specificity = r.q, r.index, s
+		}
+	}
+	return q, index
+}
+
+// match returns how specifically a media range matches the format, or -1 if it does not
+func (f Format) match(mediaRange string) int {
+	if mediaRange == "*/*" {
+		return 0
+	}
+	for _, mediaType := range append([]string{f.MediaType}, f.aliases...) {
+		if mediaRange == mediaType {
+			return 2
+		}
+		if mediaRange == strings.SplitN(mediaType, "/", 2)[0]+"/*" {
+			return 1
+		}
+	}
+	return -1
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for i, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, index: i})
+	}
+	return ranges
+}
+
+// NotAcceptable writes a 406 problem+json response listing the supported media types
+func NotAcceptable(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, http.StatusNotAcceptable, problem.CodeNotAcceptable,
+		"Supported response types: "+strings.Join(MediaTypes(), ", "))
+}
+
+// Write renders v in the given format with the given status
+// root names the document element for formats that need one, such as XML
+func Write(w http.ResponseWriter, r *http.Request, format Format, status int, root string, v interface{}) {
+	// The response depends on the Accept header, so caches must key on it
+	w.Header().Add("Vary", "Accept")
+
+	body, err := format.Encode(root, v)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error encoding response", "error", err, "media_type", format.MediaType)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType())
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		slog.ErrorContext(r.Context(), "error writing response", "error", err)
+	}
+}
+
+// Encode renders v in the format
+// Every format uses the JSON field names of v, so all representations share one schema
+func (f Format) Encode(root string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return f.encode(root, data)
+}
+
+// contentType returns the Content-Type header for the format
+func (f Format) contentType() string {
+	switch f.MediaType {
+	case MediaTypeXML, MediaTypeYAML, MediaTypeCSV:
+		return f.MediaType + "; charset=utf-8"
+	default:
+		return f.MediaType
+	}
+}
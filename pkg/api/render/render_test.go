@@ -0,0 +1,75 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+		wantOK bool
+	}{
+		{"no header", "", MediaTypeJSON, true},
+		{"any type", "*/*", MediaTypeJSON, true},
+		{"json", "application/json", MediaTypeJSON, true},
+		{"xml", "application/xml", MediaTypeXML, true},
+		{"xml alias", "text/xml", MediaTypeXML, true},
+		{"yaml alias", "application/x-yaml", MediaTypeYAML, true},
+		{"csv", "text/csv", MediaTypeCSV, true},
+		{"msgpack", "application/msgpack", MediaTypeMessagePack, true},
+		{"client order breaks ties", "application/xml, application/json", MediaTypeXML, true},
+		{"quality wins over order", "application/xml;q=0.5, application/yaml", MediaTypeYAML, true},
+		{"subtype wildcard", "text/*", MediaTypeXML, true},
+		{"specific range overrides wildcard", "application/json;q=0, */*;q=0.1", MediaTypeXML, true},
+		{"browser header", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", MediaTypeXML, true},
+		{"unsupported", "text/html", "", false},
+		{"explicitly refused", "application/json;q=0", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := Negotiate(tt.accept)
+			if ok != tt.wantOK {
+				t.Fatalf("Negotiate(%q) ok = %v, want %v", tt.accept, ok, tt.wantOK)
+			}
+			if format.MediaType != tt.want {
+				t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, format.MediaType, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	format, _ := Negotiate("application/yaml")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	Write(rr, req, format, http.StatusCreated, "result", map[string]int{"count": 1})
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/yaml; charset=utf-8" {
+		t.Errorf("Expected YAML content type, got %s", got)
+	}
+	if got := rr.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Expected Vary: Accept, got %q", got)
+	}
+	if got := rr.Body.String(); got != "count: 1\n" {
+		t.Errorf("Unexpected body %q", got)
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	NotAcceptable(rr, req)
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status 406, got %d", rr.Code)
+	}
+}
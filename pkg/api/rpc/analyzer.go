@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/analyzerpb"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/history"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/metrics"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/ratelimit"
+)
+
+// AnalyzerServer implements the gRPC AnalyzerService on top of pkg/domain
+// Authentication and rate limiting are applied by interceptors
+type AnalyzerServer struct {
+	analyzerpb.UnimplementedAnalyzerServiceServer
+	limits config.InputLimits
+}
+
+// NewAnalyzerServer creates an AnalyzerServer that enforces the given input limits
+func NewAnalyzerServer(limits config.InputLimits) *AnalyzerServer {
+	return &AnalyzerServer{limits: limits}
+}
+
+// Analyze counts the words, vowels and consonants in one sentence
+func (s *AnalyzerServer) Analyze(ctx context.Context, req *analyzerpb.AnalyzeRequest) (*analyzerpb.AnalyzeResponse, error) {
+	return s.analyze(ctx, "sentence", req.GetSentence())
+}
+
+// AnalyzeStream analyzes each sentence of the request and streams one result per sentence
+func (s *AnalyzerServer) AnalyzeStream(req *analyzerpb.AnalyzeStreamRequest, stream analyzerpb.AnalyzerService_AnalyzeStreamServer) error {
+	if len(req.GetSentences()) == 0 {
+		return invalidArgument([]domain.FieldError{{Field: "sentences", Message: "is required"}})
+	}
+
+	for i, sentence := range req.GetSentences() {
+		result, err := s.analyze(stream.Context(), fmt.Sprintf("sentences[%d]", i), sentence)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&analyzerpb.AnalyzeStreamResponse{Index: int32(i), Result: result}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnalyzeBatch analyzes every sentence the client streams and returns all results with their totals
+func (s *AnalyzerServer) AnalyzeBatch(stream analyzerpb.AnalyzerService_AnalyzeBatchServer) error {
+	response := &analyzerpb.AnalyzeBatchResponse{Total: &analyzerpb.AnalyzeResponse{}}
+
+	for i := 0; ; i++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err := s.analyze(stream.Context(), fmt.Sprintf("requests[%d].sentence", i), req.GetSentence())
+		if err != nil {
+			return err
+		}
+		response.Results = append(response.Results, result)
+		response.Total.WordCount += result.WordCount
+		response.Total.VowelCount += result.VowelCount
+		response.Total.ConsonantCount += result.ConsonantCount
+		for name, count := range result.Custom {
+			if response.Total.Custom == nil {
+				response.Total.Custom = make(map[string]int32, len(result.Custom))
+			}
+			response.Total.Custom[name] += count
+		}
+	}
+
+	return stream.SendAndClose(response)
+}
+
+// analyze validates, charges and analyzes one sentence, as the HTTP handler does
+// field names the sentence in validation errors
+func (s *AnalyzerServer) analyze(ctx context.Context, field, sentence string) (*analyzerpb.AnalyzeResponse, error) {
+	req := domain.SentenceAnalysisRequest{Sentence: sentence}
+	if fieldErrs := req.Validate(s.limits.MaxSentenceLength); len(fieldErrs) > 0 {
+		for i := range fieldErrs {
+			fieldErrs[i].Field = field
+		}
+		return nil, invalidArgument(fieldErrs)
+	}
+
+	// Charge the sentence against the client's daily character quota
+	characters := utf8.RuneCountInString(sentence)
+	if _, err := ratelimit.ChargeCharacters(ctx, characters); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, "Daily character quota exceeded")
+	}
+
+	result, err := domain.AnalyzeSentenceContext(ctx, sentence)
+	if err != nil {
+		ratelimit.RefundCharacters(ctx, characters)
+		slog.ErrorContext(ctx, "error analyzing sentence", "error", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	metrics.ObserveAnalysis(characters, result.WordCount)
+
+	// A history that cannot be written does not fail the analysis
+	method, _ := grpc.Method(ctx)
+	if err := history.Record(ctx, domain.HistoryEntry{
+		Endpoint:       method,
+		Preview:        sentence,
+		CharacterCount: characters,
+		WordCount:      result.WordCount,
+		VowelCount:     result.VowelCount,
+		ConsonantCount: result.ConsonantCount,
+	}); err != nil {
+		slog.WarnContext(ctx, "error recording analysis history", "error", err)
+	}
+
+	response := &analyzerpb.AnalyzeResponse{
+		WordCount:      int32(result.WordCount),
+		VowelCount:     int32(result.VowelCount),
+		ConsonantCount: int32(result.ConsonantCount),
+	}
+	if result.Custom != nil {
+		response.Custom = make(map[string]int32, len(result.Custom))
+		for name, count := range result.Custom {
+			response.Custom[name] = int32(count)
+		}
+	}
+	return response, nil
+}
+
+// invalidArgument converts field errors into an InvalidArgument status with BadRequest details
+func invalidArgument(fieldErrs []domain.FieldError) error {
+	details := &errdetails.BadRequest{}
+	for _, fieldErr := range fieldErrs {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldErr.Field,
+			Description: fieldErr.Message,
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, "Validation failed").WithDetails(details)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "Validation failed")
+	}
+	return st.Err()
+}
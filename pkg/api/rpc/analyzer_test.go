@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/analyzerpb"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
+)
+
+// newClient serves an AnalyzerServer over an in-memory connection and returns a client for it
+func newClient(t *testing.T, limits config.InputLimits) analyzerpb.AnalyzerServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	analyzerpb.RegisterAnalyzerServiceServer(server, NewAnalyzerServer(limits))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return analyzerpb.NewAnalyzerServiceClient(conn)
+}
+
+func TestAnalyze(t *testing.T) {
+	client := newClient(t, config.InputLimits{MaxSentenceLength: 20})
+
+	tests := []struct {
+		name      string
+		sentence  string
+		wantCode  codes.Code
+		wantWords int32
+		wantField string
+	}{
+		{"valid sentence", "Hello world", codes.OK, 2, ""},
+		{"empty sentence", "", codes.InvalidArgument, 0, "sentence"},
+		{"too long", "This sentence is far too long", codes.InvalidArgument, 0, "sentence"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Sentence: tt.sentence})
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("Expected code %v, got %v (%v)", tt.wantCode, code, err)
+			}
+			if tt.wantCode == codes.OK {
+				if resp.GetWordCount() != tt.wantWords {
+					t.Errorf("Expected %d words, got %d", tt.wantWords, resp.GetWordCount())
+				}
+				if resp.GetVowelCount() != 3 || resp.GetConsonantCount() != 7 {
+					t.Errorf("Expected 3 vowels and 7 consonants, got %d and %d", resp.GetVowelCount(), resp.GetConsonantCount())
+				}
+				return
+			}
+			assertFieldViolation(t, err, tt.wantField)
+		})
+	}
+}
+
+func TestAnalyzeStream(t *testing.T) {
+	client := newClient(t, config.DefaultInputLimits())
+
+	stream, err := client.AnalyzeStream(context.Background(), &analyzerpb.AnalyzeStreamRequest{
+		Sentences: []string{"one", "two words", "three little words"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+
+	wantWords := []int32{1, 2, 3}
+	for i, want := range wantWords {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("result %d: unexpected error %v", i, err)
+		}
+		if resp.GetIndex() != int32(i) {
+			t.Errorf("Expected index %d, got %d", i, resp.GetIndex())
+		}
+		if resp.GetResult().GetWordCount() != want {
+			t.Errorf("result %d: expected %d words, got %d", i, want, resp.GetResult().GetWordCount())
+		}
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected end of stream, got %v", err)
+	}
+
+	// An invalid sentence ends the stream with the index of the offending field
+	stream, err = client.AnalyzeStream(context.Background(), &analyzerpb.AnalyzeStreamRequest{Sentences: []string{"ok", " "}})
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected the first result, got %v", err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	assertFieldViolation(t, err, "sentences[1]")
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	client := newClient(t, config.DefaultInputLimits())
+
+	stream, err := client.AnalyzeBatch(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+	for _, sentence := range []string{"Hello world", "abc"} {
+		if err := stream.Send(&analyzerpb.AnalyzeRequest{Sentence: sentence}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.GetResults()) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(resp.GetResults()))
+	}
+	total := resp.GetTotal()
+	if total.GetWordCount() != 3 || total.GetVowelCount() != 4 || total.GetConsonantCount() != 9 {
+		t.Errorf("Expected totals 3/4/9, got %d/%d/%d", total.GetWordCount(), total.GetVowelCount(), total.GetConsonantCount())
+	}
+}
+
+func TestAnalyzeBatchCustom(t *testing.T) {
+	original := rules.Active()
+	defer rules.SetActive(original)
+	set, err := rules.Compile([]rules.Rule{{Name: "hashtags", Pattern: `#\w+`}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	rules.SetActive(set)
+
+	client := newClient(t, config.DefaultInputLimits())
+	stream, err := client.AnalyzeBatch(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start stream: %v", err)
+	}
+	for _, sentence := range []string{"#go #rules", "no tags", "#grpc"} {
+		if err := stream.Send(&analyzerpb.AnalyzeRequest{Sentence: sentence}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := resp.GetResults()[0].GetCustom(), map[string]int32{"hashtags": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected custom counts %v, got %v", want, got)
+	}
+	if got, want := resp.GetTotal().GetCustom(), map[string]int32{"hashtags": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected custom totals %v, got %v", want, got)
+	}
+}
+
+// assertFieldViolation checks that err carries BadRequest details naming field
+func assertFieldViolation(t *testing.T, err error, field string) {
+	t.Helper()
+
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				if violation.GetField() == field {
+					return
+				}
+			}
+		}
+	}
+	t.Errorf("Expected a field violation for %q in %v", field, err)
+}
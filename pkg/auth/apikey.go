@@ -0,0 +1,414 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/filelock"
+)
+
+// APIKeyHeader is the header machine-to-machine callers use to send an API key
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks raw keys issued by this service so they are easy to spot in logs and secret scanners
+const apiKeyPrefix = "sak_"
+
+// API key errors
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrExpiredAPIKey  = errors.New("API key has expired")
+	ErrRevokedAPIKey  = errors.New("API key has been revoked")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// Quota holds the usage limits attached to an API key
+// A zero value means the limit is not set and the server-wide default applies
+type Quota struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	DailyCharacters   int `json:"daily_characters,omitempty"`
+}
+
+// APIKey represents an issued API key
+// Only the SHA-256 hash of the raw key is kept; the raw key is shown once at issue time
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Roles     []string   `json:"roles"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Quota     Quota      `json:"quota"`
+}
+
+// Expired reports whether the key has passed its expiry time
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Revoked reports whether the key has been revoked
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// APIKeyStore persists API keys
+type APIKeyStore interface {
+	Create(key APIKey) error
+	Get(id string) (APIKey, error)
+	GetByHash(hash string) (APIKey, error)
+	List() ([]APIKey, error)
+	Revoke(id string, at time.Time) error
+}
+
+// MemoryAPIKeyStore is an in-memory APIKeyStore
+type MemoryAPIKeyStore struct {
+	mu     sync.RWMutex
+	keys   map[string]APIKey
+	byHash map[string]string
+}
+
+// NewMemoryAPIKeyStore creates an empty in-memory API key store
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys:   make(map[string]APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+// Create stores a new API key
+func (s *MemoryAPIKeyStore) Create(key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+	s.byHash[key.Hash] = key.ID
+	return nil
+}
+
+// Get returns the API key with the given ID
+func (s *MemoryAPIKeyStore) Get(id string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// GetByHash returns the API key whose raw value hashes to the given hash
+func (s *MemoryAPIKeyStore) GetByHash(hash string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byHash[hash]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return s.keys[id], nil
+}
+
+// List returns all API keys ordered by creation time
+func (s *MemoryAPIKeyStore) List() ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// Revoke marks the API key with the given ID as revoked
+func (s *MemoryAPIKeyStore) Revoke(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		s.keys[id] = key
+	}
+	return nil
+}
+
+// FileAPIKeyStore is an APIKeyStore kept in a JSON file under a directory, so issued keys survive restarts
+// Every lookup reads the file and every change holds a lock file while it rewrites it, so replicas sharing
+// the directory see the same keys
+type FileAPIKeyStore struct {
+	path string
+	lock string
+	// mu serializes changes within this process, where the lock file may not be enforced
+	mu sync.Mutex
+}
+
+// storedAPIKey is how an API key is written to the file, with the hash the API leaves out
+type storedAPIKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// NewFileAPIKeyStore creates a file-backed API key store in dir, creating the directory if needed,
+// and checks that the keys already issued can be read
+func NewFileAPIKeyStore(dir string) (*FileAPIKeyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileAPIKeyStore{path: filepath.Join(dir, "keys.json"), lock: filepath.Join(dir, "keys.lock")}
+	if _, err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create writes a new API key to the file
+func (s *FileAPIKeyStore) Create(key APIKey) error {
+	return s.update(func(keys *MemoryAPIKeyStore) error {
+		return keys.Create(key)
+	})
+}
+
+// Get returns the API key with the given ID
+func (s *FileAPIKeyStore) Get(id string) (APIKey, error) {
+	keys, err := s.read()
+	if err != nil {
+		return APIKey{}, err
+	}
+	return keys.Get(id)
+}
+
+// GetByHash returns the API key whose raw value hashes to the given hash
+func (s *FileAPIKeyStore) GetByHash(hash string) (APIKey, error) {
+	keys, err := s.read()
+	if err != nil {
+		return APIKey{}, err
+	}
+	return keys.GetByHash(hash)
+}
+
+// List returns all API keys ordered by creation time
+func (s *FileAPIKeyStore) List() ([]APIKey, error) {
+	keys, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return keys.List()
+}
+
+// Revoke writes the revocation of the API key with the given ID to the file
+func (s *FileAPIKeyStore) Revoke(id string, at time.Time) error {
+	return s.update(func(keys *MemoryAPIKeyStore) error {
+		return keys.Revoke(id, at)
+	})
+}
+
+// update applies fn to the keys read from the file and writes the result unless fn fails,
+// holding the lock file so changes from other replicas are not lost
+func (s *FileAPIKeyStore) update(fn func(keys *MemoryAPIKeyStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := filelock.Lock(s.lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(keys); err != nil {
+		return err
+	}
+	list, err := keys.List()
+	if err != nil {
+		return err
+	}
+	return s.write(list)
+}
+
+// read decodes the keys in the file into memory, or returns none when there is no file
+func (s *FileAPIKeyStore) read() (*MemoryAPIKeyStore, error) {
+	keys := NewMemoryAPIKeyStore()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []storedAPIKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", s.path, err)
+	}
+	for _, key := range stored {
+		key.APIKey.Hash = key.Hash
+		if err := keys.Create(key.APIKey); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// write replaces the file with keys by writing a temporary file next to it and renaming it into place,
+// so a crash never leaves a partly written file
+func (s *FileAPIKeyStore) write(keys []APIKey) error {
+	stored := make([]storedAPIKey, len(keys))
+	for i, key := range keys {
+		stored[i] = storedAPIKey{APIKey: key, Hash: key.Hash}
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "keys.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+var (
+	apiKeyStoreMu sync.RWMutex
+	apiKeyStore   APIKeyStore = NewMemoryAPIKeyStore()
+)
+
+// SetAPIKeyStore replaces the store used for API keys
+func SetAPIKeyStore(store APIKeyStore) {
+	apiKeyStoreMu.Lock()
+	defer apiKeyStoreMu.Unlock()
+	apiKeyStore = store
+}
+
+// GetAPIKeyStore returns the store used for API keys
+func GetAPIKeyStore() APIKeyStore {
+	apiKeyStoreMu.RLock()
+	defer apiKeyStoreMu.RUnlock()
+	return apiKeyStore
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of a raw API key
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAPIKey creates and stores a new API key
+// It returns the raw key, which must be handed to the caller since only its hash is stored
+func IssueAPIKey(name string, roles []string, ttl time.Duration, quota Quota) (string, APIKey, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", APIKey{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+
+	rawKey := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now().UTC()
+
+	key := APIKey{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Roles:     roles,
+		Hash:      HashAPIKey(rawKey),
+		CreatedAt: now,
+		Quota:     quota,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := GetAPIKeyStore().Create(key); err != nil {
+		return "", APIKey{}, err
+	}
+
+	return rawKey, key, nil
+}
+
+// ValidateAPIKey looks up a raw API key and checks that it is usable
+func ValidateAPIKey(rawKey string) (*APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := GetAPIKeyStore().GetByHash(HashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if key.Revoked() {
+		return nil, ErrRevokedAPIKey
+	}
+	if key.Expired(time.Now()) {
+		return nil, ErrExpiredAPIKey
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey revokes the API key with the given ID
+func RevokeAPIKey(id string) error {
+	return GetAPIKeyStore().Revoke(id, time.Now().UTC())
+}
+
+// ListAPIKeys returns all issued API keys
+func ListAPIKeys() ([]APIKey, error) {
+	return GetAPIKeyStore().List()
+}
+
+// ExtractAPIKeyFromRequest extracts the API key from the X-API-Key header
+func ExtractAPIKeyFromRequest(r *http.Request) (string, bool) {
+	rawKey := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	return rawKey, rawKey != ""
+}
+
+// CheckAPIKeyStore verifies that the API key store can be queried
+func CheckAPIKeyStore() error {
+	if _, err := GetAPIKeyStore().Get(""); err != nil && !errors.Is(err, ErrAPIKeyNotFound) {
+		return err
+	}
+	return nil
+}
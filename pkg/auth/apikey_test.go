@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTestAPIKeyStore installs a fresh in-memory store for the duration of the test
+func useTestAPIKeyStore(t *testing.T) {
+	original := GetAPIKeyStore()
+	SetAPIKeyStore(NewMemoryAPIKeyStore())
+	t.Cleanup(func() {
+		SetAPIKeyStore(original)
+	})
+}
+
+func TestIssueAndValidateAPIKey(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	quota := Quota{RequestsPerMinute: 60, DailyCharacters: 10000}
+	rawKey, key, err := IssueAPIKey("billing-service", []string{"user"}, time.Hour, quota)
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+
+	if key.Hash == rawKey {
+		t.Error("Expected the stored hash to differ from the raw key")
+	}
+	if key.ExpiresAt == nil {
+		t.Fatal("Expected ExpiresAt to be set")
+	}
+
+	got, err := ValidateAPIKey(rawKey)
+	if err != nil {
+		t.Fatalf("Failed to validate API key: %v", err)
+	}
+
+	if got.ID != key.ID {
+		t.Errorf("Expected ID to be %s, got %s", key.ID, got.ID)
+	}
+	if got.Name != "billing-service" {
+		t.Errorf("Expected Name to be billing-service, got %s", got.Name)
+	}
+	if got.Quota != quota {
+		t.Errorf("Expected Quota to be %+v, got %+v", quota, got.Quota)
+	}
+}
+
+func TestValidateAPIKeyErrors(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	revokedKey, revoked, err := IssueAPIKey("revoked", []string{"user"}, 0, Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	if err := RevokeAPIKey(revoked.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	expiredKey, _, err := IssueAPIKey("expired", []string{"user"}, time.Nanosecond, Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		name    string
+		rawKey  string
+		wantErr error
+	}{
+		{name: "missing prefix", rawKey: "not-a-key", wantErr: ErrInvalidAPIKey},
+		{name: "unknown key", rawKey: apiKeyPrefix + "unknown", wantErr: ErrInvalidAPIKey},
+		{name: "revoked key", rawKey: revokedKey, wantErr: ErrRevokedAPIKey},
+		{name: "expired key", rawKey: expiredKey, wantErr: ErrExpiredAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateAPIKey(tt.rawKey)
+			if err != tt.wantErr {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRevokeUnknownAPIKey(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	if err := RevokeAPIKey("missing"); err != ErrAPIKeyNotFound {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	for _, name := range []string{"first", "second"} {
+		if _, _, err := IssueAPIKey(name, []string{"user"}, 0, Quota{}); err != nil {
+			t.Fatalf("Failed to issue API key: %v", err)
+		}
+	}
+
+	keys, err := ListAPIKeys()
+	if err != nil {
+		t.Fatalf("Failed to list API keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(keys))
+	}
+}
+
+func TestGetAuthInfoFromRequestWithAPIKey(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	rawKey, key, err := IssueAPIKey("reporting", []string{"user", "reports"}, 0, Quota{DailyCharacters: 500})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set(APIKeyHeader, rawKey)
+
+	authInfo, err := GetAuthInfoFromRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to get auth info: %v", err)
+	}
+
+	if authInfo.APIKeyID != key.ID {
+		t.Errorf("Expected APIKeyID to be %s, got %s", key.ID, authInfo.APIKeyID)
+	}
+	if authInfo.UserID != "apikey:"+key.ID {
+		t.Errorf("Expected UserID to be apikey:%s, got %s", key.ID, authInfo.UserID)
+	}
+	if !authInfo.HasRole("reports") {
+		t.Errorf("Expected roles to include reports, got %v", authInfo.Roles)
+	}
+	if authInfo.Quota.DailyCharacters != 500 {
+		t.Errorf("Expected DailyCharacters quota to be 500, got %d", authInfo.Quota.DailyCharacters)
+	}
+}
+
+// unreachableAPIKeyStore simulates a store whose backend is down
+type unreachableAPIKeyStore struct {
+	APIKeyStore
+}
+
+func (unreachableAPIKeyStore) Get(id string) (APIKey, error) {
+	return APIKey{}, errors.New("connection refused")
+}
+
+func TestCheckAPIKeyStore(t *testing.T) {
+	useTestAPIKeyStore(t)
+
+	if err := CheckAPIKeyStore(); err != nil {
+		t.Errorf("Expected the memory store to be reachable, got %v", err)
+	}
+
+	SetAPIKeyStore(unreachableAPIKeyStore{})
+	if err := CheckAPIKeyStore(); err == nil {
+		t.Error("Expected an error for an unreachable store")
+	}
+}
+
+func TestFileAPIKeyStore(t *testing.T) {
+	original := GetAPIKeyStore()
+	defer SetAPIKeyStore(original)
+
+	dir := t.TempDir()
+	store, err := NewFileAPIKeyStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileAPIKeyStore() error = %v", err)
+	}
+	SetAPIKeyStore(store)
+
+	rawKey, key, err := IssueAPIKey("billing-service", []string{"user"}, 0, Quota{DailyCharacters: 500})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	revokedKey, revoked, err := IssueAPIKey("old-service", []string{"user"}, 0, Quota{})
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	if err := RevokeAPIKey(revoked.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+	if err := store.Revoke("missing", time.Now()); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() of an unknown key error = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	// A restarted server, or another store on the same directory, knows the issued keys
+	reopened, err := NewFileAPIKeyStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileAPIKeyStore() error = %v", err)
+	}
+	SetAPIKeyStore(reopened)
+
+	got, err := ValidateAPIKey(rawKey)
+	if err != nil {
+		t.Fatalf("Failed to validate API key after reopening: %v", err)
+	}
+	if got.ID != key.ID || got.Quota != key.Quota || !got.CreatedAt.Equal(key.CreatedAt) {
+		t.Errorf("Expected key %+v after reopening, got %+v", key, got)
+	}
+	if _, err := ValidateAPIKey(revokedKey); !errors.Is(err, ErrRevokedAPIKey) {
+		t.Errorf("Expected the revocation to be kept, got %v", err)
+	}
+	if keys, _ := ListAPIKeys(); len(keys) != 2 {
+		t.Errorf("Expected 2 keys after reopening, got %d", len(keys))
+	}
+}
+
+func TestFileAPIKeyStoreSharedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	first, err := NewFileAPIKeyStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileAPIKeyStore() error = %v", err)
+	}
+	second, err := NewFileAPIKeyStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileAPIKeyStore() error = %v", err)
+	}
+
+	// Two replicas issue keys at the same time on the same directory
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		store := first
+		if i%2 == 1 {
+			store = second
+		}
+		wg.Add(1)
+		go func(i int, store *FileAPIKeyStore) {
+			defer wg.Done()
+			key := APIKey{ID: fmt.Sprintf("key-%d", i), Hash: fmt.Sprintf("hash-%d", i), CreatedAt: time.Now()}
+			if err := store.Create(key); err != nil {
+				t.Errorf("Create() error = %v", err)
+			}
+		}(i, store)
+	}
+	wg.Wait()
+
+	for _, store := range []*FileAPIKeyStore{first, second} {
+		if keys, _ := store.List(); len(keys) != 20 {
+			t.Errorf("Expected every replica to see 20 keys, got %d", len(keys))
+		}
+	}
+
+	// A revocation on one replica is seen by the other
+	if err := first.Revoke("key-1", time.Now()); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if key, err := second.GetByHash("hash-1"); err != nil || !key.Revoked() {
+		t.Errorf("Expected the other replica to see the revocation, got %+v, %v", key, err)
+	}
+}
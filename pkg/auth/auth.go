@@ -23,6 +23,20 @@ var (
 type AuthInfo struct {
 	UserID string
 	Roles  []string
+
+	// APIKeyID and Quota are set when the request was authenticated with an API key
+	APIKeyID string
+	Quota    Quota
+}
+
+// HasRole reports whether the authenticated user has the given role
+func (a *AuthInfo) HasRole(role string) bool {
+	for _, r := range a.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 // JWTClaims represents the claims in the JWT token
@@ -104,7 +118,11 @@ func ValidateToken(tokenString string) (*JWTClaims, error) {
 
 // ExtractTokenFromRequest extracts the JWT token from the Authorization header
 func ExtractTokenFromRequest(r *http.Request) (string, error) {
-	authHeader := r.Header.Get("Authorization")
+	return ParseBearerToken(r.Header.Get("Authorization"))
+}
+
+// ParseBearerToken extracts the JWT token from an Authorization header value
+func ParseBearerToken(authHeader string) (string, error) {
 	if authHeader == "" {
 		return "", ErrNoToken
 	}
@@ -119,8 +137,30 @@ func ExtractTokenFromRequest(r *http.Request) (string, error) {
 }
 
 // GetAuthInfoFromRequest extracts the AuthInfo from the request
+// An X-API-Key header takes precedence over a Bearer token
 func GetAuthInfoFromRequest(r *http.Request) (*AuthInfo, error) {
-	tokenString, err := ExtractTokenFromRequest(r)
+	rawKey, _ := ExtractAPIKeyFromRequest(r)
+	return Authenticate(r.Header.Get("Authorization"), rawKey)
+}
+
+// Authenticate validates an API key or, when none is given, an Authorization header value
+// It is shared by the HTTP middleware and the gRPC interceptors
+func Authenticate(authHeader, rawKey string) (*AuthInfo, error) {
+	if rawKey = strings.TrimSpace(rawKey); rawKey != "" {
+		key, err := ValidateAPIKey(rawKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuthInfo{
+			UserID:   "apikey:" + key.ID,
+			Roles:    key.Roles,
+			APIKeyID: key.ID,
+			Quota:    key.Quota,
+		}, nil
+	}
+
+	tokenString, err := ParseBearerToken(authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -152,3 +192,13 @@ func GetAuthInfo(ctx context.Context) (*AuthInfo, bool) {
 	authInfo, ok := ctx.Value(AuthInfoKey).(*AuthInfo)
 	return authInfo, ok
 }
+
+// CheckSigningKey verifies that tokens can be signed and validated with the configured key
+func CheckSigningKey() error {
+	token, err := GenerateToken("health-check", nil)
+	if err != nil {
+		return err
+	}
+	_, err = ValidateToken(token)
+	return err
+}
@@ -185,3 +185,12 @@ func TestGetAuthInfoFromEmptyContext(t *testing.T) {
 		t.Errorf("Expected GetAuthInfo to return false for empty context")
 	}
 }
+
+func TestCheckSigningKey(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	if err := CheckSigningKey(); err != nil {
+		t.Errorf("Expected the signing key to be usable, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// ErrLoginLocked is returned when login attempts are temporarily blocked
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginAttempts tracks failed login attempts for a single username or client IP
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// LoginAttemptStore persists failed login attempts
+// Implementations backed by a shared store (e.g. Redis) let replicas enforce the same limits
+type LoginAttemptStore interface {
+	// Get returns the attempts recorded for key, or a zero value if there are none
+	Get(key string) (LoginAttempts, error)
+	// RecordFailure atomically adds a failure for key, starting a new count
+	// when the previous failure is older than window
+	RecordFailure(key string, now time.Time, window time.Duration) (LoginAttempts, error)
+	// Lock blocks key until the given time
+	Lock(key string, until time.Time) error
+	// Reset clears all attempts recorded for key
+	Reset(key string) error
+}
+
+// MemoryLoginAttemptStore is an in-memory LoginAttemptStore for single-replica deployments
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]loginAttemptEntry
+	writes   int
+}
+
+// loginAttemptEntry is the attempts of one key with the window of the policy that recorded them,
+// so usernames and IPs, which have their own policies, each expire with their own window
+type loginAttemptEntry struct {
+	LoginAttempts
+	window time.Duration
+}
+
+// NewMemoryLoginAttemptStore creates an empty in-memory login attempt store
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: make(map[string]loginAttemptEntry),
+	}
+}
+
+// Get returns the attempts recorded for key
+func (s *MemoryLoginAttemptStore) Get(key string) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key].LoginAttempts, nil
+}
+
+// RecordFailure adds a failure for key
+func (s *MemoryLoginAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.attempts[key]
+	if now.Sub(entry.LastFailure) > window {
+		entry.Failures = 0
+	}
+	entry.Failures++
+	entry.LastFailure = now
+	entry.window = window
+	s.attempts[key] = entry
+
+	// Periodically drop stale entries so username spraying cannot grow the map forever
+	s.writes++
+	if s.writes%1000 == 0 {
+		s.prune(now)
+	}
+
+	return entry.LoginAttempts, nil
+}
+
+// Lock blocks key until the given time
+func (s *MemoryLoginAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.attempts[key]
+	entry.LockedUntil = until
+	s.attempts[key] = entry
+	return nil
+}
+
+// Reset clears all attempts recorded for key
+func (s *MemoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// prune drops the entries whose failures have left their window and that are not locked
+func (s *MemoryLoginAttemptStore) prune(now time.Time) {
+	for key, entry := range s.attempts {
+		if now.Sub(entry.LastFailure) > entry.window && now.After(entry.LockedUntil) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+// AuditEvent describes a security-relevant login event
+type AuditEvent struct {
+	Type       string
+	Username   string
+	IP         string
+	Time       time.Time
+	RetryAfter time.Duration
+}
+
+// Login audit event types
+const (
+	AuditLoginSucceeded = "login_succeeded"
+	AuditLoginFailed    = "login_failed"
+	AuditLoginLocked    = "login_locked"
+	AuditLoginThrottled = "login_throttled"
+)
+
+// AuditSink receives login audit events
+// It can be replaced to forward events to a SIEM
+var AuditSink = func(event AuditEvent) {
+	slog.Info("audit",
+		"event", event.Type,
+		"username", event.Username,
+		"ip", event.IP,
+		"retry_after", event.RetryAfter,
+	)
+}
+
+var (
+	loginAttemptStoreMu sync.RWMutex
+	loginAttemptStore   LoginAttemptStore = NewMemoryLoginAttemptStore()
+)
+
+// SetLoginAttemptStore replaces the store used to track failed logins
+func SetLoginAttemptStore(store LoginAttemptStore) {
+	loginAttemptStoreMu.Lock()
+	defer loginAttemptStoreMu.Unlock()
+	loginAttemptStore = store
+}
+
+// GetLoginAttemptStore returns the store used to track failed logins
+func GetLoginAttemptStore() LoginAttemptStore {
+	loginAttemptStoreMu.RLock()
+	defer loginAttemptStoreMu.RUnlock()
+	return loginAttemptStore
+}
+
+// CheckLoginAllowed reports whether a login for username from ip may be attempted now
+// When it may not, it returns ErrLoginLocked and how long the caller must wait
+func CheckLoginAllowed(username, ip string) (time.Duration, error) {
+	store := GetLoginAttemptStore()
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range loginAttemptKeys(username, ip) {
+		attempts, err := store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if wait := attempts.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		AuditSink(AuditEvent{Type: AuditLoginThrottled, Username: username, IP: ip, Time: now, RetryAfter: retryAfter})
+		return retryAfter, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+// RecordLoginFailure records a failed login and applies the backoff or lockout of cfg
+func RecordLoginFailure(cfg config.LoginConfig, username, ip string) error {
+	store := GetLoginAttemptStore()
+	now := time.Now()
+
+	AuditSink(AuditEvent{Type: AuditLoginFailed, Username: username, IP: ip, Time: now})
+
+	keys := loginAttemptKeys(username, ip)
+	policies := []config.LockoutPolicy{cfg.Username, cfg.IP}
+
+	for i, key := range keys {
+		policy := policies[i]
+		attempts, err := store.RecordFailure(key, now, policy.Window)
+		if err != nil {
+			return err
+		}
+
+		delay := lockoutDelay(policy, attempts.Failures)
+		if delay <= 0 {
+			continue
+		}
+		if err := store.Lock(key, now.Add(delay)); err != nil {
+			return err
+		}
+		if attempts.Failures >= policy.MaxAttempts {
+			AuditSink(AuditEvent{Type: AuditLoginLocked, Username: username, IP: ip, Time: now, RetryAfter: delay})
+		}
+	}
+
+	return nil
+}
+
+// RecordLoginSuccess clears the failed attempts for username
+// The per-IP count is kept so one valid account cannot be used to reset it
+func RecordLoginSuccess(username, ip string) error {
+	AuditSink(AuditEvent{Type: AuditLoginSucceeded, Username: username, IP: ip, Time: time.Now()})
+	return GetLoginAttemptStore().Reset(loginAttemptKeys(username, ip)[0])
+}
+
+// lockoutDelay returns how long a key throttled by policy p must wait after its nth failure
+func lockoutDelay(p config.LockoutPolicy, failures int) time.Duration {
+	if p.MaxAttempts > 0 && failures >= p.MaxAttempts {
+		return p.LockoutDuration
+	}
+	if failures < p.BackoffAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.BackoffAfter; i < failures; i++ {
+		delay *= 2
+		if delay >= p.LockoutDuration {
+			return p.LockoutDuration
+		}
+	}
+	return delay
+}
+
+func loginAttemptKeys(username, ip string) []string {
+	return []string{
+		"user:" + strings.ToLower(strings.TrimSpace(username)),
+		"ip:" + ip,
+	}
+}
+
+// trustProxyHeaders makes ClientIP honour X-Forwarded-For
+var trustProxyHeaders atomic.Bool
+
+// SetTrustProxyHeaders sets whether ClientIP takes the client IP from X-Forwarded-For, which is only
+// safe behind a proxy that sets the header, such as Kong
+func SetTrustProxyHeaders(trust bool) {
+	trustProxyHeaders.Store(trust)
+}
+
+// ClientIP returns the IP address of the client that sent the request
+// X-Forwarded-For is only honoured once SetTrustProxyHeaders(true) has been called
+func ClientIP(r *http.Request) string {
+	if trustProxyHeaders.Load() {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// CheckLoginAttemptStore verifies that the login attempt store can be queried
+func CheckLoginAttemptStore() error {
+	_, err := GetLoginAttemptStore().Get("health-check")
+	return err
+}
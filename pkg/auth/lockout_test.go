@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// useTestLoginAttemptStore installs a fresh in-memory store and a silent audit sink for the duration of the test
+func useTestLoginAttemptStore(t *testing.T) *[]AuditEvent {
+	originalStore := GetLoginAttemptStore()
+	originalSink := AuditSink
+
+	var events []AuditEvent
+	SetLoginAttemptStore(NewMemoryLoginAttemptStore())
+	AuditSink = func(event AuditEvent) {
+		events = append(events, event)
+	}
+
+	t.Cleanup(func() {
+		SetLoginAttemptStore(originalStore)
+		AuditSink = originalSink
+	})
+	return &events
+}
+
+func TestLockoutDelay(t *testing.T) {
+	policy := config.LockoutPolicy{
+		BackoffAfter:    3,
+		MaxAttempts:     6,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, time.Minute},
+		{7, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDelay(policy, tt.failures); got != tt.want {
+			t.Errorf("lockoutDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRecordLoginFailureLocksUsername(t *testing.T) {
+	events := useTestLoginAttemptStore(t)
+	cfg := config.DefaultLoginConfig()
+	cfg.Username.MaxAttempts = 3
+
+	// Two failures stay below the backoff threshold
+	for i := 0; i < 2; i++ {
+		if err := RecordLoginFailure(cfg, "Alice", "10.0.0.1"); err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+	}
+	if _, err := CheckLoginAllowed("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("Expected login to be allowed, got %v", err)
+	}
+
+	// The third failure reaches MaxAttempts and locks the username
+	if err := RecordLoginFailure(cfg, "alice", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+
+	retryAfter, err := CheckLoginAllowed("ALICE", "10.0.0.2")
+	if err != ErrLoginLocked {
+		t.Fatalf("Expected ErrLoginLocked, got %v", err)
+	}
+	if retryAfter <= 0 || retryAfter > 15*time.Minute {
+		t.Errorf("Expected retryAfter within the lockout duration, got %v", retryAfter)
+	}
+
+	// Other usernames from the same IP are not affected yet
+	if _, err := CheckLoginAllowed("bob", "10.0.0.1"); err != nil {
+		t.Errorf("Expected bob to be allowed, got %v", err)
+	}
+
+	var locked bool
+	for _, event := range *events {
+		if event.Type == AuditLoginLocked {
+			locked = true
+		}
+	}
+	if !locked {
+		t.Error("Expected a login_locked audit event")
+	}
+}
+
+func TestRecordLoginFailureLocksIP(t *testing.T) {
+	useTestLoginAttemptStore(t)
+	cfg := config.DefaultLoginConfig()
+	cfg.IP.MaxAttempts = 2
+
+	// Spray different usernames from one IP
+	RecordLoginFailure(cfg, "user1", "10.0.0.9")
+	RecordLoginFailure(cfg, "user2", "10.0.0.9")
+
+	if _, err := CheckLoginAllowed("user3", "10.0.0.9"); err != ErrLoginLocked {
+		t.Errorf("Expected ErrLoginLocked for the sprayed IP, got %v", err)
+	}
+	if _, err := CheckLoginAllowed("user3", "10.0.0.10"); err != nil {
+		t.Errorf("Expected other IPs to be allowed, got %v", err)
+	}
+}
+
+func TestRecordLoginSuccessResetsUsername(t *testing.T) {
+	useTestLoginAttemptStore(t)
+
+	for i := 0; i < 3; i++ {
+		RecordLoginFailure(config.DefaultLoginConfig(), "carol", "10.0.0.1")
+	}
+	if err := RecordLoginSuccess("carol", "10.0.0.1"); err != nil {
+		t.Fatalf("Failed to record success: %v", err)
+	}
+
+	attempts, _ := GetLoginAttemptStore().Get("user:carol")
+	if attempts.Failures != 0 {
+		t.Errorf("Expected failures to be reset, got %d", attempts.Failures)
+	}
+	attempts, _ = GetLoginAttemptStore().Get("ip:10.0.0.1")
+	if attempts.Failures != 3 {
+		t.Errorf("Expected IP failures to be kept, got %d", attempts.Failures)
+	}
+}
+
+func TestMemoryLoginAttemptStoreWindow(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Now()
+
+	store.RecordFailure("key", now.Add(-time.Hour), time.Minute)
+	attempts, _ := store.RecordFailure("key", now, time.Minute)
+
+	if attempts.Failures != 1 {
+		t.Errorf("Expected failures outside the window to be forgotten, got %d", attempts.Failures)
+	}
+}
+
+func TestMemoryLoginAttemptStorePruneUsesEntryWindow(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Now()
+
+	// A short username window must not prune an IP entry that is still inside its longer window
+	store.RecordFailure("ip:10.0.0.1", now.Add(-30*time.Minute), time.Hour)
+	store.RecordFailure("user:alice", now.Add(-30*time.Minute), time.Minute)
+	store.prune(now)
+
+	if attempts, _ := store.Get("ip:10.0.0.1"); attempts.Failures != 1 {
+		t.Errorf("Expected the IP entry to be kept inside its window, got %d failures", attempts.Failures)
+	}
+	if attempts, _ := store.Get("user:alice"); attempts.Failures != 0 {
+		t.Errorf("Expected the username entry to be pruned after its window, got %d failures", attempts.Failures)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+
+	if ip := ClientIP(req); ip != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1, got %s", ip)
+	}
+
+	SetTrustProxyHeaders(true)
+	defer SetTrustProxyHeaders(false)
+
+	if ip := ClientIP(req); ip != "203.0.113.5" {
+		t.Errorf("Expected 203.0.113.5 with trusted proxy headers, got %s", ip)
+	}
+}
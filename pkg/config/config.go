@@ -1,19 +1,329 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
+	Port      int
+	Server    ServerConfig
+	RateLimit RateLimitConfig
+	Login     LoginConfig
+	Input     InputLimits
+	Log       LogConfig
+	Tracing   TracingConfig
+	Shutdown  ShutdownConfig
+	Docs      DocsConfig
+	GRPC      GRPCConfig
+	GraphQL   GraphQLConfig
+	WebSocket WebSocketConfig
+	Jobs      JobsConfig
+	Upload    UploadConfig
+	Corpus    CorpusConfig
+	History   HistoryConfig
+	Rules     RulesConfig
+	APIKeys   APIKeysConfig
+	API       APIConfig
+}
+
+// APIConfig holds the API versioning configuration
+type APIConfig struct {
+	// DeprecatedAt is when the unversioned routes, aliases of /v1, were deprecated
+	DeprecatedAt time.Time
+	// SunsetAt is when the unversioned routes are expected to stop working; no Sunset header is sent when zero
+	SunsetAt time.Time
+}
+
+// JobsConfig holds the configuration of long-running analyses and their progress streams
+type JobsConfig struct {
+	// Enabled serves the /analyze/jobs endpoints
+	Enabled bool
+	// MaxBodyBytes is the largest job request body accepted, in bytes; it replaces the usual input limit
+	MaxBodyBytes int64
+	// MaxSentences is the most sentences a batch job may contain
+	MaxSentences int
+	// Retention is how long a finished job's events can still be replayed
+	Retention time.Duration
+	// KeepAlive is the interval between comments sent on an idle event stream so proxies keep it open
+	KeepAlive time.Duration
+	// MaxRunning is the most jobs each client may have running at once
+	MaxRunning int
+	// MaxRetained is the most jobs, running or finished, kept for each client until they are pruned
+	MaxRetained int
+}
+
+// UploadConfig holds the configuration of document uploads
+type UploadConfig struct {
+	// Enabled serves the /v1/analyze/documents endpoint
+	Enabled bool
+	// MaxFileBytes is the largest document accepted, in bytes
+	MaxFileBytes int64
+	// MaxTextBytes bounds the text extracted from a document, which compressed formats such as DOCX can inflate
+	MaxTextBytes int
+}
+
+// APIKeysConfig holds the configuration of issued API keys
+type APIKeysConfig struct {
+	// Dir is the directory API keys are stored in; they are only kept in memory, and lost on restart, when it is empty
+	Dir string
+}
+
+// CorpusConfig holds the configuration of named corpora
+type CorpusConfig struct {
+	// Enabled serves the /v1/corpora endpoints
+	Enabled bool
+	// Dir is the directory corpora are stored in; they are only kept in memory when it is empty
+	Dir string
+	// MaxDocuments is the most documents a corpus may hold
+	MaxDocuments int
+	// MaxCorpora is the most corpora each client may have
+	MaxCorpora int
+}
+
+// HistoryConfig holds the configuration of each client's history of past analyses
+type HistoryConfig struct {
+	// Enabled records analyses and serves the /v1/history endpoints
+	Enabled bool
+	// Dir is the directory histories are stored in; they are only kept in memory when it is empty
+	Dir string
+	// RetentionDays is how long analyses are kept by default, and the longest a client may keep them
+	RetentionDays int
+	// MaxEntries is the most analyses kept per client; the oldest are dropped first
+	MaxEntries int
+}
+
+// RulesConfig holds the configuration of the custom counting rules
+type RulesConfig struct {
+	// File is the YAML or JSON file of rules; no custom counts are returned when it is empty
+	File string
+	// ReloadInterval is how often the file is checked for changes; when 0 it is only reloaded on SIGHUP
+	ReloadInterval time.Duration
+}
+
+// WebSocketConfig holds the live analysis WebSocket configuration
+type WebSocketConfig struct {
+	// Enabled serves the /analyze/live endpoint
+	Enabled bool
+	// IdleTimeout closes a connection that has sent neither a message nor a pong for this long
+	IdleTimeout time.Duration
+	// AllowedOrigins lists the browser origins that may connect; the service's own origin is always allowed
+	AllowedOrigins []string
+}
+
+// GraphQLConfig holds the GraphQL endpoint configuration
+type GraphQLConfig struct {
+	// Enabled serves the /graphql endpoint
+	Enabled bool
+	// MaxBatchSize is the most operations a batched request may contain
+	MaxBatchSize int
+}
+
+// GRPCConfig holds the gRPC server configuration
+type GRPCConfig struct {
+	// Enabled serves the gRPC API alongside HTTP
+	Enabled bool
+	// Port is the port the gRPC server listens on
 	Port int
 }
 
+// DocsConfig holds the API documentation configuration
+type DocsConfig struct {
+	// Enabled serves Swagger UI and the OpenAPI specification; production deployments may turn it off
+	Enabled bool
+}
+
+// ServerConfig holds the HTTP server's timeouts
+type ServerConfig struct {
+	// ReadHeaderTimeout bounds how long a client may take to send the request headers
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout bounds how long a client may take to send the whole request, body included
+	ReadTimeout time.Duration
+}
+
+// ShutdownConfig holds the graceful shutdown configuration
+type ShutdownConfig struct {
+	// DrainDelay is how long /readyz fails before the server stops accepting connections,
+	// giving load balancers time to take the pod out of rotation
+	DrainDelay time.Duration
+	// Timeout bounds how long in-flight requests may take to finish
+	Timeout time.Duration
+}
+
+// TracingConfig holds the OpenTelemetry tracing configuration
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://otel-collector:4318; tracing export is off when empty
+	Endpoint string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are sampled; incoming sampled parents are always honoured
+	SampleRatio float64
+}
+
+// LogConfig holds the logging configuration
+type LogConfig struct {
+	// Level is the minimum level logged: debug, info, warn or error
+	Level string
+	// Format is the log output format: json or text
+	Format string
+}
+
+// LoginConfig holds the login throttling configuration
+type LoginConfig struct {
+	Username LockoutPolicy
+	IP       LockoutPolicy
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For, for deployments behind Kong
+	TrustProxyHeaders bool
+}
+
+// LockoutPolicy controls how failed logins are throttled for one kind of key
+type LockoutPolicy struct {
+	// BackoffAfter is the number of failures after which exponential backoff starts
+	BackoffAfter int
+	// MaxAttempts is the number of failures after which the key is locked out (0 disables the lockout)
+	MaxAttempts int
+	// BaseDelay is the first backoff delay; it doubles with every further failure
+	BaseDelay time.Duration
+	// LockoutDuration is how long a key stays locked once MaxAttempts is reached
+	LockoutDuration time.Duration
+	// Window is how long a failure counts towards the limits
+	Window time.Duration
+}
+
+// DefaultLoginConfig returns the login throttling used when none is configured
+func DefaultLoginConfig() LoginConfig {
+	return LoginConfig{
+		Username: LockoutPolicy{
+			BackoffAfter:    3,
+			MaxAttempts:     10,
+			BaseDelay:       time.Second,
+			LockoutDuration: 15 * time.Minute,
+			Window:          15 * time.Minute,
+		},
+		IP: LockoutPolicy{
+			BackoffAfter:    10,
+			MaxAttempts:     50,
+			BaseDelay:       time.Second,
+			LockoutDuration: 15 * time.Minute,
+			Window:          15 * time.Minute,
+		},
+	}
+}
+
+// InputLimits holds the limits applied to analysis request bodies
+type InputLimits struct {
+	// MaxBodyBytes is the largest request body accepted, in bytes
+	MaxBodyBytes int64
+	// MaxSentenceLength is the longest sentence accepted, in characters
+	MaxSentenceLength int
+}
+
+// DefaultInputLimits returns the input limits used when none are configured
+func DefaultInputLimits() InputLimits {
+	return InputLimits{
+		MaxBodyBytes:      1 << 20, // 1 MiB
+		MaxSentenceLength: 100000,
+	}
+}
+
+// RateLimitConfig holds the per-client rate limit and quota configuration
+type RateLimitConfig struct {
+	// Enabled turns the rate limiter on; it can be disabled when Kong enforces limits instead
+	Enabled bool
+	// RequestsPerMinute is the default sustained request rate per client
+	RequestsPerMinute int
+	// Burst is the number of requests a client may send at once; it defaults to RequestsPerMinute
+	Burst int
+	// RoleRequestsPerMinute overrides RequestsPerMinute for clients with the given role
+	RoleRequestsPerMinute map[string]int
+	// DailyCharacters is the default number of characters a client may analyze per UTC day (0 means unlimited)
+	DailyCharacters int
+	// RoleDailyCharacters overrides DailyCharacters for clients with the given role
+	RoleDailyCharacters map[string]int
+}
+
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() Config {
 	config := Config{
 		Port: 8080, // Default port
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerMinute: 60,
+		},
+		Login: DefaultLoginConfig(),
+		Input: DefaultInputLimits(),
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "sentence-analyzer-vm",
+			SampleRatio: 1,
+		},
+		Server: ServerConfig{
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       60 * time.Second,
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: 5 * time.Second,
+			Timeout:    15 * time.Second,
+		},
+		Docs: DocsConfig{
+			Enabled: true,
+		},
+		GRPC: GRPCConfig{
+			Enabled: true,
+			Port:    9090,
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:      true,
+			MaxBatchSize: 10,
+		},
+		WebSocket: WebSocketConfig{
+			Enabled:     true,
+			IdleTimeout: 60 * time.Second,
+		},
+		API: APIConfig{
+			DeprecatedAt: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+			SunsetAt:     time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+		},
+		Jobs: JobsConfig{
+			Enabled:      true,
+			MaxBodyBytes: 16 << 20, // 16 MiB
+			MaxSentences: 1000,
+			Retention:    10 * time.Minute,
+			KeepAlive:    15 * time.Second,
+			MaxRunning:   2,
+			MaxRetained:  20,
+		},
+		Upload: UploadConfig{
+			Enabled:      true,
+			MaxFileBytes: 10 << 20, // 10 MiB
+			MaxTextBytes: 16 << 20, // 16 MiB
+		},
+		Corpus: CorpusConfig{
+			Enabled:      true,
+			Dir:          "data/corpora",
+			MaxDocuments: 10000,
+			MaxCorpora:   100,
+		},
+		History: HistoryConfig{
+			Enabled:       true,
+			Dir:           "data/history",
+			RetentionDays: 30,
+			MaxEntries:    1000,
+		},
+		Rules: RulesConfig{
+			ReloadInterval: 30 * time.Second,
+		},
+		APIKeys: APIKeysConfig{
+			Dir: "data/api-keys",
+		},
 	}
 
 	// Override with environment variables if set
@@ -23,5 +333,249 @@ func LoadConfig() Config {
 		}
 	}
 
+	if enabledStr := os.Getenv("RATE_LIMIT_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			config.RateLimit.Enabled = enabled
+		}
+	}
+	if rpm, err := strconv.Atoi(os.Getenv("RATE_LIMIT_RPM")); err == nil && rpm > 0 {
+		config.RateLimit.RequestsPerMinute = rpm
+	}
+	if burst, err := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST")); err == nil && burst > 0 {
+		config.RateLimit.Burst = burst
+	}
+	if chars, err := strconv.Atoi(os.Getenv("QUOTA_DAILY_CHARACTERS")); err == nil && chars >= 0 {
+		config.RateLimit.DailyCharacters = chars
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS")); err == nil && attempts >= 0 {
+		config.Login.Username.MaxAttempts = attempts
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS_PER_IP")); err == nil && attempts >= 0 {
+		config.Login.IP.MaxAttempts = attempts
+	}
+	if delay, err := time.ParseDuration(os.Getenv("LOGIN_BACKOFF_BASE")); err == nil && delay > 0 {
+		config.Login.Username.BaseDelay = delay
+		config.Login.IP.BaseDelay = delay
+	}
+	if lockout, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION")); err == nil && lockout > 0 {
+		config.Login.Username.LockoutDuration = lockout
+		config.Login.IP.LockoutDuration = lockout
+	}
+	if trust, err := strconv.ParseBool(os.Getenv("TRUST_PROXY_HEADERS")); err == nil {
+		config.Login.TrustProxyHeaders = trust
+	}
+	if maxBody, err := strconv.ParseInt(os.Getenv("MAX_BODY_BYTES"), 10, 64); err == nil && maxBody > 0 {
+		config.Input.MaxBodyBytes = maxBody
+	}
+	if maxLength, err := strconv.Atoi(os.Getenv("MAX_SENTENCE_LENGTH")); err == nil && maxLength > 0 {
+		config.Input.MaxSentenceLength = maxLength
+	}
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		config.Log.Level = level
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		config.Log.Format = format
+	}
+	config.Tracing.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+	if ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil && ratio >= 0 && ratio <= 1 {
+		config.Tracing.SampleRatio = ratio
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("DOCS_ENABLED")); err == nil {
+		config.Docs.Enabled = enabled
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("GRPC_ENABLED")); err == nil {
+		config.GRPC.Enabled = enabled
+	}
+	if port, err := strconv.Atoi(os.Getenv("GRPC_PORT")); err == nil && port >= 0 {
+		config.GRPC.Port = port
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("GRAPHQL_ENABLED")); err == nil {
+		config.GraphQL.Enabled = enabled
+	}
+	if size, err := strconv.Atoi(os.Getenv("GRAPHQL_MAX_BATCH_SIZE")); err == nil && size > 0 {
+		config.GraphQL.MaxBatchSize = size
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("WEBSOCKET_ENABLED")); err == nil {
+		config.WebSocket.Enabled = enabled
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("WEBSOCKET_IDLE_TIMEOUT")); err == nil && timeout > 0 {
+		config.WebSocket.IdleTimeout = timeout
+	}
+	config.WebSocket.AllowedOrigins = parseList(os.Getenv("WEBSOCKET_ALLOWED_ORIGINS"))
+	if enabled, err := strconv.ParseBool(os.Getenv("JOBS_ENABLED")); err == nil {
+		config.Jobs.Enabled = enabled
+	}
+	if maxBody, err := strconv.ParseInt(os.Getenv("JOBS_MAX_BODY_BYTES"), 10, 64); err == nil && maxBody > 0 {
+		config.Jobs.MaxBodyBytes = maxBody
+	}
+	if maxSentences, err := strconv.Atoi(os.Getenv("JOBS_MAX_SENTENCES")); err == nil && maxSentences > 0 {
+		config.Jobs.MaxSentences = maxSentences
+	}
+	if retention, err := time.ParseDuration(os.Getenv("JOBS_RETENTION")); err == nil && retention > 0 {
+		config.Jobs.Retention = retention
+	}
+	if keepAlive, err := time.ParseDuration(os.Getenv("JOBS_KEEPALIVE")); err == nil && keepAlive > 0 {
+		config.Jobs.KeepAlive = keepAlive
+	}
+	if maxRunning, err := strconv.Atoi(os.Getenv("JOBS_MAX_RUNNING")); err == nil && maxRunning > 0 {
+		config.Jobs.MaxRunning = maxRunning
+	}
+	if maxRetained, err := strconv.Atoi(os.Getenv("JOBS_MAX_RETAINED")); err == nil && maxRetained > 0 {
+		config.Jobs.MaxRetained = maxRetained
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("UPLOAD_ENABLED")); err == nil {
+		config.Upload.Enabled = enabled
+	}
+	if maxFile, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_FILE_BYTES"), 10, 64); err == nil && maxFile > 0 {
+		config.Upload.MaxFileBytes = maxFile
+	}
+	if maxText, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_TEXT_BYTES")); err == nil && maxText > 0 {
+		config.Upload.MaxTextBytes = maxText
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("CORPUS_ENABLED")); err == nil {
+		config.Corpus.Enabled = enabled
+	}
+	if dir, ok := os.LookupEnv("CORPUS_DIR"); ok {
+		config.Corpus.Dir = dir
+	}
+	if maxDocuments, err := strconv.Atoi(os.Getenv("CORPUS_MAX_DOCUMENTS")); err == nil && maxDocuments > 0 {
+		config.Corpus.MaxDocuments = maxDocuments
+	}
+	if maxCorpora, err := strconv.Atoi(os.Getenv("CORPUS_MAX_CORPORA")); err == nil && maxCorpora > 0 {
+		config.Corpus.MaxCorpora = maxCorpora
+	}
+	if enabled, err := strconv.ParseBool(os.Getenv("HISTORY_ENABLED")); err == nil {
+		config.History.Enabled = enabled
+	}
+	if dir, ok := os.LookupEnv("HISTORY_DIR"); ok {
+		config.History.Dir = dir
+	}
+	if days, err := strconv.Atoi(os.Getenv("HISTORY_RETENTION_DAYS")); err == nil && days > 0 {
+		config.History.RetentionDays = days
+	}
+	if maxEntries, err := strconv.Atoi(os.Getenv("HISTORY_MAX_ENTRIES")); err == nil && maxEntries > 0 {
+		config.History.MaxEntries = maxEntries
+	}
+	if dir, ok := os.LookupEnv("API_KEYS_DIR"); ok {
+		config.APIKeys.Dir = dir
+	}
+	config.Rules.File = os.Getenv("COUNTING_RULES_FILE")
+	if interval, err := time.ParseDuration(os.Getenv("COUNTING_RULES_RELOAD_INTERVAL")); err == nil && interval >= 0 {
+		config.Rules.ReloadInterval = interval
+	}
+	if deprecatedAt, err := parseDate(os.Getenv("API_UNVERSIONED_DEPRECATED_AT")); err == nil {
+		config.API.DeprecatedAt = deprecatedAt
+	}
+	if sunsetStr := os.Getenv("API_UNVERSIONED_SUNSET_AT"); sunsetStr != "" {
+		// "none" removes the Sunset header while no date has been decided
+		if sunsetStr == "none" {
+			config.API.SunsetAt = time.Time{}
+		} else if sunsetAt, err := parseDate(sunsetStr); err == nil {
+			config.API.SunsetAt = sunsetAt
+		}
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("SERVER_READ_HEADER_TIMEOUT")); err == nil && timeout > 0 {
+		config.Server.ReadHeaderTimeout = timeout
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("SERVER_READ_TIMEOUT")); err == nil && timeout > 0 {
+		config.Server.ReadTimeout = timeout
+	}
+	if delay, err := time.ParseDuration(os.Getenv("SHUTDOWN_DRAIN_DELAY")); err == nil && delay >= 0 {
+		config.Shutdown.DrainDelay = delay
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && timeout > 0 {
+		config.Shutdown.Timeout = timeout
+	}
+	config.RateLimit.RoleRequestsPerMinute = parseRoleLimits(os.Getenv("RATE_LIMIT_ROLE_RPM"))
+	config.RateLimit.RoleDailyCharacters = parseRoleLimits(os.Getenv("QUOTA_ROLE_DAILY_CHARACTERS"))
+
 	return config
 }
+
+// Validate checks that the configuration can be used to serve requests
+func (c Config) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	if c.GRPC.Enabled && (c.GRPC.Port < 0 || c.GRPC.Port > 65535 || (c.GRPC.Port == c.Port && c.Port != 0)) {
+		return fmt.Errorf("invalid gRPC port %d", c.GRPC.Port)
+	}
+	for _, policy := range []LockoutPolicy{c.Login.Username, c.Login.IP} {
+		if policy.MaxAttempts < 0 || policy.BaseDelay <= 0 || policy.LockoutDuration <= 0 || policy.Window <= 0 {
+			return errors.New("login attempt limits must not be negative and login delays must be positive")
+		}
+	}
+	if c.GraphQL.Enabled && c.GraphQL.MaxBatchSize <= 0 {
+		return errors.New("GraphQL batch size must be positive")
+	}
+	if c.WebSocket.Enabled && c.WebSocket.IdleTimeout <= 0 {
+		return errors.New("WebSocket idle timeout must be positive")
+	}
+	if c.Jobs.Enabled && (c.Jobs.MaxBodyBytes <= 0 || c.Jobs.MaxSentences <= 0 || c.Jobs.Retention <= 0 || c.Jobs.KeepAlive <= 0 ||
+		c.Jobs.MaxRunning <= 0 || c.Jobs.MaxRetained <= 0) {
+		return errors.New("job limits and intervals must be positive")
+	}
+	if c.Upload.Enabled && (c.Upload.MaxFileBytes <= 0 || c.Upload.MaxTextBytes <= 0) {
+		return errors.New("upload limits must be positive")
+	}
+	if c.Corpus.Enabled && (c.Corpus.MaxDocuments <= 0 || c.Corpus.MaxCorpora <= 0) {
+		return errors.New("corpus document and corpus limits must be positive")
+	}
+	if c.History.Enabled && (c.History.RetentionDays <= 0 || c.History.MaxEntries <= 0) {
+		return errors.New("history retention and entry limit must be positive")
+	}
+	if c.Rules.ReloadInterval < 0 {
+		return errors.New("counting rules reload interval must not be negative")
+	}
+	if !c.API.SunsetAt.IsZero() && c.API.SunsetAt.Before(c.API.DeprecatedAt) {
+		return errors.New("API sunset must not be before the deprecation")
+	}
+	if c.Input.MaxBodyBytes <= 0 || c.Input.MaxSentenceLength <= 0 {
+		return errors.New("input limits must be positive")
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("invalid log format %q", c.Log.Format)
+	}
+	return nil
+}
+
+// parseRoleLimits parses a list like "admin=600,user=60" into a map
+// Malformed entries are skipped
+func parseRoleLimits(s string) map[string]int {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(s, ",") {
+		role, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			continue
+		}
+		limits[strings.TrimSpace(role)] = limit
+	}
+	return limits
+}
+
+// parseList parses a comma-separated list, skipping empty entries
+func parseList(s string) []string {
+	var list []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// parseDate parses a date like 2027-04-19 or an RFC 3339 time, in UTC
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t.UTC(), err
+}
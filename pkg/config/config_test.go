@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLoadConfigDefault(t *testing.T) {
@@ -121,3 +123,525 @@ func TestConfigStruct(t *testing.T) {
 		t.Errorf("Expected port to be 7070, got %d", config.Port)
 	}
 }
+
+func TestLoadConfigRateLimitDefaults(t *testing.T) {
+	for _, name := range []string{"RATE_LIMIT_ENABLED", "RATE_LIMIT_RPM", "RATE_LIMIT_BURST", "QUOTA_DAILY_CHARACTERS", "RATE_LIMIT_ROLE_RPM", "QUOTA_ROLE_DAILY_CHARACTERS"} {
+		os.Unsetenv(name)
+	}
+
+	config := LoadConfig()
+
+	if !config.RateLimit.Enabled {
+		t.Error("Expected rate limiting to be enabled by default")
+	}
+	if config.RateLimit.RequestsPerMinute != 60 {
+		t.Errorf("Expected default RequestsPerMinute to be 60, got %d", config.RateLimit.RequestsPerMinute)
+	}
+	if config.RateLimit.DailyCharacters != 0 {
+		t.Errorf("Expected unlimited daily characters by default, got %d", config.RateLimit.DailyCharacters)
+	}
+}
+
+func TestLoadConfigRateLimitFromEnv(t *testing.T) {
+	os.Setenv("RATE_LIMIT_ENABLED", "false")
+	os.Setenv("RATE_LIMIT_RPM", "120")
+	os.Setenv("RATE_LIMIT_BURST", "10")
+	os.Setenv("QUOTA_DAILY_CHARACTERS", "5000")
+	os.Setenv("RATE_LIMIT_ROLE_RPM", "admin=600, user=30,broken")
+	os.Setenv("QUOTA_ROLE_DAILY_CHARACTERS", "admin=0,user=-1")
+	defer func() {
+		for _, name := range []string{"RATE_LIMIT_ENABLED", "RATE_LIMIT_RPM", "RATE_LIMIT_BURST", "QUOTA_DAILY_CHARACTERS", "RATE_LIMIT_ROLE_RPM", "QUOTA_ROLE_DAILY_CHARACTERS"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	config := LoadConfig()
+
+	if config.RateLimit.Enabled {
+		t.Error("Expected rate limiting to be disabled")
+	}
+	if config.RateLimit.RequestsPerMinute != 120 {
+		t.Errorf("Expected RequestsPerMinute to be 120, got %d", config.RateLimit.RequestsPerMinute)
+	}
+	if config.RateLimit.Burst != 10 {
+		t.Errorf("Expected Burst to be 10, got %d", config.RateLimit.Burst)
+	}
+	if config.RateLimit.DailyCharacters != 5000 {
+		t.Errorf("Expected DailyCharacters to be 5000, got %d", config.RateLimit.DailyCharacters)
+	}
+	if len(config.RateLimit.RoleRequestsPerMinute) != 2 || config.RateLimit.RoleRequestsPerMinute["user"] != 30 {
+		t.Errorf("Unexpected RoleRequestsPerMinute: %v", config.RateLimit.RoleRequestsPerMinute)
+	}
+	if limit, ok := config.RateLimit.RoleDailyCharacters["admin"]; !ok || limit != 0 {
+		t.Errorf("Expected admin to have an unlimited daily quota, got %v", config.RateLimit.RoleDailyCharacters)
+	}
+	if _, ok := config.RateLimit.RoleDailyCharacters["user"]; ok {
+		t.Error("Expected negative role limits to be skipped")
+	}
+}
+
+func TestLoadConfigInputLimits(t *testing.T) {
+	os.Unsetenv("MAX_BODY_BYTES")
+	os.Unsetenv("MAX_SENTENCE_LENGTH")
+
+	config := LoadConfig()
+	if config.Input != DefaultInputLimits() {
+		t.Errorf("Expected default input limits, got %+v", config.Input)
+	}
+
+	os.Setenv("MAX_BODY_BYTES", "2048")
+	os.Setenv("MAX_SENTENCE_LENGTH", "0")
+	defer func() {
+		os.Unsetenv("MAX_BODY_BYTES")
+		os.Unsetenv("MAX_SENTENCE_LENGTH")
+	}()
+
+	config = LoadConfig()
+	if config.Input.MaxBodyBytes != 2048 {
+		t.Errorf("Expected MaxBodyBytes to be 2048, got %d", config.Input.MaxBodyBytes)
+	}
+	if config.Input.MaxSentenceLength != DefaultInputLimits().MaxSentenceLength {
+		t.Errorf("Expected a non-positive MaxSentenceLength to be ignored, got %d", config.Input.MaxSentenceLength)
+	}
+}
+
+func TestLoadConfigLog(t *testing.T) {
+	os.Unsetenv("LOG_LEVEL")
+	os.Unsetenv("LOG_FORMAT")
+
+	config := LoadConfig()
+	if config.Log.Level != "info" || config.Log.Format != "json" {
+		t.Errorf("Expected info/json logging by default, got %+v", config.Log)
+	}
+
+	os.Setenv("LOG_LEVEL", "debug")
+	os.Setenv("LOG_FORMAT", "text")
+	defer func() {
+		os.Unsetenv("LOG_LEVEL")
+		os.Unsetenv("LOG_FORMAT")
+	}()
+
+	config = LoadConfig()
+	if config.Log.Level != "debug" || config.Log.Format != "text" {
+		t.Errorf("Expected debug/text logging, got %+v", config.Log)
+	}
+}
+
+func TestLoadConfigTracing(t *testing.T) {
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	os.Setenv("OTEL_SERVICE_NAME", "analyzer-test")
+	os.Setenv("OTEL_TRACES_SAMPLER_ARG", "1.5")
+	defer func() {
+		os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		os.Unsetenv("OTEL_SERVICE_NAME")
+		os.Unsetenv("OTEL_TRACES_SAMPLER_ARG")
+	}()
+
+	config := LoadConfig()
+
+	if config.Tracing.Endpoint != "http://collector:4318" {
+		t.Errorf("Expected endpoint http://collector:4318, got %s", config.Tracing.Endpoint)
+	}
+	if config.Tracing.ServiceName != "analyzer-test" {
+		t.Errorf("Expected service name analyzer-test, got %s", config.Tracing.ServiceName)
+	}
+	if config.Tracing.SampleRatio != 1 {
+		t.Errorf("Expected an out-of-range sample ratio to be ignored, got %v", config.Tracing.SampleRatio)
+	}
+}
+
+func TestLoadConfigServer(t *testing.T) {
+	config := LoadConfig()
+	want := ServerConfig{ReadHeaderTimeout: 10 * time.Second, ReadTimeout: 60 * time.Second}
+	if config.Server != want {
+		t.Errorf("Expected server config %+v by default, got %+v", want, config.Server)
+	}
+
+	os.Setenv("SERVER_READ_HEADER_TIMEOUT", "5s")
+	os.Setenv("SERVER_READ_TIMEOUT", "-1s")
+	defer func() {
+		os.Unsetenv("SERVER_READ_HEADER_TIMEOUT")
+		os.Unsetenv("SERVER_READ_TIMEOUT")
+	}()
+
+	config = LoadConfig()
+	want = ServerConfig{ReadHeaderTimeout: 5 * time.Second, ReadTimeout: 60 * time.Second}
+	if config.Server != want {
+		t.Errorf("Expected a negative read timeout to be ignored, got %+v", config.Server)
+	}
+}
+
+func TestLoadConfigShutdown(t *testing.T) {
+	os.Setenv("SHUTDOWN_DRAIN_DELAY", "0s")
+	os.Setenv("SHUTDOWN_TIMEOUT", "-5s")
+	defer func() {
+		os.Unsetenv("SHUTDOWN_DRAIN_DELAY")
+		os.Unsetenv("SHUTDOWN_TIMEOUT")
+	}()
+
+	config := LoadConfig()
+
+	if config.Shutdown.DrainDelay != 0 {
+		t.Errorf("Expected drain delay 0, got %v", config.Shutdown.DrainDelay)
+	}
+	if config.Shutdown.Timeout != 15*time.Second {
+		t.Errorf("Expected a negative timeout to be ignored, got %v", config.Shutdown.Timeout)
+	}
+}
+
+func TestLoadConfigLogin(t *testing.T) {
+	os.Setenv("LOGIN_MAX_ATTEMPTS", "5")
+	os.Setenv("LOGIN_MAX_ATTEMPTS_PER_IP", "20")
+	os.Setenv("LOGIN_BACKOFF_BASE", "2s")
+	os.Setenv("LOGIN_LOCKOUT_DURATION", "-1m")
+	os.Setenv("TRUST_PROXY_HEADERS", "true")
+	defer func() {
+		for _, name := range []string{"LOGIN_MAX_ATTEMPTS", "LOGIN_MAX_ATTEMPTS_PER_IP", "LOGIN_BACKOFF_BASE", "LOGIN_LOCKOUT_DURATION", "TRUST_PROXY_HEADERS"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	config := LoadConfig()
+	defaults := DefaultLoginConfig()
+
+	if config.Login.Username.MaxAttempts != 5 || config.Login.IP.MaxAttempts != 20 {
+		t.Errorf("Expected 5 username and 20 IP attempts, got %d and %d", config.Login.Username.MaxAttempts, config.Login.IP.MaxAttempts)
+	}
+	if config.Login.Username.BaseDelay != 2*time.Second || config.Login.IP.BaseDelay != 2*time.Second {
+		t.Errorf("Expected a 2s backoff for usernames and IPs, got %v and %v", config.Login.Username.BaseDelay, config.Login.IP.BaseDelay)
+	}
+	if config.Login.Username.LockoutDuration != defaults.Username.LockoutDuration {
+		t.Errorf("Expected a negative lockout duration to be ignored, got %v", config.Login.Username.LockoutDuration)
+	}
+	if config.Login.Username.BackoffAfter != defaults.Username.BackoffAfter || config.Login.IP.Window != defaults.IP.Window {
+		t.Errorf("Expected unset login settings to keep their defaults, got %+v", config.Login)
+	}
+	if !config.Login.TrustProxyHeaders {
+		t.Error("Expected proxy headers to be trusted")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Port: 8080, Login: DefaultLoginConfig(), Input: DefaultInputLimits(), Log: LogConfig{Level: "info", Format: "json"}}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"valid", func(c *Config) {}, false},
+		{"random port", func(c *Config) { c.Port = 0 }, false},
+		{"negative port", func(c *Config) { c.Port = -1 }, true},
+		{"port out of range", func(c *Config) { c.Port = 70000 }, true},
+		{"zero body limit", func(c *Config) { c.Input.MaxBodyBytes = 0 }, true},
+		{"unknown log format", func(c *Config) { c.Log.Format = "xml" }, true},
+		{"gRPC port clashes with HTTP", func(c *Config) { c.GRPC = GRPCConfig{Enabled: true, Port: 8080} }, true},
+		{"disabled gRPC port is ignored", func(c *Config) { c.GRPC = GRPCConfig{Port: 8080} }, false},
+		{"negative login attempts", func(c *Config) { c.Login.IP.MaxAttempts = -1 }, true},
+		{"zero login lockout duration", func(c *Config) { c.Login.Username.LockoutDuration = 0 }, true},
+		{"login lockout disabled", func(c *Config) { c.Login.Username.MaxAttempts = 0 }, false},
+		{"zero WebSocket idle timeout", func(c *Config) { c.WebSocket = WebSocketConfig{Enabled: true} }, true},
+		{"zero GraphQL batch size", func(c *Config) { c.GraphQL = GraphQLConfig{Enabled: true} }, true},
+		{"sunset before deprecation", func(c *Config) {
+			c.API = APIConfig{DeprecatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), SunsetAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+		}, true},
+		{"zero job retention", func(c *Config) {
+			c.Jobs = JobsConfig{Enabled: true, MaxBodyBytes: 1, MaxSentences: 1, KeepAlive: time.Second}
+		}, true},
+		{"zero running jobs per client", func(c *Config) {
+			c.Jobs = JobsConfig{Enabled: true, MaxBodyBytes: 1, MaxSentences: 1, Retention: time.Second, KeepAlive: time.Second, MaxRetained: 1}
+		}, true},
+		{"disabled jobs are ignored", func(c *Config) { c.Jobs = JobsConfig{} }, false},
+		{"zero upload text limit", func(c *Config) { c.Upload = UploadConfig{Enabled: true, MaxFileBytes: 1} }, true},
+		{"disabled uploads are ignored", func(c *Config) { c.Upload = UploadConfig{} }, false},
+		{"zero corpus document limit", func(c *Config) { c.Corpus = CorpusConfig{Enabled: true, Dir: "data", MaxCorpora: 1} }, true},
+		{"zero corpus limit", func(c *Config) { c.Corpus = CorpusConfig{Enabled: true, Dir: "data", MaxDocuments: 1} }, true},
+		{"disabled corpora are ignored", func(c *Config) { c.Corpus = CorpusConfig{} }, false},
+		{"zero history retention", func(c *Config) { c.History = HistoryConfig{Enabled: true, MaxEntries: 1} }, true},
+		{"zero history entry limit", func(c *Config) { c.History = HistoryConfig{Enabled: true, RetentionDays: 1} }, true},
+		{"disabled history is ignored", func(c *Config) { c.History = HistoryConfig{} }, false},
+		{"negative rules reload interval", func(c *Config) { c.Rules.ReloadInterval = -time.Second }, true},
+		{"rules reloaded only on SIGHUP", func(c *Config) { c.Rules.ReloadInterval = 0 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDocs(t *testing.T) {
+	if config := LoadConfig(); !config.Docs.Enabled {
+		t.Error("Expected docs to be enabled by default")
+	}
+
+	os.Setenv("DOCS_ENABLED", "false")
+	defer os.Unsetenv("DOCS_ENABLED")
+
+	if config := LoadConfig(); config.Docs.Enabled {
+		t.Error("Expected DOCS_ENABLED=false to disable docs")
+	}
+}
+
+func TestLoadConfigGRPC(t *testing.T) {
+	config := LoadConfig()
+	if !config.GRPC.Enabled || config.GRPC.Port != 9090 {
+		t.Errorf("Expected gRPC enabled on port 9090 by default, got %+v", config.GRPC)
+	}
+
+	os.Setenv("GRPC_ENABLED", "false")
+	os.Setenv("GRPC_PORT", "9191")
+	defer func() {
+		os.Unsetenv("GRPC_ENABLED")
+		os.Unsetenv("GRPC_PORT")
+	}()
+
+	config = LoadConfig()
+	if config.GRPC.Enabled || config.GRPC.Port != 9191 {
+		t.Errorf("Expected gRPC disabled on port 9191, got %+v", config.GRPC)
+	}
+}
+
+func TestLoadConfigGraphQL(t *testing.T) {
+	config := LoadConfig()
+	if !config.GraphQL.Enabled || config.GraphQL.MaxBatchSize != 10 {
+		t.Errorf("Expected GraphQL enabled with batches of 10 by default, got %+v", config.GraphQL)
+	}
+
+	os.Setenv("GRAPHQL_ENABLED", "false")
+	os.Setenv("GRAPHQL_MAX_BATCH_SIZE", "25")
+	defer func() {
+		os.Unsetenv("GRAPHQL_ENABLED")
+		os.Unsetenv("GRAPHQL_MAX_BATCH_SIZE")
+	}()
+
+	config = LoadConfig()
+	if config.GraphQL.Enabled || config.GraphQL.MaxBatchSize != 25 {
+		t.Errorf("Expected GraphQL disabled with batches of 25, got %+v", config.GraphQL)
+	}
+}
+
+func TestLoadConfigWebSocket(t *testing.T) {
+	config := LoadConfig()
+	if !config.WebSocket.Enabled || config.WebSocket.IdleTimeout != time.Minute || len(config.WebSocket.AllowedOrigins) != 0 {
+		t.Errorf("Expected WebSocket enabled with a 1m idle timeout and no extra origins by default, got %+v", config.WebSocket)
+	}
+
+	os.Setenv("WEBSOCKET_ENABLED", "false")
+	os.Setenv("WEBSOCKET_IDLE_TIMEOUT", "30s")
+	os.Setenv("WEBSOCKET_ALLOWED_ORIGINS", "https://editor.example.com, ,https://app.example.com")
+	defer func() {
+		os.Unsetenv("WEBSOCKET_ENABLED")
+		os.Unsetenv("WEBSOCKET_IDLE_TIMEOUT")
+		os.Unsetenv("WEBSOCKET_ALLOWED_ORIGINS")
+	}()
+
+	config = LoadConfig()
+	want := []string{"https://editor.example.com", "https://app.example.com"}
+	if config.WebSocket.Enabled || config.WebSocket.IdleTimeout != 30*time.Second || !reflect.DeepEqual(config.WebSocket.AllowedOrigins, want) {
+		t.Errorf("Expected WebSocket disabled with a 30s idle timeout and origins %v, got %+v", want, config.WebSocket)
+	}
+}
+
+func TestLoadConfigJobs(t *testing.T) {
+	config := LoadConfig()
+	want := JobsConfig{
+		Enabled:      true,
+		MaxBodyBytes: 16 << 20,
+		MaxSentences: 1000,
+		Retention:    10 * time.Minute,
+		KeepAlive:    15 * time.Second,
+		MaxRunning:   2,
+		MaxRetained:  20,
+	}
+	if config.Jobs != want {
+		t.Errorf("Expected jobs config %+v by default, got %+v", want, config.Jobs)
+	}
+
+	os.Setenv("JOBS_ENABLED", "false")
+	os.Setenv("JOBS_MAX_BODY_BYTES", "1024")
+	os.Setenv("JOBS_MAX_SENTENCES", "10")
+	os.Setenv("JOBS_RETENTION", "1h")
+	os.Setenv("JOBS_KEEPALIVE", "-5s")
+	os.Setenv("JOBS_MAX_RUNNING", "1")
+	os.Setenv("JOBS_MAX_RETAINED", "0")
+	defer func() {
+		os.Unsetenv("JOBS_ENABLED")
+		os.Unsetenv("JOBS_MAX_BODY_BYTES")
+		os.Unsetenv("JOBS_MAX_SENTENCES")
+		os.Unsetenv("JOBS_RETENTION")
+		os.Unsetenv("JOBS_KEEPALIVE")
+		os.Unsetenv("JOBS_MAX_RUNNING")
+		os.Unsetenv("JOBS_MAX_RETAINED")
+	}()
+
+	config = LoadConfig()
+	want = JobsConfig{MaxBodyBytes: 1024, MaxSentences: 10, Retention: time.Hour, KeepAlive: 15 * time.Second, MaxRunning: 1, MaxRetained: 20}
+	if config.Jobs != want {
+		t.Errorf("Expected jobs config %+v, got %+v", want, config.Jobs)
+	}
+}
+
+func TestLoadConfigUpload(t *testing.T) {
+	config := LoadConfig()
+	want := UploadConfig{Enabled: true, MaxFileBytes: 10 << 20, MaxTextBytes: 16 << 20}
+	if config.Upload != want {
+		t.Errorf("Expected upload config %+v by default, got %+v", want, config.Upload)
+	}
+
+	os.Setenv("UPLOAD_ENABLED", "false")
+	os.Setenv("UPLOAD_MAX_FILE_BYTES", "2048")
+	os.Setenv("UPLOAD_MAX_TEXT_BYTES", "0")
+	defer func() {
+		os.Unsetenv("UPLOAD_ENABLED")
+		os.Unsetenv("UPLOAD_MAX_FILE_BYTES")
+		os.Unsetenv("UPLOAD_MAX_TEXT_BYTES")
+	}()
+
+	config = LoadConfig()
+	want = UploadConfig{MaxFileBytes: 2048, MaxTextBytes: 16 << 20}
+	if config.Upload != want {
+		t.Errorf("Expected upload config %+v, got %+v", want, config.Upload)
+	}
+}
+
+func TestLoadConfigCorpus(t *testing.T) {
+	config := LoadConfig()
+	want := CorpusConfig{Enabled: true, Dir: "data/corpora", MaxDocuments: 10000, MaxCorpora: 100}
+	if config.Corpus != want {
+		t.Errorf("Expected corpus config %+v by default, got %+v", want, config.Corpus)
+	}
+
+	// An empty directory keeps corpora in memory
+	os.Setenv("CORPUS_ENABLED", "false")
+	os.Setenv("CORPUS_DIR", "")
+	os.Setenv("CORPUS_MAX_DOCUMENTS", "-1")
+	os.Setenv("CORPUS_MAX_CORPORA", "5")
+	defer func() {
+		os.Unsetenv("CORPUS_ENABLED")
+		os.Unsetenv("CORPUS_DIR")
+		os.Unsetenv("CORPUS_MAX_DOCUMENTS")
+		os.Unsetenv("CORPUS_MAX_CORPORA")
+	}()
+
+	config = LoadConfig()
+	want = CorpusConfig{MaxDocuments: 10000, MaxCorpora: 5}
+	if config.Corpus != want {
+		t.Errorf("Expected corpus config %+v, got %+v", want, config.Corpus)
+	}
+}
+
+func TestLoadConfigHistory(t *testing.T) {
+	config := LoadConfig()
+	want := HistoryConfig{Enabled: true, Dir: "data/history", RetentionDays: 30, MaxEntries: 1000}
+	if config.History != want {
+		t.Errorf("Expected history config %+v by default, got %+v", want, config.History)
+	}
+
+	// An empty directory keeps histories in memory
+	os.Setenv("HISTORY_ENABLED", "false")
+	os.Setenv("HISTORY_DIR", "")
+	os.Setenv("HISTORY_RETENTION_DAYS", "7")
+	os.Setenv("HISTORY_MAX_ENTRIES", "0")
+	defer func() {
+		os.Unsetenv("HISTORY_ENABLED")
+		os.Unsetenv("HISTORY_DIR")
+		os.Unsetenv("HISTORY_RETENTION_DAYS")
+		os.Unsetenv("HISTORY_MAX_ENTRIES")
+	}()
+
+	config = LoadConfig()
+	want = HistoryConfig{RetentionDays: 7, MaxEntries: 1000}
+	if config.History != want {
+		t.Errorf("Expected history config %+v, got %+v", want, config.History)
+	}
+}
+
+func TestLoadConfigAPIKeys(t *testing.T) {
+	if config := LoadConfig(); config.APIKeys.Dir != "data/api-keys" {
+		t.Errorf("Expected API keys in data/api-keys by default, got %q", config.APIKeys.Dir)
+	}
+
+	// An empty directory keeps API keys in memory
+	os.Setenv("API_KEYS_DIR", "")
+	defer os.Unsetenv("API_KEYS_DIR")
+	if config := LoadConfig(); config.APIKeys.Dir != "" {
+		t.Errorf("Expected no API key directory, got %q", config.APIKeys.Dir)
+	}
+}
+
+func TestLoadConfigRules(t *testing.T) {
+	config := LoadConfig()
+	if want := (RulesConfig{ReloadInterval: 30 * time.Second}); config.Rules != want {
+		t.Errorf("Expected rules config %+v by default, got %+v", want, config.Rules)
+	}
+
+	os.Setenv("COUNTING_RULES_FILE", "/etc/analyzer/rules.yaml")
+	os.Setenv("COUNTING_RULES_RELOAD_INTERVAL", "0")
+	defer func() {
+		os.Unsetenv("COUNTING_RULES_FILE")
+		os.Unsetenv("COUNTING_RULES_RELOAD_INTERVAL")
+	}()
+
+	config = LoadConfig()
+	if want := (RulesConfig{File: "/etc/analyzer/rules.yaml"}); config.Rules != want {
+		t.Errorf("Expected rules config %+v, got %+v", want, config.Rules)
+	}
+}
+
+func TestLoadConfigAPI(t *testing.T) {
+	config := LoadConfig()
+	if config.API.DeprecatedAt.IsZero() || !config.API.SunsetAt.After(config.API.DeprecatedAt) {
+		t.Errorf("Expected a default deprecation followed by a sunset, got %+v", config.API)
+	}
+
+	tests := []struct {
+		name             string
+		deprecatedAt     string
+		sunsetAt         string
+		wantDeprecatedAt time.Time
+		wantSunsetAt     time.Time
+	}{
+		{
+			name:             "dates",
+			deprecatedAt:     "2026-01-01",
+			sunsetAt:         "2026-07-01",
+			wantDeprecatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantSunsetAt:     time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:             "RFC 3339 times",
+			deprecatedAt:     "2026-01-01T12:00:00+02:00",
+			sunsetAt:         "none",
+			wantDeprecatedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:             "invalid values keep the defaults",
+			deprecatedAt:     "soon",
+			sunsetAt:         "later",
+			wantDeprecatedAt: config.API.DeprecatedAt,
+			wantSunsetAt:     config.API.SunsetAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("API_UNVERSIONED_DEPRECATED_AT", tt.deprecatedAt)
+			os.Setenv("API_UNVERSIONED_SUNSET_AT", tt.sunsetAt)
+			defer func() {
+				os.Unsetenv("API_UNVERSIONED_DEPRECATED_AT")
+				os.Unsetenv("API_UNVERSIONED_SUNSET_AT")
+			}()
+
+			got := LoadConfig().API
+			if !got.DeprecatedAt.Equal(tt.wantDeprecatedAt) || !got.SunsetAt.Equal(tt.wantSunsetAt) {
+				t.Errorf("Expected deprecation %v and sunset %v, got %+v", tt.wantDeprecatedAt, tt.wantSunsetAt, got)
+			}
+		})
+	}
+}
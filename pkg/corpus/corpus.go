@@ -0,0 +1,200 @@
+// Package corpus keeps named collections of texts, summarized so that statistics can be
+// aggregated over every text a client has added
+package corpus
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// Corpus errors
+var (
+	ErrCorpusNotFound = errors.New("corpus not found")
+	ErrCorpusFull     = errors.New("corpus is full")
+	ErrTooManyCorpora = errors.New("too many corpora")
+)
+
+// namePattern matches corpus names, which are also used as file names
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidName reports whether a corpus can have the given name: 1 to 64 lowercase letters, digits,
+// '-' and '_', starting with a letter or digit
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Corpus is a named collection of documents owned by the client that created it
+// The texts are not kept, only each document's summary and the words and letters of all of them
+type Corpus struct {
+	Name      string                  `json:"name"`
+	Owner     string                  `json:"owner"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+	Documents []domain.CorpusDocument `json:"documents"`
+	// Vocabulary counts each distinct word, compared as in domain.WordFrequencies
+	Vocabulary map[string]int `json:"vocabulary"`
+	// Letters counts each letter, lowercased
+	Letters map[string]int `json:"letters"`
+}
+
+// New creates an empty corpus
+func New(owner, name string, now time.Time) *Corpus {
+	return &Corpus{
+		Name:       name,
+		Owner:      owner,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Documents:  []domain.CorpusDocument{},
+		Vocabulary: make(map[string]int),
+		Letters:    make(map[string]int),
+	}
+}
+
+// Add analyzes a text and adds its summary, words and letters to the corpus
+// It returns the new document and the text's counts; the corpus is left unchanged when the analysis fails
+func (c *Corpus) Add(ctx context.Context, text string, now time.Time) (domain.CorpusDocument, domain.SentenceAnalysisResponse, error) {
+	counts, err := domain.AnalyzeSentenceContext(ctx, text)
+	if err != nil {
+		return domain.CorpusDocument{}, domain.SentenceAnalysisResponse{}, err
+	}
+	document := domain.CorpusDocument{
+		ID:             len(c.Documents) + 1,
+		AddedAt:        now,
+		WordCount:      counts.WordCount,
+		CharacterCount: utf8.RuneCountInString(text),
+		SentenceCount:  len(domain.SplitSentences(ctx, text)),
+		ReadingEase:    domain.ReadingEase(ctx, text),
+	}
+
+	for _, frequency := range domain.WordFrequencies(ctx, text) {
+		if c.Vocabulary[frequency.Word] == 0 {
+			document.NewWords++
+		}
+		c.Vocabulary[frequency.Word] += frequency.Count
+	}
+	for letter, count := range domain.LetterFrequencies(ctx, text) {
+		c.Letters[string(letter)] += count
+	}
+
+	c.Documents = append(c.Documents, document)
+	c.UpdatedAt = now
+	return document, counts, nil
+}
+
+// Summary returns the corpus's name, size and times
+func (c *Corpus) Summary() domain.CorpusSummary {
+	summary := domain.CorpusSummary{
+		Name:          c.Name,
+		DocumentCount: len(c.Documents),
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+	for _, document := range c.Documents {
+		summary.WordCount += document.WordCount
+	}
+	return summary
+}
+
+// Statistics aggregates the corpus's documents
+func (c *Corpus) Statistics() domain.CorpusStatistics {
+	stats := domain.CorpusStatistics{
+		Name:               c.Name,
+		DocumentCount:      len(c.Documents),
+		CreatedAt:          c.CreatedAt,
+		UpdatedAt:          c.UpdatedAt,
+		VocabularySize:     len(c.Vocabulary),
+		VocabularyGrowth:   make([]domain.VocabularyPoint, 0, len(c.Documents)),
+		LetterDistribution: make([]domain.LetterShare, 0, len(c.Letters)),
+	}
+
+	readingEase := make([]float64, 0, len(c.Documents))
+	vocabulary := 0
+	for i, document := range c.Documents {
+		stats.WordCount += document.WordCount
+		stats.CharacterCount += document.CharacterCount
+		stats.SentenceCount += document.SentenceCount
+		vocabulary += document.NewWords
+		stats.VocabularyGrowth = append(stats.VocabularyGrowth, domain.VocabularyPoint{
+			Documents:      i + 1,
+			WordCount:      stats.WordCount,
+			VocabularySize: vocabulary,
+		})
+		readingEase = append(readingEase, document.ReadingEase)
+	}
+	stats.ReadingEase = distribution(readingEase)
+
+	letters := 0
+	for _, count := range c.Letters {
+		letters += count
+	}
+	for letter, count := range c.Letters {
+		stats.LetterDistribution = append(stats.LetterDistribution, domain.LetterShare{
+			Letter: letter,
+			Count:  count,
+			Share:  float64(count) / float64(letters),
+		})
+	}
+	// Ties are broken alphabetically so the order is stable
+	sort.Slice(stats.LetterDistribution, func(i, j int) bool {
+		a, b := stats.LetterDistribution[i], stats.LetterDistribution[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Letter < b.Letter
+	})
+	return stats
+}
+
+// clone returns a deep copy of the corpus
+func (c *Corpus) clone() *Corpus {
+	copied := *c
+	copied.Documents = append([]domain.CorpusDocument{}, c.Documents...)
+	copied.Vocabulary = make(map[string]int, len(c.Vocabulary))
+	for word, count := range c.Vocabulary {
+		copied.Vocabulary[word] = count
+	}
+	copied.Letters = make(map[string]int, len(c.Letters))
+	for letter, count := range c.Letters {
+		copied.Letters[letter] = count
+	}
+	return &copied
+}
+
+// distribution summarizes values by their minimum, maximum, mean and percentiles
+func distribution(values []float64) domain.ReadingEaseDistribution {
+	if len(values) == 0 {
+		return domain.ReadingEaseDistribution{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	return domain.ReadingEaseDistribution{
+		Min:  sorted[0],
+		P10:  percentile(sorted, 10),
+		P25:  percentile(sorted, 25),
+		P50:  percentile(sorted, 50),
+		P75:  percentile(sorted, 75),
+		P90:  percentile(sorted, 90),
+		Max:  sorted[len(sorted)-1],
+		Mean: sum / float64(len(sorted)),
+	}
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating linearly between the nearest ranks
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
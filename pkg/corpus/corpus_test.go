@@ -0,0 +1,141 @@
+package corpus
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "essays", want: true},
+		{name: "2026_q3-drafts", want: true},
+		{name: "", want: false},
+		{name: "Essays", want: false},
+		{name: "-drafts", want: false},
+		{name: "../etc", want: false},
+		{name: "a.json", want: false},
+		{name: string(make([]byte, 65)), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidName(tt.name); got != tt.want {
+				t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorpusAdd(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c := New("user", "essays", now)
+
+	first, counts, err := c.Add(context.Background(), "The cat sat. The cat ran.", now)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	want := domain.CorpusDocument{
+		ID:             1,
+		AddedAt:        now,
+		WordCount:      6,
+		CharacterCount: 25,
+		SentenceCount:  2,
+		ReadingEase:    domain.ReadingEase(context.Background(), "The cat sat. The cat ran."),
+		NewWords:       4,
+	}
+	if first != want {
+		t.Errorf("Add() = %+v, want %+v", first, want)
+	}
+	if counts.WordCount != 6 || counts.VowelCount != 6 || counts.ConsonantCount != 12 {
+		t.Errorf("Add() counts = %+v, want 6 words, 6 vowels and 12 consonants", counts)
+	}
+
+	later := now.Add(time.Hour)
+	second, _, _ := c.Add(context.Background(), "A cat!", later)
+	if second.ID != 2 || second.NewWords != 1 {
+		t.Errorf("Add() = %+v, want ID 2 with 1 new word", second)
+	}
+	if c.UpdatedAt != later || len(c.Documents) != 2 {
+		t.Errorf("Expected two documents updated at %v, got %d at %v", later, len(c.Documents), c.UpdatedAt)
+	}
+	if c.Vocabulary["cat"] != 3 || c.Letters["a"] != 6 {
+		t.Errorf("Expected 3 cats and 6 a's, got %d and %d", c.Vocabulary["cat"], c.Letters["a"])
+	}
+}
+
+func TestCorpusStatistics(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c := New("user", "essays", now)
+	c.Documents = []domain.CorpusDocument{
+		{ID: 1, WordCount: 10, CharacterCount: 50, SentenceCount: 2, ReadingEase: 80, NewWords: 8},
+		{ID: 2, WordCount: 20, CharacterCount: 90, SentenceCount: 1, ReadingEase: 40, NewWords: 5},
+		{ID: 3, WordCount: 5, CharacterCount: 20, SentenceCount: 1, ReadingEase: 60, NewWords: 0},
+	}
+	c.Vocabulary = map[string]int{"a": 1}
+	c.Letters = map[string]int{"b": 1, "e": 2, "a": 1}
+
+	got := c.Statistics()
+	if got.DocumentCount != 3 || got.WordCount != 35 || got.CharacterCount != 160 || got.SentenceCount != 4 || got.VocabularySize != 1 {
+		t.Errorf("Statistics() totals = %+v", got)
+	}
+
+	wantGrowth := []domain.VocabularyPoint{
+		{Documents: 1, WordCount: 10, VocabularySize: 8},
+		{Documents: 2, WordCount: 30, VocabularySize: 13},
+		{Documents: 3, WordCount: 35, VocabularySize: 13},
+	}
+	if !reflect.DeepEqual(got.VocabularyGrowth, wantGrowth) {
+		t.Errorf("Statistics() vocabulary growth = %+v, want %+v", got.VocabularyGrowth, wantGrowth)
+	}
+
+	wantLetters := []domain.LetterShare{
+		{Letter: "e", Count: 2, Share: 0.5},
+		{Letter: "a", Count: 1, Share: 0.25},
+		{Letter: "b", Count: 1, Share: 0.25},
+	}
+	if !reflect.DeepEqual(got.LetterDistribution, wantLetters) {
+		t.Errorf("Statistics() letter distribution = %+v, want %+v", got.LetterDistribution, wantLetters)
+	}
+
+	wantEase := domain.ReadingEaseDistribution{Min: 40, P10: 44, P25: 50, P50: 60, P75: 70, P90: 76, Max: 80, Mean: 60}
+	if !reflect.DeepEqual(got.ReadingEase, wantEase) {
+		t.Errorf("Statistics() reading ease = %+v, want %+v", got.ReadingEase, wantEase)
+	}
+}
+
+func TestCorpusStatisticsEmpty(t *testing.T) {
+	got := New("user", "essays", time.Now()).Statistics()
+	if got.DocumentCount != 0 || got.VocabularyGrowth == nil || got.LetterDistribution == nil {
+		t.Errorf("Statistics() = %+v, want empty lists", got)
+	}
+	if got.ReadingEase != (domain.ReadingEaseDistribution{}) {
+		t.Errorf("Statistics() reading ease = %+v, want zero", got.ReadingEase)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{values: []float64{7}, p: 90, want: 7},
+		{values: []float64{1, 2}, p: 50, want: 1.5},
+		{values: []float64{1, 2, 3, 4, 5}, p: 25, want: 2},
+		{values: []float64{1, 2, 3, 4, 5}, p: 90, want: 4.6},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package corpus
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/filelock"
+)
+
+// Store persists corpora, each identified by its owner and name
+type Store interface {
+	Get(owner, name string) (*Corpus, error)
+	// List returns the summaries of an owner's corpora ordered by name
+	List(owner string) ([]domain.CorpusSummary, error)
+	// Update applies fn to a corpus, or to a new empty one when it does not exist, and saves the result
+	// unless fn fails; updates are applied one at a time, so fn sees every earlier update
+	// A new corpus is refused with ErrTooManyCorpora when the owner already has maxCorpora; zero is not enforced
+	Update(owner, name string, maxCorpora int, fn func(c *Corpus) error) (*Corpus, error)
+	Delete(owner, name string) error
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu      sync.RWMutex
+	corpora map[string]map[string]*Corpus
+}
+
+// NewMemoryStore creates an empty in-memory corpus store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{corpora: make(map[string]map[string]*Corpus)}
+}
+
+// Get returns a copy of the corpus with the given owner and name
+func (s *MemoryStore) Get(owner, name string) (*Corpus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.corpora[owner][name]
+	if !ok {
+		return nil, ErrCorpusNotFound
+	}
+	return c.clone(), nil
+}
+
+// List returns the summaries of an owner's corpora ordered by name
+func (s *MemoryStore) List(owner string) ([]domain.CorpusSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := make([]domain.CorpusSummary, 0, len(s.corpora[owner]))
+	for _, c := range s.corpora[owner] {
+		summaries = append(summaries, c.Summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// Update applies fn to a copy of a corpus, or to a new empty one, and stores the result unless fn fails
+func (s *MemoryStore) Update(owner, name string, maxCorpora int, fn func(c *Corpus) error) (*Corpus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := New(owner, name, time.Now().UTC())
+	if existing, ok := s.corpora[owner][name]; ok {
+		c = existing.clone()
+	} else if maxCorpora > 0 && len(s.corpora[owner]) >= maxCorpora {
+		return nil, ErrTooManyCorpora
+	}
+	if err := fn(c); err != nil {
+		return nil, err
+	}
+
+	if s.corpora[owner] == nil {
+		s.corpora[owner] = make(map[string]*Corpus)
+	}
+	s.corpora[owner][name] = c
+	return c.clone(), nil
+}
+
+// Delete removes the corpus with the given owner and name
+func (s *MemoryStore) Delete(owner, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.corpora[owner][name]; !ok {
+		return ErrCorpusNotFound
+	}
+	delete(s.corpora[owner], name)
+	return nil
+}
+
+// FileStore is a Store that keeps each corpus in a JSON file under a directory, one subdirectory per owner,
+// so corpora survive restarts without a database
+// Files are replaced atomically and updates hold a lock file, so replicas may share a directory
+type FileStore struct {
+	dir string
+	// mu serializes updates within this process, where the lock file may not be enforced;
+	// reads need no lock as files are replaced by renaming
+	mu sync.Mutex
+}
+
+// NewFileStore creates a file-backed corpus store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// lock holds the lock file of the directory until the returned function is called,
+// so an update from another replica cannot be lost between reading a file and replacing it
+func (s *FileStore) lock() (func(), error) {
+	return filelock.Lock(filepath.Join(s.dir, ".lock"))
+}
+
+// ownerDir returns the directory of an owner's corpora
+// Owners are user IDs, so they are encoded to be safe as file names
+func (s *FileStore) ownerDir(owner string) string {
+	return filepath.Join(s.dir, "owner-"+base64.RawURLEncoding.EncodeToString([]byte(owner)))
+}
+
+// path returns the file of a corpus
+func (s *FileStore) path(owner, name string) string {
+	return filepath.Join(s.ownerDir(owner), name+".json")
+}
+
+// Get reads the corpus with the given owner and name
+func (s *FileStore) Get(owner, name string) (*Corpus, error) {
+	if !ValidName(name) {
+		return nil, ErrCorpusNotFound
+	}
+	return s.read(s.path(owner, name))
+}
+
+// List reads the summaries of an owner's corpora ordered by name
+func (s *FileStore) List(owner string) ([]domain.CorpusSummary, error) {
+	entries, err := os.ReadDir(s.ownerDir(owner))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []domain.CorpusSummary{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are ordered by file name, so by corpus name
+	summaries := make([]domain.CorpusSummary, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || !ValidName(name) {
+			continue
+		}
+		c, err := s.read(filepath.Join(s.ownerDir(owner), entry.Name()))
+		if errors.Is(err, ErrCorpusNotFound) {
+			// Deleted since the directory was read
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, c.Summary())
+	}
+	return summaries, nil
+}
+
+// Update applies fn to a corpus read from its file, or to a new empty one, and writes the result unless fn fails
+func (s *FileStore) Update(owner, name string, maxCorpora int, fn func(c *Corpus) error) (*Corpus, error) {
+	if !ValidName(name) {
+		return nil, ErrCorpusNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, err := s.read(s.path(owner, name))
+	if errors.Is(err, ErrCorpusNotFound) {
+		c, err = New(owner, name, time.Now().UTC()), s.checkCount(owner, maxCorpora)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(c); err != nil {
+		return nil, err
+	}
+	if err := s.write(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkCount returns ErrTooManyCorpora when the owner already has maxCorpora corpora
+func (s *FileStore) checkCount(owner string, maxCorpora int) error {
+	if maxCorpora <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.ownerDir(owner))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() && ValidName(name) {
+			count++
+		}
+	}
+	if count >= maxCorpora {
+		return ErrTooManyCorpora
+	}
+	return nil
+}
+
+// Delete removes the file of the corpus with the given owner and name
+func (s *FileStore) Delete(owner, name string) error {
+	if !ValidName(name) {
+		return ErrCorpusNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(s.path(owner, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrCorpusNotFound
+	}
+	return err
+}
+
+// read decodes the corpus in a file
+func (s *FileStore) read(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCorpusNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := New("", "", time.Time{})
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// write replaces the file of a corpus by writing a temporary file next to it and renaming it into place,
+// so a crash never leaves a partly written corpus
+func (s *FileStore) write(c *Corpus) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	dir := s.ownerDir(c.Owner)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, c.Name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(c.Owner, c.Name))
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store = NewMemoryStore()
+)
+
+// SetStore replaces the store used for corpora
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+// GetStore returns the store used for corpora
+func GetStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// CheckStore verifies that the corpus store can be queried
+func CheckStore() error {
+	_, err := GetStore().List("")
+	return err
+}
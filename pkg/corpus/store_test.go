@@ -0,0 +1,197 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStores returns each Store implementation, empty
+func newStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "corpora"))
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	return map[string]Store{"memory": NewMemoryStore(), "file": fileStore}
+}
+
+// addText returns an update that adds text to a corpus
+func addText(text string) func(c *Corpus) error {
+	return func(c *Corpus) error {
+		_, _, err := c.Add(context.Background(), text, time.Now().UTC())
+		return err
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Get("alice", "essays"); !errors.Is(err, ErrCorpusNotFound) {
+				t.Fatalf("Get() error = %v, want ErrCorpusNotFound", err)
+			}
+
+			// The first update creates the corpus
+			if _, err := store.Update("alice", "essays", 0, addText("One two.")); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			c, err := store.Update("alice", "essays", 0, addText("Two three."))
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if len(c.Documents) != 2 || c.Vocabulary["two"] != 2 {
+				t.Errorf("Update() = %+v, want two documents sharing a word", c)
+			}
+
+			got, err := store.Get("alice", "essays")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Owner != "alice" || got.Name != "essays" || len(got.Documents) != 2 || got.Letters["t"] != 3 {
+				t.Errorf("Get() = %+v", got)
+			}
+
+			// A failed update leaves the corpus unchanged
+			failure := errors.New("full")
+			if _, err := store.Update("alice", "essays", 0, func(c *Corpus) error {
+				c.Add(context.Background(), "Four.", time.Now())
+				return failure
+			}); !errors.Is(err, failure) {
+				t.Errorf("Update() error = %v, want %v", err, failure)
+			}
+			if got, _ := store.Get("alice", "essays"); len(got.Documents) != 2 {
+				t.Errorf("Expected 2 documents after a failed update, got %d", len(got.Documents))
+			}
+
+			// Corpora are scoped to their owner
+			if _, err := store.Update("alice", "drafts", 0, addText("Five.")); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if _, err := store.Get("bob", "essays"); !errors.Is(err, ErrCorpusNotFound) {
+				t.Errorf("Get() for another owner error = %v, want ErrCorpusNotFound", err)
+			}
+			summaries, err := store.List("alice")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(summaries) != 2 || summaries[0].Name != "drafts" || summaries[1].Name != "essays" || summaries[1].WordCount != 4 {
+				t.Errorf("List() = %+v", summaries)
+			}
+			if summaries, err := store.List("bob"); err != nil || len(summaries) != 0 {
+				t.Errorf("List() for another owner = %v, %v, want none", summaries, err)
+			}
+
+			if err := store.Delete("alice", "essays"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := store.Delete("alice", "essays"); !errors.Is(err, ErrCorpusNotFound) {
+				t.Errorf("Delete() twice error = %v, want ErrCorpusNotFound", err)
+			}
+			if _, err := store.Get("alice", "essays"); !errors.Is(err, ErrCorpusNotFound) {
+				t.Errorf("Get() after Delete() error = %v, want ErrCorpusNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreMaxCorpora(t *testing.T) {
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, corpus := range []string{"essays", "drafts"} {
+				if _, err := store.Update("alice", corpus, 2, addText("One.")); err != nil {
+					t.Fatalf("Update(%q) error = %v", corpus, err)
+				}
+			}
+
+			// Existing corpora can still grow, and other owners have their own limit
+			if _, err := store.Update("alice", "essays", 2, addText("Two.")); err != nil {
+				t.Errorf("Update() of an existing corpus error = %v", err)
+			}
+			if _, err := store.Update("bob", "essays", 2, addText("Two.")); err != nil {
+				t.Errorf("Update() for another owner error = %v", err)
+			}
+			if _, err := store.Update("alice", "notes", 2, addText("Three.")); !errors.Is(err, ErrTooManyCorpora) {
+				t.Errorf("Update() of a third corpus error = %v, want ErrTooManyCorpora", err)
+			}
+
+			// Deleting a corpus makes room for another
+			if err := store.Delete("alice", "drafts"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Update("alice", "notes", 2, addText("Three.")); err != nil {
+				t.Errorf("Update() after Delete() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReturnsCopies(t *testing.T) {
+	store := NewMemoryStore()
+	if _, err := store.Update("alice", "essays", 0, addText("One.")); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	c, _ := store.Get("alice", "essays")
+	c.Vocabulary["one"] = 100
+	c.Documents = nil
+	if got, _ := store.Get("alice", "essays"); got.Vocabulary["one"] != 1 || len(got.Documents) != 1 {
+		t.Errorf("Changing a returned corpus changed the store: %+v", got)
+	}
+}
+
+func TestFileStorePersists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if _, err := store.Update("user/1", "essays", 0, addText("One two.")); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// A new store over the same directory, as after a restart, sees the corpus
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	c, err := reopened.Get("user/1", "essays")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(c.Documents) != 1 || c.Vocabulary["two"] != 1 {
+		t.Errorf("Get() = %+v", c)
+	}
+
+	// Only the corpus file is left, inside the owner's directory
+	files, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "essays.json" {
+		t.Errorf("Expected a single essays.json, got %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "user")); !os.IsNotExist(err) {
+		t.Errorf("Expected the owner to be encoded in the directory name, stat error = %v", err)
+	}
+}
+
+func TestFileStoreRejectsInvalidNames(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if _, err := store.Update("alice", "../escape", 0, addText("One.")); !errors.Is(err, ErrCorpusNotFound) {
+		t.Errorf("Update() error = %v, want ErrCorpusNotFound", err)
+	}
+}
+
+func TestCheckStore(t *testing.T) {
+	original := GetStore()
+	defer SetStore(original)
+
+	SetStore(NewMemoryStore())
+	if err := CheckStore(); err != nil {
+		t.Errorf("CheckStore() error = %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package docs
+
+import (
+	_ "embed"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the OpenAPI specification, embedded so the binary can run from any directory
+//
+//go:embed openapi/openapi.yaml
+var spec []byte
+
+// Spec returns the OpenAPI specification as YAML
+func Spec() []byte {
+	return spec
+}
+
+// Paths returns the paths documented in the OpenAPI specification in sorted order
+func Paths() ([]string, error) {
+	var document struct {
+		Paths map[string]yaml.Node `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(spec, &document); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(document.Paths))
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
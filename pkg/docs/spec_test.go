@@ -0,0 +1,133 @@
+package docs
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/gql"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/handlers"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/auth"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/health"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/pipeline"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+// TestSpecSchemasMatchTypes keeps the documented schemas in sync with the JSON encoding of the Go types
+func TestSpecSchemasMatchTypes(t *testing.T) {
+	var document struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]yaml.Node `yaml:"properties"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(Spec(), &document); err != nil {
+		t.Fatalf("Failed to parse OpenAPI specification: %v", err)
+	}
+
+	types := map[string]interface{}{
+		"LoginRequest":             handlers.LoginRequest{},
+		"LoginResponse":            handlers.LoginResponse{},
+		"SentenceAnalysisRequest":  domain.SentenceAnalysisRequest{},
+		"SentenceAnalysisResponse": domain.SentenceAnalysisResponse{},
+		"DetailedAnalysisResponse": domain.DetailedAnalysisResponse{},
+		"TokenizeOutput":           pipeline.Tokens{},
+		"ClassifyCharactersOutput": pipeline.CharacterClasses{},
+		"CountOutput":              pipeline.Counts{},
+		"MetricsOutput":            pipeline.Metrics{},
+		"WordFrequency":            domain.WordFrequency{},
+		"ExcludedContent":          domain.ExcludedContent{},
+		"ExclusionReason":          domain.ExclusionReason{},
+		"Quota":                    auth.Quota{},
+		"APIKey":                   auth.APIKey{},
+		"CreateAPIKeyRequest":      handlers.CreateAPIKeyRequest{},
+		"CreateAPIKeyResponse":     handlers.CreateAPIKeyResponse{},
+		"FieldError":               domain.FieldError{},
+		"GraphQLRequest":           gql.Request{},
+		"TextEdit":                 domain.TextEdit{},
+		"LiveRequest":              handlers.LiveRequest{},
+		"LiveResponse":             handlers.LiveResponse{},
+		"AnalysisJobRequest":       domain.AnalysisJobRequest{},
+		"AnalysisJobResponse":      handlers.AnalysisJobResponse{},
+		"AnalysisProgress":         domain.AnalysisProgress{},
+		"DocumentAnalysisResponse": domain.DocumentAnalysisResponse{},
+		"JobFailure":               handlers.JobFailure{},
+		"UploadAnalysisResponse":   domain.UploadAnalysisResponse{},
+		"SectionAnalysis":          domain.SectionAnalysis{},
+		"CompareRequest":           domain.CompareRequest{},
+		"ComparisonResponse":       domain.ComparisonResponse{},
+		"AnalysisDelta":            domain.AnalysisDelta{},
+		"WordDiff":                 domain.WordDiff{},
+		"Similarity":               domain.Similarity{},
+		"CorpusDocumentRequest":    domain.CorpusDocumentRequest{},
+		"CorpusDocument":           domain.CorpusDocument{},
+		"CorpusSummary":            domain.CorpusSummary{},
+		"CorpusStatistics":         domain.CorpusStatistics{},
+		"VocabularyPoint":          domain.VocabularyPoint{},
+		"LetterShare":              domain.LetterShare{},
+		"ReadingEaseDistribution":  domain.ReadingEaseDistribution{},
+		"HistoryEntry":             domain.HistoryEntry{},
+		"HistoryRetentionRequest":  domain.HistoryRetentionRequest{},
+		"HistoryRetention":         domain.HistoryRetention{},
+		"Problem":                  problem.Problem{},
+		"VersionInfo":              version.Info{},
+		"CheckResult":              health.CheckResult{},
+		"HealthReport":             health.Report{},
+	}
+
+	for name, value := range types {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := document.Components.Schemas[name]
+			if !ok {
+				t.Fatalf("Schema %s is not documented", name)
+			}
+
+			var documented []string
+			for property := range schema.Properties {
+				documented = append(documented, property)
+			}
+			sort.Strings(documented)
+
+			if want := jsonFields(reflect.TypeOf(value)); !reflect.DeepEqual(documented, want) {
+				t.Errorf("Schema %s documents %v, but the type encodes %v", name, documented, want)
+			}
+		})
+	}
+}
+
+// jsonFields returns the sorted JSON member names of a struct type
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestPaths(t *testing.T) {
+	paths, err := Paths()
+	if err != nil {
+		t.Fatalf("Paths returned error: %v", err)
+	}
+	if len(paths) == 0 || !sort.StringsAreSorted(paths) {
+		t.Errorf("Expected sorted documented paths, got %v", paths)
+	}
+}
@@ -0,0 +1,15 @@
+// Loaded from a file rather than an inline script so the Content-Security-Policy can forbid inline scripts
+window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/swagger/openapi.yaml",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    // The public validator is unreachable from air-gapped clusters and blocked by the CSP
+    validatorUrl: null,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    layout: "StandaloneLayout"
+  });
+};
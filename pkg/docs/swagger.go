@@ -1,8 +1,39 @@
 package docs
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
 	"net/http"
-	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/api/problem"
+)
+
+// SwaggerUIVersion is the version of the vendored Swagger UI assets
+const SwaggerUIVersion = "5.18.2"
+
+// AssetsPath is the path prefix under which the Swagger UI assets are served
+const AssetsPath = "/swagger/assets/"
+
+// swaggerUI holds the vendored Swagger UI assets, so the docs work without internet access
+//
+//go:embed swagger-ui/*.js swagger-ui/*.css swagger-ui/*.png
+var swaggerUI embed.FS
+
+// contentSecurityPolicy only allows the page to load its own assets and call the API
+// Swagger UI sets inline styles and uses data: URIs for icons, so those are allowed
+const contentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+
+// Cache policies: assets may be reused for a day, the page and the spec are revalidated on every load
+const (
+	assetCacheControl    = "public, max-age=86400"
+	documentCacheControl = "no-cache"
 )
 
 // HTML content for Swagger UI
@@ -11,51 +42,85 @@ const swaggerHTML = `<!DOCTYPE html>
 <head>
     <meta charset="UTF-8">
     <title>Sentence Analysis API - Swagger UI</title>
-    <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui.css" />
-    <style>
-        html { box-sizing: border-box; overflow: -moz-scrollbars-vertical; overflow-y: scroll; }
-        *, *:before, *:after { box-sizing: inherit; }
-        body { margin: 0; background: #fafafa; }
-    </style>
+    <link rel="stylesheet" type="text/css" href="` + AssetsPath + `swagger-ui.css?v=` + SwaggerUIVersion + `" />
+    <link rel="stylesheet" type="text/css" href="` + AssetsPath + `index.css" />
+    <link rel="icon" type="image/png" href="` + AssetsPath + `favicon-32x32.png" sizes="32x32" />
+    <link rel="icon" type="image/png" href="` + AssetsPath + `favicon-16x16.png" sizes="16x16" />
 </head>
 <body>
     <div id="swagger-ui"></div>
-    <script src="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui-bundle.js" charset="UTF-8"></script>
-    <script src="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui-standalone-preset.js" charset="UTF-8"></script>
-    <script>
-        window.onload = function() {
-            window.ui = SwaggerUIBundle({
-                url: "/swagger/openapi.yaml",
-                dom_id: '#swagger-ui',
-                deepLinking: true,
-                presets: [
-                    SwaggerUIBundle.presets.apis,
-                    SwaggerUIStandalonePreset
-                ],
-                layout: "StandaloneLayout"
-            });
-        }
-    </script>
+    <script src="` + AssetsPath + `swagger-ui-bundle.js?v=` + SwaggerUIVersion + `" charset="UTF-8"></script>
+    <script src="` + AssetsPath + `swagger-ui-standalone-preset.js?v=` + SwaggerUIVersion + `" charset="UTF-8"></script>
+    <script src="` + AssetsPath + `swagger-initializer.js" charset="UTF-8"></script>
 </body>
 </html>`
 
+// document is an embedded file served with an ETag
+type document struct {
+	data []byte
+	etag string
+}
+
+func newDocument(data []byte) document {
+	sum := sha256.Sum256(data)
+	return document{data: data, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+}
+
+var (
+	swaggerPage = newDocument([]byte(swaggerHTML))
+	specDoc     = newDocument(spec)
+	assets      = loadAssets()
+)
+
+// loadAssets indexes the embedded Swagger UI assets by file name
+func loadAssets() map[string]document {
+	docs := make(map[string]document)
+	fs.WalkDir(swaggerUI, "swagger-ui", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := swaggerUI.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		docs[path.Base(name)] = newDocument(data)
+		return nil
+	})
+	return docs
+}
+
+// serveDocument writes doc with caching headers, answering conditional requests with 304 Not Modified
+func serveDocument(w http.ResponseWriter, r *http.Request, name, contentType, cacheControl string, doc document) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", doc.etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(doc.data))
+}
+
 // HandleSwaggerUI serves the Swagger UI HTML page
 func HandleSwaggerUI(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(swaggerHTML))
+	w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+	w.Header().Set("X-Frame-Options", "DENY")
+	serveDocument(w, r, "index.html", "text/html; charset=utf-8", documentCacheControl, swaggerPage)
 }
 
-// HandleSwaggerJSON serves the OpenAPI specification as YAML
+// HandleSwaggerYAML serves the OpenAPI specification as YAML
 func HandleSwaggerYAML(w http.ResponseWriter, r *http.Request) {
-	// Read the OpenAPI specification file
-	data, err := os.ReadFile("pkg/docs/openapi/openapi.yaml")
-	if err != nil {
-		http.Error(w, "Failed to read OpenAPI specification", http.StatusInternalServerError)
+	serveDocument(w, r, "openapi.yaml", "application/yaml", documentCacheControl, specDoc)
+}
+
+// HandleSwaggerAssets serves the embedded Swagger UI assets under /swagger/assets/
+func HandleSwaggerAssets(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, AssetsPath)
+	doc, ok := assets[name]
+	if !ok {
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "Asset not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/yaml")
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	// The content type is derived from the file extension
+	serveDocument(w, r, name, "", assetCacheControl, doc)
 }
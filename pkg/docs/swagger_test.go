@@ -0,0 +1,96 @@
+package docs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHandleSwaggerUI(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/swagger", nil)
+	rr := httptest.NewRecorder()
+	HandleSwaggerUI(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+		t.Errorf("Expected a Content-Security-Policy restricting scripts, got %q", csp)
+	}
+
+	// The page must not depend on anything outside the binary
+	body := rr.Body.String()
+	if strings.Contains(body, "http://") || strings.Contains(body, "https://") {
+		t.Error("Expected the page to reference only local assets")
+	}
+
+	// Every referenced asset is served
+	for _, match := range regexp.MustCompile(`(?:href|src)="([^"?]+)`).FindAllStringSubmatch(body, -1) {
+		req := httptest.NewRequest(http.MethodGet, match[1], nil)
+		rr := httptest.NewRecorder()
+		HandleSwaggerAssets(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("Asset %s returned status %d", match[1], rr.Code)
+		}
+	}
+}
+
+func TestHandleSwaggerAssets(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		wantStatusCode  int
+		wantContentType string
+	}{
+		{"script", AssetsPath + "swagger-ui-bundle.js", http.StatusOK, "text/javascript"},
+		{"stylesheet", AssetsPath + "swagger-ui.css", http.StatusOK, "text/css"},
+		{"image", AssetsPath + "favicon-16x16.png", http.StatusOK, "image/png"},
+		{"unknown asset", AssetsPath + "missing.js", http.StatusNotFound, "application/problem+json"},
+		{"not embedded", AssetsPath + "README.md", http.StatusNotFound, "application/problem+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			HandleSwaggerAssets(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatusCode)
+			}
+			if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.wantContentType) {
+				t.Errorf("Expected content type %s, got %s", tt.wantContentType, contentType)
+			}
+			if tt.wantStatusCode == http.StatusOK && rr.Header().Get("Cache-Control") != assetCacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", assetCacheControl, rr.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestHandleSwaggerYAMLConditional(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/swagger/openapi.yaml", nil)
+	rr := httptest.NewRecorder()
+	HandleSwaggerYAML(rr, req)
+
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d and %q", rr.Code, etag)
+	}
+	if rr.Body.String() != string(Spec()) {
+		t.Error("Expected the embedded specification in the response")
+	}
+
+	// A revalidation with the same ETag is answered without a body
+	req = httptest.NewRequest(http.MethodGet, "/swagger/openapi.yaml", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	HandleSwaggerYAML(rr, req)
+
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 Not Modified, got %d", rr.Code)
+	}
+}
@@ -1,19 +1,334 @@
 package domain
 
 import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
 	"github.com/hc12r/sentence-analyzer-vm/internal/analyzer"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/extract"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/pipeline"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
 )
 
 // AnalyzeSentence counts words, vowels, and consonants in a sentence
 // This function acts as an adapter between the internal analyzer and the public API
 func AnalyzeSentence(sentence string) SentenceAnalysisResponse {
-	// Use the internal analyzer to perform the analysis
 	result := analyzer.AnalyzeSentence(sentence)
-
-	// Convert the internal result to the public response format
 	return SentenceAnalysisResponse{
 		WordCount:      result.WordCount,
 		VowelCount:     result.VowelCount,
 		ConsonantCount: result.ConsonantCount,
+		Custom:         CountCustom(context.Background(), sentence),
+	}
+}
+
+// AnalyzeSentenceContext is AnalyzeSentence run through the analysis pipeline, traced as a child of any span in ctx
+// It returns the error of a pipeline stage that fails
+func AnalyzeSentenceContext(ctx context.Context, sentence string) (SentenceAnalysisResponse, error) {
+	ctx, span := tracing.Start(ctx, "domain.AnalyzeSentence")
+	defer span.End()
+	span.SetAttributes(attribute.Int("analyzer.input_bytes", len(sentence)))
+
+	// Count through the tokenize and classify_characters stages, each in its own span
+	outputs, err := pipeline.Run(ctx, sentence, []string{pipeline.StageTokenize, pipeline.StageClassifyCharacters})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return SentenceAnalysisResponse{}, err
+	}
+	tokens := outputs[pipeline.StageTokenize].(pipeline.Tokens)
+	classes := outputs[pipeline.StageClassifyCharacters].(pipeline.CharacterClasses)
+
+	return SentenceAnalysisResponse{
+		WordCount:      len(tokens.Words),
+		VowelCount:     classes.Vowels,
+		ConsonantCount: classes.Consonants,
+		Custom:         CountCustom(ctx, sentence),
+	}, nil
+}
+
+// AnalyzeDetailed is AnalyzeSentenceContext with the character and sentence counts and up to
+// topWords of the most frequent words
+func AnalyzeDetailed(ctx context.Context, text string, topWords int) (DetailedAnalysisResponse, error) {
+	ctx, span := tracing.Start(ctx, "domain.AnalyzeDetailed")
+	defer span.End()
+
+	counts, err := AnalyzeSentenceContext(ctx, text)
+	if err != nil {
+		return DetailedAnalysisResponse{}, err
+	}
+	frequencies := WordFrequencies(ctx, text)
+	if len(frequencies) > topWords {
+		frequencies = frequencies[:topWords]
+	}
+
+	return DetailedAnalysisResponse{
+		WordCount:      counts.WordCount,
+		VowelCount:     counts.VowelCount,
+		ConsonantCount: counts.ConsonantCount,
+		CharacterCount: utf8.RuneCountInString(text),
+		SentenceCount:  len(SplitSentences(ctx, text)),
+		TopWords:       frequencies,
+		Custom:         counts.Custom,
+	}, nil
+}
+
+// Compare analyzes an original text and its rewrite as AnalyzeDetailed does, with the change in each count,
+// a word-level diff and similarity scores
+func Compare(ctx context.Context, original, revised string, topWords int) (ComparisonResponse, error) {
+	ctx, span := tracing.Start(ctx, "domain.Compare")
+	defer span.End()
+
+	var result ComparisonResponse
+	var err error
+	if result.Original, err = AnalyzeDetailed(ctx, original, topWords); err != nil {
+		return ComparisonResponse{}, err
+	}
+	if result.Revised, err = AnalyzeDetailed(ctx, revised, topWords); err != nil {
+		return ComparisonResponse{}, err
+	}
+	result.Delta = AnalysisDelta{
+		WordCount:      result.Revised.WordCount - result.Original.WordCount,
+		VowelCount:     result.Revised.VowelCount - result.Original.VowelCount,
+		ConsonantCount: result.Revised.ConsonantCount - result.Original.ConsonantCount,
+		CharacterCount: result.Revised.CharacterCount - result.Original.CharacterCount,
+		SentenceCount:  result.Revised.SentenceCount - result.Original.SentenceCount,
+		Custom:         customDelta(result.Original.Custom, result.Revised.Custom),
+	}
+
+	ops := analyzer.DiffWords(ctx, original, revised)
+	result.Diff = make([]WordDiff, len(ops))
+	for i, op := range ops {
+		result.Diff[i] = WordDiff{Op: op.Op, Text: strings.Join(op.Words, " ")}
+	}
+	result.Similarity = Similarity{
+		Jaccard:      analyzer.JaccardSimilarity(original, revised),
+		Cosine:       analyzer.CosineSimilarity(original, revised),
+		EditDistance: analyzer.EditDistance(ops),
+	}
+	return result, nil
+}
+
+// markupFormats maps the marked up input formats to the extractors that read them
+var markupFormats = map[string]string{
+	FormatMarkdown: extract.FormatMarkdown,
+	FormatHTML:     extract.FormatHTML,
+}
+
+// Prose returns the prose of a sentence in the given input format, leaving out code, tags, link URLs,
+// front matter and other markup, with a report of what was left out
+// Plain sentences are returned unchanged with no report
+func Prose(ctx context.Context, sentence, format string) (string, *ExcludedContent, error) {
+	extractFormat, ok := markupFormats[format]
+	if !ok {
+		return sentence, nil, nil
+	}
+
+	_, span := tracing.Start(ctx, "domain.Prose")
+	defer span.End()
+	span.SetAttributes(attribute.String("analyzer.input_format", format))
+
+	prose, exclusions, err := extract.Prose(extractFormat, []byte(sentence))
+	if err != nil {
+		return "", nil, err
+	}
+
+	excluded := &ExcludedContent{Reasons: make([]ExclusionReason, 0, len(exclusions))}
+	for _, exclusion := range exclusions {
+		excluded.Characters += exclusion.Characters
+		excluded.Reasons = append(excluded.Reasons, ExclusionReason{Reason: exclusion.Reason, Characters: exclusion.Characters})
+	}
+	sort.SliceStable(excluded.Reasons, func(i, j int) bool {
+		return excluded.Reasons[i].Characters > excluded.Reasons[j].Characters
+	})
+	span.SetAttributes(attribute.Int("analyzer.excluded_characters", excluded.Characters))
+	return prose, excluded, nil
+}
+
+// CountCustom counts the matches of each configured custom counting rule in a text, nil when none are configured
+func CountCustom(ctx context.Context, text string) map[string]int {
+	set := rules.Active()
+	if set.Len() == 0 {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "domain.CountCustom")
+	defer span.End()
+	span.SetAttributes(attribute.Int("analyzer.rule_count", set.Len()))
+
+	return set.Count(text)
+}
+
+// customDelta returns the change in each custom count from original to revised, nil when neither has any
+func customDelta(original, revised map[string]int) map[string]int {
+	if original == nil && revised == nil {
+		return nil
+	}
+	delta := make(map[string]int, len(revised))
+	for name, count := range revised {
+		delta[name] = count
+	}
+	for name, count := range original {
+		delta[name] -= count
+	}
+	return delta
+}
+
+// addCustom returns the sum of custom totals and counts
+// It returns a new map, so totals already handed out, such as in progress events, are not changed
+func addCustom(totals, counts map[string]int) map[string]int {
+	if counts == nil {
+		return totals
+	}
+	sum := make(map[string]int, len(counts))
+	for name, count := range totals {
+		sum[name] = count
+	}
+	for name, count := range counts {
+		sum[name] += count
+	}
+	return sum
+}
+
+// AnalysisStages returns the names of the registered analysis pipeline stages in alphabetical order
+func AnalysisStages() []string {
+	return pipeline.Stages()
+}
+
+// RunAnalyses runs the named pipeline stages on a text and returns their outputs keyed by name
+func RunAnalyses(ctx context.Context, text string, names []string) (map[string]interface{}, error) {
+	ctx, span := tracing.Start(ctx, "domain.RunAnalyses")
+	defer span.End()
+	span.SetAttributes(attribute.StringSlice("analyzer.stages", names))
+
+	outputs, err := pipeline.Run(ctx, text, names)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return outputs, err
+}
+
+// WordFrequencies counts each distinct word in a text, most frequent first
+func WordFrequencies(ctx context.Context, text string) []WordFrequency {
+	ctx, span := tracing.Start(ctx, "domain.WordFrequencies")
+	defer span.End()
+
+	internal := analyzer.WordFrequencies(ctx, text)
+	frequencies := make([]WordFrequency, len(internal))
+	for i, frequency := range internal {
+		frequencies[i] = WordFrequency{Word: frequency.Word, Count: frequency.Count}
+	}
+	return frequencies
+}
+
+// LetterFrequencies counts each letter of a text, ignoring case
+func LetterFrequencies(ctx context.Context, text string) map[rune]int {
+	_, span := tracing.Start(ctx, "domain.LetterFrequencies")
+	defer span.End()
+
+	return analyzer.LetterFrequencies(text)
+}
+
+// ReadingEase is the Flesch reading ease of a text, from its words, sentences and estimated syllables
+// Higher scores are easier to read; the syllable estimate assumes English
+func ReadingEase(ctx context.Context, text string) float64 {
+	ctx, span := tracing.Start(ctx, "domain.ReadingEase")
+	defer span.End()
+
+	words := strings.Fields(text)
+	syllables := 0
+	for _, word := range words {
+		syllables += analyzer.Syllables(word)
+	}
+	return analyzer.ReadingEase(len(words), len(SplitSentences(ctx, text)), syllables)
+}
+
+// SplitSentences splits a text into its sentences
+func SplitSentences(ctx context.Context, text string) []string {
+	ctx, span := tracing.Start(ctx, "domain.SplitSentences")
+	defer span.End()
+
+	return analyzer.SplitSentences(ctx, text)
+}
+
+// AnalyzeSentences analyzes each sentence in order and adds up the totals, calling
+// progress, if set, after each sentence; it stops with ctx's error when ctx is done
+func AnalyzeSentences(ctx context.Context, sentences []string, progress func(AnalysisProgress)) (DocumentAnalysisResponse, error) {
+	ctx, span := tracing.Start(ctx, "domain.AnalyzeSentences")
+	defer span.End()
+	span.SetAttributes(attribute.Int("analyzer.sentence_count", len(sentences)))
+
+	// The rules are fixed for the whole job, even if they are reloaded meanwhile
+	set := rules.Active()
+	state := AnalysisProgress{SentencesTotal: len(sentences)}
+	for _, sentence := range sentences {
+		state.BytesTotal += len(sentence)
+	}
+
+	result := DocumentAnalysisResponse{Sentences: make([]SentenceAnalysisResponse, 0, len(sentences))}
+	for _, sentence := range sentences {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		// Counted directly so a large document does not produce a span per sentence
+		counts := analyzer.AnalyzeSentence(sentence)
+		sentenceResult := SentenceAnalysisResponse{
+			WordCount:      counts.WordCount,
+			VowelCount:     counts.VowelCount,
+			ConsonantCount: counts.ConsonantCount,
+			Custom:         set.Count(sentence),
+		}
+		result.Sentences = append(result.Sentences, sentenceResult)
+
+		state.BytesProcessed += len(sentence)
+		state.SentencesDone++
+		state.Totals.WordCount += sentenceResult.WordCount
+		state.Totals.VowelCount += sentenceResult.VowelCount
+		state.Totals.ConsonantCount += sentenceResult.ConsonantCount
+		state.Totals.Custom = addCustom(state.Totals.Custom, sentenceResult.Custom)
+		if progress != nil {
+			progress(state)
+		}
+	}
+
+	result.Totals = state.Totals
+	return result, nil
+}
+
+// AnalyzeSections analyzes each section of an extracted document and adds up the totals
+// It stops with the error of a pipeline stage that fails
+func AnalyzeSections(ctx context.Context, sections []extract.Section) ([]SectionAnalysis, SentenceAnalysisResponse, error) {
+	ctx, span := tracing.Start(ctx, "domain.AnalyzeSections")
+	defer span.End()
+	span.SetAttributes(attribute.Int("analyzer.section_count", len(sections)))
+
+	var totals SentenceAnalysisResponse
+	results := make([]SectionAnalysis, 0, len(sections))
+	for _, section := range sections {
+		counts, err := AnalyzeSentenceContext(ctx, section.Text)
+		if err != nil {
+			return nil, SentenceAnalysisResponse{}, err
+		}
+		results = append(results, SectionAnalysis{
+			Title:          section.Title,
+			Page:           section.Page,
+			CharacterCount: utf8.RuneCountInString(section.Text),
+			WordCount:      counts.WordCount,
+			VowelCount:     counts.VowelCount,
+			ConsonantCount: counts.ConsonantCount,
+			Custom:         counts.Custom,
+		})
+
+		totals.WordCount += counts.WordCount
+		totals.VowelCount += counts.VowelCount
+		totals.ConsonantCount += counts.ConsonantCount
+		totals.Custom = addCustom(totals.Custom, counts.Custom)
 	}
+	return results, totals, nil
 }
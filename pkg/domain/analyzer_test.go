@@ -1,7 +1,15 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
 	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/extract"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/pipeline"
+	"github.com/hc12r/sentence-analyzer-vm/pkg/rules"
 )
 
 func TestAnalyzeSentence(t *testing.T) {
@@ -51,6 +59,10 @@ func TestAnalyzeSentence(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := AnalyzeSentence(tt.sentence)
+			// The pipeline counts as AnalyzeSentence does
+			if traced, err := AnalyzeSentenceContext(context.Background(), tt.sentence); err != nil || !reflect.DeepEqual(traced, got) {
+				t.Errorf("AnalyzeSentenceContext() = %+v, %v, want %+v", traced, err, got)
+			}
 			if got.WordCount != tt.want.WordCount {
 				t.Errorf("WordCount = %v, want %v", got.WordCount, tt.want.WordCount)
 			}
@@ -63,3 +75,253 @@ func TestAnalyzeSentence(t *testing.T) {
 		})
 	}
 }
+
+func TestWordFrequencies(t *testing.T) {
+	got := WordFrequencies(context.Background(), "Go, go GO! Stop.")
+	want := []WordFrequency{{Word: "go", Count: 3}, {Word: "stop", Count: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordFrequencies() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeDetailed(t *testing.T) {
+	got, err := AnalyzeDetailed(context.Background(), "Go, go GO! Stop. Wait", 2)
+	if err != nil {
+		t.Fatalf("AnalyzeDetailed() error = %v", err)
+	}
+	want := DetailedAnalysisResponse{
+		WordCount:      5,
+		VowelCount:     6,
+		ConsonantCount: 8,
+		CharacterCount: 21,
+		SentenceCount:  3,
+		TopWords:       []WordFrequency{{Word: "go", Count: 3}, {Word: "stop", Count: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeDetailed() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	got, err := Compare(context.Background(), "The cat sat.", "The black cat sat down.", 1)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	wantDelta := AnalysisDelta{WordCount: 2, VowelCount: 2, ConsonantCount: 7, CharacterCount: 11, SentenceCount: 0}
+	if !reflect.DeepEqual(got.Delta, wantDelta) {
+		t.Errorf("Compare() delta = %+v, want %+v", got.Delta, wantDelta)
+	}
+	if got.Original.WordCount != 3 || got.Revised.WordCount != 5 || len(got.Revised.TopWords) != 1 {
+		t.Errorf("Compare() analyses = %+v, %+v", got.Original, got.Revised)
+	}
+
+	wantDiff := []WordDiff{
+		{Op: "equal", Text: "The"},
+		{Op: "insert", Text: "black"},
+		{Op: "equal", Text: "cat"},
+		{Op: "delete", Text: "sat."},
+		{Op: "insert", Text: "sat down."},
+	}
+	if !reflect.DeepEqual(got.Diff, wantDiff) {
+		t.Errorf("Compare() diff = %+v, want %+v", got.Diff, wantDiff)
+	}
+
+	if got.Similarity.Jaccard != 0.6 {
+		t.Errorf("Compare() jaccard = %v, want 0.6", got.Similarity.Jaccard)
+	}
+	if want := 20 / math.Sqrt(17*32); math.Abs(got.Similarity.Cosine-want) > 1e-9 {
+		t.Errorf("Compare() cosine = %v, want %v", got.Similarity.Cosine, want)
+	}
+	if got.Similarity.EditDistance != 3 {
+		t.Errorf("Compare() edit distance = %d, want 3", got.Similarity.EditDistance)
+	}
+}
+
+func TestReadingEase(t *testing.T) {
+	// 3 words of 1 syllable in 1 sentence
+	if got, want := ReadingEase(context.Background(), "The cat sat."), 206.835-1.015*3-84.6; math.Abs(got-want) > 1e-9 {
+		t.Errorf("ReadingEase() = %v, want %v", got, want)
+	}
+}
+
+func TestCountCustom(t *testing.T) {
+	original := rules.Active()
+	defer rules.SetActive(original)
+
+	rules.SetActive(nil)
+	if got, _ := AnalyzeSentenceContext(context.Background(), "#go #rules"); got.Custom != nil {
+		t.Errorf("Expected no custom counts without rules, got %v", got.Custom)
+	}
+
+	set, err := rules.Compile([]rules.Rule{{Name: "hashtags", Pattern: `#\w+`}, {Name: "mentions", Pattern: `@\w+`}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	rules.SetActive(set)
+	want := map[string]int{"hashtags": 2, "mentions": 0}
+	if got, _ := AnalyzeSentenceContext(context.Background(), "#go #rules"); !reflect.DeepEqual(got.Custom, want) {
+		t.Errorf("AnalyzeSentenceContext() custom = %v, want %v", got.Custom, want)
+	}
+	if got, _ := AnalyzeDetailed(context.Background(), "#go #rules", 1); !reflect.DeepEqual(got.Custom, want) {
+		t.Errorf("AnalyzeDetailed() custom = %v, want %v", got.Custom, want)
+	}
+
+	// Every other analysis has the counts too, and adds them up in its totals
+	wantTotals := map[string]int{"hashtags": 3, "mentions": 1}
+	document, _ := AnalyzeSentences(context.Background(), []string{"#go #rules", "@ana #go"}, nil)
+	if got := document.Sentences[0].Custom; !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeSentences() sentence custom = %v, want %v", got, want)
+	}
+	if got := document.Totals.Custom; !reflect.DeepEqual(got, wantTotals) {
+		t.Errorf("AnalyzeSentences() totals custom = %v, want %v", got, wantTotals)
+	}
+	sections, totals, _ := AnalyzeSections(context.Background(), []extract.Section{{Text: "#go #rules"}, {Text: "@ana #go"}})
+	if got := sections[0].Custom; !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeSections() section custom = %v, want %v", got, want)
+	}
+	if got := totals.Custom; !reflect.DeepEqual(got, wantTotals) {
+		t.Errorf("AnalyzeSections() totals custom = %v, want %v", got, wantTotals)
+	}
+	// Live analysis does not rescan the text for rules after every edit
+	if got := NewDocument("#go #rules").Result().Custom; got != nil {
+		t.Errorf("Document.Result() custom = %v, want none", got)
+	}
+	comparison, _ := Compare(context.Background(), "#go #rules", "@ana #go", 1)
+	if got, wantDelta := comparison.Delta.Custom, map[string]int{"hashtags": -1, "mentions": 1}; !reflect.DeepEqual(got, wantDelta) {
+		t.Errorf("Compare() delta custom = %v, want %v", got, wantDelta)
+	}
+}
+
+func TestRunAnalyses(t *testing.T) {
+	got, err := RunAnalyses(context.Background(), "Go go!", []string{"tokenize"})
+	if err != nil {
+		t.Fatalf("RunAnalyses() error = %v", err)
+	}
+	if want := map[string]interface{}{"tokenize": pipeline.Tokens{Words: []string{"Go", "go!"}}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RunAnalyses() = %v, want %v", got, want)
+	}
+
+	if _, err := RunAnalyses(context.Background(), "Go", []string{"sentiment"}); !errors.Is(err, pipeline.ErrUnknownStage) {
+		t.Errorf("RunAnalyses() with an unknown stage error = %v, want ErrUnknownStage", err)
+	}
+}
+
+func TestLetterFrequencies(t *testing.T) {
+	got := LetterFrequencies(context.Background(), "Go go!")
+	if want := map[rune]int{'g': 2, 'o': 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LetterFrequencies() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeSections(t *testing.T) {
+	sections := []extract.Section{
+		{Title: "Intro", Text: "Intro\nHello world"},
+		{Page: 2, Text: "Sky."},
+	}
+
+	results, totals, err := AnalyzeSections(context.Background(), sections)
+	if err != nil {
+		t.Fatalf("AnalyzeSections() error = %v", err)
+	}
+	wantResults := []SectionAnalysis{
+		{Title: "Intro", CharacterCount: 17, WordCount: 3, VowelCount: 5, ConsonantCount: 10},
+		{Page: 2, CharacterCount: 4, WordCount: 1, VowelCount: 0, ConsonantCount: 3},
+	}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("AnalyzeSections() sections = %+v, want %+v", results, wantResults)
+	}
+	wantTotals := SentenceAnalysisResponse{WordCount: 4, VowelCount: 5, ConsonantCount: 13}
+	if !reflect.DeepEqual(totals, wantTotals) {
+		t.Errorf("AnalyzeSections() totals = %+v, want %+v", totals, wantTotals)
+	}
+}
+
+func TestProse(t *testing.T) {
+	tests := []struct {
+		name         string
+		sentence     string
+		format       string
+		wantProse    string
+		wantExcluded *ExcludedContent
+	}{
+		{name: "plain", sentence: "Use <b>bold</b>", format: FormatPlain, wantProse: "Use <b>bold</b>"},
+		{name: "no format", sentence: "Use `code`", wantProse: "Use `code`"},
+		{
+			name:      "markdown",
+			sentence:  "Run `go test` or read [the guide](https://go.dev/doc).",
+			format:    FormatMarkdown,
+			wantProse: "Run or read the guide.",
+			wantExcluded: &ExcludedContent{Characters: 30, Reasons: []ExclusionReason{
+				{Reason: "link_url", Characters: 20},
+				{Reason: "code", Characters: 8},
+				{Reason: "syntax", Characters: 2},
+			}},
+		},
+		{
+			name:         "html without markup to exclude",
+			sentence:     "Plain words",
+			format:       FormatHTML,
+			wantProse:    "Plain words",
+			wantExcluded: &ExcludedContent{Reasons: []ExclusionReason{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prose, excluded, err := Prose(context.Background(), tt.sentence, tt.format)
+			if err != nil {
+				t.Fatalf("Prose returned error: %v", err)
+			}
+			if prose != tt.wantProse {
+				t.Errorf("Prose() = %q, want %q", prose, tt.wantProse)
+			}
+			if !reflect.DeepEqual(excluded, tt.wantExcluded) {
+				t.Errorf("Prose() excluded = %+v, want %+v", excluded, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := SplitSentences(context.Background(), "One. Two?")
+	want := []string{"One.", "Two?"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitSentences() = %q, want %q", got, want)
+	}
+}
+
+func TestAnalyzeSentences(t *testing.T) {
+	var progress []AnalysisProgress
+	got, err := AnalyzeSentences(context.Background(), []string{"Hello World.", "Hi!"}, func(p AnalysisProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeSentences returned error: %v", err)
+	}
+
+	want := DocumentAnalysisResponse{
+		Sentences: []SentenceAnalysisResponse{
+			{WordCount: 2, VowelCount: 3, ConsonantCount: 7},
+			{WordCount: 1, VowelCount: 1, ConsonantCount: 1},
+		},
+		Totals: SentenceAnalysisResponse{WordCount: 3, VowelCount: 4, ConsonantCount: 8},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeSentences() = %+v, want %+v", got, want)
+	}
+
+	wantProgress := []AnalysisProgress{
+		{BytesProcessed: 12, BytesTotal: 15, SentencesDone: 1, SentencesTotal: 2, Totals: want.Sentences[0]},
+		{BytesProcessed: 15, BytesTotal: 15, SentencesDone: 2, SentencesTotal: 2, Totals: want.Totals},
+	}
+	if !reflect.DeepEqual(progress, wantProgress) {
+		t.Errorf("progress = %+v, want %+v", progress, wantProgress)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AnalyzeSentences(ctx, []string{"Hello"}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
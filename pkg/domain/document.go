@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"github.com/hc12r/sentence-analyzer-vm/internal/analyzer"
+)
+
+// Document is a text kept analyzed while it is edited, for live analysis as the user types
+type Document struct {
+	doc *analyzer.Document
+}
+
+// NewDocument creates a Document with the given starting text
+func NewDocument(text string) *Document {
+	return &Document{doc: analyzer.NewDocument(text)}
+}
+
+// Apply applies edits in order, recounting only the regions they touch
+// The edits should have been checked with ValidateEdits; an edit out of range stops the others
+func (d *Document) Apply(edits []TextEdit) error {
+	for _, edit := range edits {
+		if err := d.doc.Edit(edit.Offset, edit.Delete, edit.Insert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Result returns the analysis of the current text
+// Custom counting rules are left out, as matching them would mean scanning the whole text after every edit
+func (d *Document) Result() SentenceAnalysisResponse {
+	result := d.doc.Result()
+	return SentenceAnalysisResponse{
+		WordCount:      result.WordCount,
+		VowelCount:     result.VowelCount,
+		ConsonantCount: result.ConsonantCount,
+	}
+}
+
+// Len returns the length of the text in characters
+func (d *Document) Len() int {
+	return d.doc.Len()
+}
+
+// String returns the current text
+func (d *Document) String() string {
+	return d.doc.String()
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocumentApply(t *testing.T) {
+	doc := NewDocument("Hello")
+
+	edits := []TextEdit{
+		{Offset: 5, Insert: " World"},
+		{Offset: 0, Delete: 1, Insert: "J"},
+	}
+	if err := doc.Apply(edits); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if want := AnalyzeSentence("Jello World"); !reflect.DeepEqual(doc.Result(), want) {
+		t.Errorf("Expected %+v, got %+v", want, doc.Result())
+	}
+	if doc.Len() != 11 {
+		t.Errorf("Expected length 11, got %d", doc.Len())
+	}
+
+	if err := doc.Apply([]TextEdit{{Offset: 12}}); err == nil {
+		t.Error("Expected an error for an edit past the end")
+	}
+}
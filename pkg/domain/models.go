@@ -1,13 +1,255 @@
 package domain
 
+import "time"
+
+// Input formats of a sentence analysis request
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
 // SentenceAnalysisRequest represents the request body
+// Format is how the sentence is marked up: plain, the default, markdown or html
+// Analyses names pipeline stages whose outputs are added to the response
 type SentenceAnalysisRequest struct {
-	Sentence string `json:"sentence"`
+	Sentence string   `json:"sentence"`
+	Format   string   `json:"format,omitempty"`
+	Analyses []string `json:"analyses,omitempty"`
 }
 
 // SentenceAnalysisResponse represents the response body
+// Excluded is only set when the sentence was marked up, and Analyses when stages were requested
+// Custom holds the count of each custom counting rule, when any are configured
 type SentenceAnalysisResponse struct {
+	WordCount      int                    `json:"word_count"`
+	VowelCount     int                    `json:"vowel_count"`
+	ConsonantCount int                    `json:"consonant_count"`
+	Custom         map[string]int         `json:"custom,omitempty"`
+	Excluded       *ExcludedContent       `json:"excluded,omitempty"`
+	Analyses       map[string]interface{} `json:"analyses,omitempty"`
+}
+
+// ExcludedContent reports the markup left out of the analysis of a marked up sentence
+// Characters counts non-whitespace characters; Reasons breaks them down, largest first
+type ExcludedContent struct {
+	Characters int               `json:"characters"`
+	Reasons    []ExclusionReason `json:"reasons"`
+}
+
+// ExclusionReason counts the characters left out for one reason, such as code, tag or link_url
+type ExclusionReason struct {
+	Reason     string `json:"reason"`
+	Characters int    `json:"characters"`
+}
+
+// DetailedAnalysisResponse represents the richer response body of the /v2 API
+// It adds character and sentence counts and the most frequent words to the counts of SentenceAnalysisResponse
+type DetailedAnalysisResponse struct {
+	WordCount      int                    `json:"word_count"`
+	VowelCount     int                    `json:"vowel_count"`
+	ConsonantCount int                    `json:"consonant_count"`
+	CharacterCount int                    `json:"character_count"`
+	SentenceCount  int                    `json:"sentence_count"`
+	TopWords       []WordFrequency        `json:"top_words"`
+	Custom         map[string]int         `json:"custom,omitempty"`
+	Excluded       *ExcludedContent       `json:"excluded,omitempty"`
+	Analyses       map[string]interface{} `json:"analyses,omitempty"`
+}
+
+// WordFrequency represents how often a word occurs in the analyzed text
+type WordFrequency struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// CompareRequest represents the request body of a comparison of an original text and its rewrite
+type CompareRequest struct {
+	Original string `json:"original"`
+	Revised  string `json:"revised"`
+}
+
+// ComparisonResponse represents the comparison of an original text and its rewrite
+// Delta holds each revised count less the original one, and Diff the word-level changes in text order
+type ComparisonResponse struct {
+	Original   DetailedAnalysisResponse `json:"original"`
+	Revised    DetailedAnalysisResponse `json:"revised"`
+	Delta      AnalysisDelta            `json:"delta"`
+	Diff       []WordDiff               `json:"diff"`
+	Similarity Similarity               `json:"similarity"`
+}
+
+// AnalysisDelta represents the change in each count of a DetailedAnalysisResponse
+type AnalysisDelta struct {
 	WordCount      int `json:"word_count"`
 	VowelCount     int `json:"vowel_count"`
 	ConsonantCount int `json:"consonant_count"`
+	CharacterCount int `json:"character_count"`
+	SentenceCount  int `json:"sentence_count"`
+	// Custom is the change in the count of each custom counting rule, when rules are configured
+	Custom map[string]int `json:"custom,omitempty"`
+}
+
+// WordDiff is a run of words kept (equal), deleted from the original or inserted in the rewrite
+// Text holds the words separated by single spaces
+type WordDiff struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Similarity scores how alike two texts are
+// Jaccard compares their sets of words and Cosine their letter frequencies, from 0 to 1;
+// EditDistance counts the words substituted, deleted or inserted by the word diff
+type Similarity struct {
+	Jaccard      float64 `json:"jaccard"`
+	Cosine       float64 `json:"cosine"`
+	EditDistance int     `json:"edit_distance"`
+}
+
+// TextEdit replaces Delete characters starting at Offset with Insert
+// Offsets and lengths count Unicode code points
+type TextEdit struct {
+	Offset int    `json:"offset"`
+	Delete int    `json:"delete"`
+	Insert string `json:"insert"`
+}
+
+// AnalysisJobRequest represents the request body for a long-running analysis
+// Text is a document that is split into sentences; Sentences is a batch analyzed as given
+type AnalysisJobRequest struct {
+	Text      string   `json:"text,omitempty"`
+	Sentences []string `json:"sentences,omitempty"`
+}
+
+// AnalysisProgress reports how far a long-running analysis has got
+// Totals holds the counts of the sentences done so far
+type AnalysisProgress struct {
+	BytesProcessed int                      `json:"bytes_processed"`
+	BytesTotal     int                      `json:"bytes_total"`
+	SentencesDone  int                      `json:"sentences_done"`
+	SentencesTotal int                      `json:"sentences_total"`
+	Totals         SentenceAnalysisResponse `json:"totals"`
+}
+
+// DocumentAnalysisResponse represents the result of a long-running analysis
+// Sentences holds the counts of each sentence in order
+type DocumentAnalysisResponse struct {
+	Sentences []SentenceAnalysisResponse `json:"sentences"`
+	Totals    SentenceAnalysisResponse   `json:"totals"`
+}
+
+// UploadAnalysisResponse represents the analysis of an uploaded document
+// Sections holds the counts of each heading's section, or of each PDF page, in document order
+type UploadAnalysisResponse struct {
+	Filename string                   `json:"filename"`
+	Format   string                   `json:"format"`
+	Sections []SectionAnalysis        `json:"sections"`
+	Totals   SentenceAnalysisResponse `json:"totals"`
+}
+
+// SectionAnalysis represents the counts of one section of a document
+// Title is empty for text before the first heading, and Page is only set for PDFs
+type SectionAnalysis struct {
+	Title          string         `json:"title,omitempty"`
+	Page           int            `json:"page,omitempty"`
+	CharacterCount int            `json:"character_count"`
+	WordCount      int            `json:"word_count"`
+	VowelCount     int            `json:"vowel_count"`
+	ConsonantCount int            `json:"consonant_count"`
+	Custom         map[string]int `json:"custom,omitempty"`
+}
+
+// CorpusDocumentRequest represents the request body that adds a text to a corpus
+type CorpusDocumentRequest struct {
+	Text string `json:"text"`
+}
+
+// CorpusDocument represents the summary a corpus keeps of each text added to it; the text itself is not kept
+// NewWords counts the distinct words the corpus had not seen before
+type CorpusDocument struct {
+	ID             int       `json:"id"`
+	AddedAt        time.Time `json:"added_at"`
+	WordCount      int       `json:"word_count"`
+	CharacterCount int       `json:"character_count"`
+	SentenceCount  int       `json:"sentence_count"`
+	ReadingEase    float64   `json:"reading_ease"`
+	NewWords       int       `json:"new_words"`
+}
+
+// CorpusSummary represents a corpus in a list of corpora
+type CorpusSummary struct {
+	Name          string    `json:"name"`
+	DocumentCount int       `json:"document_count"`
+	WordCount     int       `json:"word_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CorpusStatistics represents the statistics aggregated over every document of a corpus
+// VocabularyGrowth has a point per document, in the order they were added
+type CorpusStatistics struct {
+	Name               string                  `json:"name"`
+	DocumentCount      int                     `json:"document_count"`
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+	WordCount          int                     `json:"word_count"`
+	CharacterCount     int                     `json:"character_count"`
+	SentenceCount      int                     `json:"sentence_count"`
+	VocabularySize     int                     `json:"vocabulary_size"`
+	VocabularyGrowth   []VocabularyPoint       `json:"vocabulary_growth"`
+	LetterDistribution []LetterShare           `json:"letter_distribution"`
+	ReadingEase        ReadingEaseDistribution `json:"reading_ease"`
+}
+
+// VocabularyPoint represents the size of a corpus's vocabulary once its first Documents documents were added
+type VocabularyPoint struct {
+	Documents      int `json:"documents"`
+	WordCount      int `json:"word_count"`
+	VocabularySize int `json:"vocabulary_size"`
+}
+
+// LetterShare represents how often a letter occurs in a corpus, ignoring case
+// Share is the letter's fraction of all the corpus's letters
+type LetterShare struct {
+	Letter string  `json:"letter"`
+	Count  int     `json:"count"`
+	Share  float64 `json:"share"`
+}
+
+// ReadingEaseDistribution represents the spread of the Flesch reading ease of a corpus's documents
+// Percentiles are interpolated between the nearest documents
+type ReadingEaseDistribution struct {
+	Min  float64 `json:"min"`
+	P10  float64 `json:"p10"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// HistoryEntry represents a past analysis in its client's history
+// Preview holds the start of the analyzed text; the counts are those of the whole text
+type HistoryEntry struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Endpoint       string    `json:"endpoint"`
+	Format         string    `json:"format,omitempty"`
+	Preview        string    `json:"preview"`
+	CharacterCount int       `json:"character_count"`
+	WordCount      int       `json:"word_count"`
+	VowelCount     int       `json:"vowel_count"`
+	ConsonantCount int       `json:"consonant_count"`
+}
+
+// HistoryRetentionRequest represents the request body that sets how long a client's history is kept
+type HistoryRetentionRequest struct {
+	RetentionDays *int `json:"retention_days"`
+}
+
+// HistoryRetention represents how long a client's history is kept, in days, and the longest it may be kept
+type HistoryRetention struct {
+	RetentionDays    int `json:"retention_days"`
+	MaxRetentionDays int `json:"max_retention_days"`
 }
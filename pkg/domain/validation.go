@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes a validation problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks the request against the input rules:
+// the sentence is required, must contain at least one non-whitespace character,
+// must be valid UTF-8 and must not exceed maxLength characters (0 disables the length check),
+// and the format, if set, must be plain, markdown or html
+func (r SentenceAnalysisRequest) Validate(maxLength int) []FieldError {
+	var errs []FieldError
+
+	switch r.Format {
+	case "", FormatPlain, FormatMarkdown, FormatHTML:
+	default:
+		errs = append(errs, FieldError{Field: "format", Message: "must be one of plain, markdown, html"})
+	}
+
+	switch {
+	case r.Sentence == "":
+		errs = append(errs, FieldError{Field: "sentence", Message: "is required"})
+	case strings.TrimSpace(r.Sentence) == "":
+		errs = append(errs, FieldError{Field: "sentence", Message: "must contain non-whitespace characters"})
+	case !utf8.ValidString(r.Sentence):
+		errs = append(errs, FieldError{Field: "sentence", Message: "must be valid UTF-8"})
+	case maxLength > 0 && utf8.RuneCountInString(r.Sentence) > maxLength:
+		errs = append(errs, FieldError{Field: "sentence", Message: fmt.Sprintf("must be at most %d characters", maxLength)})
+	}
+
+	if len(r.Analyses) > 0 {
+		stages := AnalysisStages()
+		registered := make(map[string]bool, len(stages))
+		for _, stage := range stages {
+			registered[stage] = true
+		}
+		for i, name := range r.Analyses {
+			if !registered[name] {
+				errs = append(errs, FieldError{Field: fmt.Sprintf("analyses[%d]", i), Message: "must be one of " + strings.Join(stages, ", ")})
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateEdits checks edits applied in order to a document of length characters:
+// each must lie inside the document as left by the previous edits and insert valid UTF-8,
+// and the result must not exceed maxLength characters (0 disables the length check)
+func ValidateEdits(edits []TextEdit, length, maxLength int) []FieldError {
+	var errs []FieldError
+
+	for i, edit := range edits {
+		field := fmt.Sprintf("edits[%d]", i)
+		switch {
+		case edit.Offset < 0 || edit.Offset > length:
+			errs = append(errs, FieldError{Field: field + ".offset", Message: fmt.Sprintf("must be between 0 and %d", length)})
+		case edit.Delete < 0 || edit.Offset+edit.Delete > length:
+			errs = append(errs, FieldError{Field: field + ".delete", Message: fmt.Sprintf("must be between 0 and %d", length-edit.Offset)})
+		case !utf8.ValidString(edit.Insert):
+			errs = append(errs, FieldError{Field: field + ".insert", Message: "must be valid UTF-8"})
+		default:
+			length += utf8.RuneCountInString(edit.Insert) - edit.Delete
+			continue
+		}
+		// Later offsets depend on this edit, so they cannot be checked
+		return errs
+	}
+
+	if maxLength > 0 && length > maxLength {
+		errs = append(errs, FieldError{Field: "edits", Message: fmt.Sprintf("must leave the text at most %d characters", maxLength)})
+	}
+	return errs
+}
+
+// Validate checks the request: exactly one of text and sentences is required, text must
+// contain non-whitespace characters, and sentences may hold at most maxSentences entries,
+// each valid as the sentence of a SentenceAnalysisRequest
+func (r AnalysisJobRequest) Validate(maxLength, maxSentences int) []FieldError {
+	switch {
+	case r.Text == "" && len(r.Sentences) == 0:
+		return []FieldError{{Field: "body", Message: "must contain text or sentences"}}
+	case r.Text != "" && len(r.Sentences) > 0:
+		return []FieldError{{Field: "body", Message: "must not contain both text and sentences"}}
+	case r.Text != "":
+		// A document is only bounded by the request body size
+		return renameFields(SentenceAnalysisRequest{Sentence: r.Text}.Validate(0), "text")
+	case len(r.Sentences) > maxSentences:
+		return []FieldError{{Field: "sentences", Message: fmt.Sprintf("must contain at most %d sentences", maxSentences)}}
+	}
+
+	var errs []FieldError
+	for i, sentence := range r.Sentences {
+		field := fmt.Sprintf("sentences[%d]", i)
+		errs = append(errs, renameFields(SentenceAnalysisRequest{Sentence: sentence}.Validate(maxLength), field)...)
+	}
+	return errs
+}
+
+// Validate checks that original and revised are each valid as the sentence of a SentenceAnalysisRequest
+func (r CompareRequest) Validate(maxLength int) []FieldError {
+	errs := renameFields(SentenceAnalysisRequest{Sentence: r.Original}.Validate(maxLength), "original")
+	return append(errs, renameFields(SentenceAnalysisRequest{Sentence: r.Revised}.Validate(maxLength), "revised")...)
+}
+
+// Validate checks that the text contains non-whitespace characters and is valid UTF-8
+// A document is only bounded by the request body size
+func (r CorpusDocumentRequest) Validate() []FieldError {
+	return renameFields(SentenceAnalysisRequest{Sentence: r.Text}.Validate(0), "text")
+}
+
+// Validate checks that the retention is set and between 0, which keeps no history, and maxDays
+func (r HistoryRetentionRequest) Validate(maxDays int) []FieldError {
+	switch {
+	case r.RetentionDays == nil:
+		return []FieldError{{Field: "retention_days", Message: "is required"}}
+	case *r.RetentionDays < 0 || *r.RetentionDays > maxDays:
+		return []FieldError{{Field: "retention_days", Message: fmt.Sprintf("must be between 0 and %d", maxDays)}}
+	}
+	return nil
+}
+
+// renameFields reports errs against the given field
+func renameFields(errs []FieldError, field string) []FieldError {
+	for i := range errs {
+		errs[i].Field = field
+	}
+	return errs
+}
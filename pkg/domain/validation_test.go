@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentenceAnalysisRequestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		sentence    string
+		format      string
+		analyses    []string
+		maxLength   int
+		wantField   string
+		wantMessage string
+	}{
+		{name: "valid sentence", sentence: "Hello World", maxLength: 100},
+		{name: "empty sentence", sentence: "", maxLength: 100, wantMessage: "is required"},
+		{name: "whitespace only", sentence: " \t\n", maxLength: 100, wantMessage: "must contain non-whitespace characters"},
+		{name: "invalid UTF-8", sentence: "Hello \xff World", maxLength: 100, wantMessage: "must be valid UTF-8"},
+		{name: "too long", sentence: strings.Repeat("a", 11), maxLength: 10, wantMessage: "must be at most 10 characters"},
+		{name: "length counts characters not bytes", sentence: strings.Repeat("é", 10), maxLength: 10},
+		{name: "no length limit", sentence: strings.Repeat("a", 1000), maxLength: 0},
+		{name: "markdown format", sentence: "# Hello", format: FormatMarkdown, maxLength: 100},
+		{name: "html format", sentence: "<p>Hello</p>", format: FormatHTML, maxLength: 100},
+		{name: "unknown format", sentence: "Hello", format: "rtf", maxLength: 100, wantField: "format", wantMessage: "must be one of plain, markdown, html"},
+		{name: "analyses", sentence: "Hello", analyses: []string{"count", "metrics"}, maxLength: 100},
+		{name: "unknown analysis", sentence: "Hello", analyses: []string{"count", "sentiment"}, maxLength: 100, wantField: "analyses[1]", wantMessage: "must be one of classify_characters, count, metrics, tokenize"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := SentenceAnalysisRequest{Sentence: tt.sentence, Format: tt.format, Analyses: tt.analyses}.Validate(tt.maxLength)
+
+			if tt.wantMessage == "" {
+				if len(errs) != 0 {
+					t.Errorf("Expected no errors, got %v", errs)
+				}
+				return
+			}
+
+			if len(errs) != 1 {
+				t.Fatalf("Expected 1 error, got %v", errs)
+			}
+			wantField := tt.wantField
+			if wantField == "" {
+				wantField = "sentence"
+			}
+			if errs[0].Field != wantField {
+				t.Errorf("Expected field %s, got %s", wantField, errs[0].Field)
+			}
+			if errs[0].Message != tt.wantMessage {
+				t.Errorf("Expected message %q, got %q", tt.wantMessage, errs[0].Message)
+			}
+		})
+	}
+}
+
+func TestValidateEdits(t *testing.T) {
+	tests := []struct {
+		name      string
+		edits     []TextEdit
+		length    int
+		maxLength int
+		wantField string
+	}{
+		{name: "valid edits", edits: []TextEdit{{Offset: 5, Insert: " World"}, {Offset: 11, Insert: "!"}}, length: 5, maxLength: 20},
+		{name: "no edits", length: 5, maxLength: 20},
+		{name: "offset past the end", edits: []TextEdit{{Offset: 6}}, length: 5, maxLength: 20, wantField: "edits[0].offset"},
+		{name: "negative offset", edits: []TextEdit{{Offset: -1}}, length: 5, maxLength: 20, wantField: "edits[0].offset"},
+		{name: "delete past the end", edits: []TextEdit{{Offset: 3, Delete: 3}}, length: 5, maxLength: 20, wantField: "edits[0].delete"},
+		{name: "offset depends on earlier edits", edits: []TextEdit{{Offset: 0, Delete: 5}, {Offset: 1}}, length: 5, maxLength: 20, wantField: "edits[1].offset"},
+		{name: "invalid UTF-8", edits: []TextEdit{{Insert: "\xff"}}, length: 5, maxLength: 20, wantField: "edits[0].insert"},
+		{name: "too long", edits: []TextEdit{{Insert: strings.Repeat("é", 16)}}, length: 5, maxLength: 20, wantField: "edits"},
+		{name: "no length limit", edits: []TextEdit{{Insert: strings.Repeat("a", 100)}}, length: 5, maxLength: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateEdits(tt.edits, tt.length, tt.maxLength)
+
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Errorf("Expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Errorf("Expected an error for field %s, got %v", tt.wantField, errs)
+			}
+		})
+	}
+}
+
+func TestAnalysisJobRequestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   AnalysisJobRequest
+		wantField string
+	}{
+		{name: "document", request: AnalysisJobRequest{Text: strings.Repeat("a", 50)}},
+		{name: "batch", request: AnalysisJobRequest{Sentences: []string{"One", "Two"}}},
+		{name: "empty", request: AnalysisJobRequest{}, wantField: "body"},
+		{name: "text and sentences", request: AnalysisJobRequest{Text: "One", Sentences: []string{"Two"}}, wantField: "body"},
+		{name: "whitespace document", request: AnalysisJobRequest{Text: "  "}, wantField: "text"},
+		{name: "too many sentences", request: AnalysisJobRequest{Sentences: []string{"1", "2", "3", "4"}}, wantField: "sentences"},
+		{name: "sentence too long", request: AnalysisJobRequest{Sentences: []string{"One", strings.Repeat("a", 11)}}, wantField: "sentences[1]"},
+		{name: "empty sentence", request: AnalysisJobRequest{Sentences: []string{""}}, wantField: "sentences[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.request.Validate(10, 3)
+
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Errorf("Expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Errorf("Expected one error for %s, got %v", tt.wantField, errs)
+			}
+		})
+	}
+}
+
+func TestCompareRequestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    CompareRequest
+		wantFields []string
+	}{
+		{name: "valid", request: CompareRequest{Original: "One", Revised: "Two"}},
+		{name: "missing original", request: CompareRequest{Revised: "Two"}, wantFields: []string{"original"}},
+		{name: "revised too long", request: CompareRequest{Original: "One", Revised: strings.Repeat("a", 11)}, wantFields: []string{"revised"}},
+		{name: "both whitespace", request: CompareRequest{Original: " ", Revised: "\t"}, wantFields: []string{"original", "revised"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range tt.request.Validate(10) {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("Validate() fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestCorpusDocumentRequestValidate(t *testing.T) {
+	if errs := (CorpusDocumentRequest{Text: strings.Repeat("a", 1000)}).Validate(); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+	if errs := (CorpusDocumentRequest{Text: " "}).Validate(); len(errs) != 1 || errs[0].Field != "text" {
+		t.Errorf("Expected one error for text, got %v", errs)
+	}
+}
+
+func TestHistoryRetentionRequestValidate(t *testing.T) {
+	days := func(n int) *int { return &n }
+	tests := []struct {
+		name      string
+		request   HistoryRetentionRequest
+		wantError bool
+	}{
+		{name: "keep nothing", request: HistoryRetentionRequest{RetentionDays: days(0)}},
+		{name: "maximum", request: HistoryRetentionRequest{RetentionDays: days(30)}},
+		{name: "missing", request: HistoryRetentionRequest{}, wantError: true},
+		{name: "negative", request: HistoryRetentionRequest{RetentionDays: days(-1)}, wantError: true},
+		{name: "above maximum", request: HistoryRetentionRequest{RetentionDays: days(31)}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.request.Validate(30)
+			if tt.wantError && (len(errs) != 1 || errs[0].Field != "retention_days") {
+				t.Errorf("Expected one error for retention_days, got %v", errs)
+			}
+			if !tt.wantError && len(errs) != 0 {
+				t.Errorf("Expected no errors, got %v", errs)
+			}
+		})
+	}
+}
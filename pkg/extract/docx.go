@@ -0,0 +1,123 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// wordNamespace is the namespace of the WordprocessingML elements in a DOCX document
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// maxDocumentXMLBytes bounds the uncompressed document part read from a DOCX archive,
+// so a small upload cannot expand without limit
+const maxDocumentXMLBytes = 256 << 20
+
+// errNoDocumentPart is returned for archives without a word/document.xml part
+var errNoDocumentPart = errors.New("archive has no word/document.xml part")
+
+// extractDOCX splits a DOCX document into sections at its Title and Heading paragraphs
+func extractDOCX(b *builder, data []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	var part *zip.File
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			part = f
+			break
+		}
+	}
+	if part == nil {
+		return errNoDocumentPart
+	}
+
+	rc, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return readDocumentXML(b, io.LimitReader(rc, maxDocumentXMLBytes))
+}
+
+// readDocumentXML streams the paragraphs of a document part, ignoring deleted text and field codes
+func readDocumentXML(b *builder, r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+
+	var (
+		paragraph strings.Builder
+		heading   bool
+		inText    bool
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				heading = false
+			case "pStyle":
+				heading = headingStyle(attr(t, "val"))
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				paragraph.WriteByte(' ')
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if heading {
+					err = b.heading(paragraph.String())
+				} else {
+					err = b.line(paragraph.String())
+				}
+				if err != nil {
+					return err
+				}
+				paragraph.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+}
+
+// headingStyle reports whether a paragraph style is one of Word's built-in title and heading styles
+func headingStyle(style string) bool {
+	style = strings.ToLower(style)
+	return style == "title" || strings.HasPrefix(style, "heading")
+}
+
+// attr returns the value of an element's attribute with the given local name
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newDOCX builds a DOCX archive whose document part has the given body
+func newDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:document xmlns:w="` + wordNamespace + `"><w:body>` + body + `</w:body></w:document>`,
+	}
+	for name, content := range parts {
+		f, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDOCX(t *testing.T) {
+	body := `<w:p><w:r><w:t>Opening line.</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Chapter</w:t></w:r><w:r><w:t xml:space="preserve"> one</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Split</w:t><w:tab/><w:t>by a tab</w:t></w:r><w:del><w:r><w:delText>removed</w:delText></w:r></w:del></w:p>` +
+		`<w:p/>` +
+		`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Cell text</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+		`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Appendix</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:fldChar w:fldCharType="begin"/><w:instrText>PAGE</w:instrText><w:t>Last words.</w:t></w:r></w:p>`
+
+	sections, err := Extract(FormatDOCX, newDOCX(t, body), 0)
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+
+	want := []Section{
+		{Text: "Opening line."},
+		{Title: "Chapter one", Text: "Chapter one\nSplit by a tab\nCell text"},
+		{Title: "Appendix", Text: "Appendix\nLast words."},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("Expected sections %+v, got %+v", want, sections)
+	}
+}
+
+func TestExtractDOCXWithoutDocumentPart(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	if _, err := archive.Create("word/styles.xml"); err != nil {
+		t.Fatalf("Failed to create part: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+
+	_, err := Extract(FormatDOCX, buf.Bytes(), 0)
+	if !errors.Is(err, errNoDocumentPart) {
+		t.Errorf("Expected error %v, got %v", errNoDocumentPart, err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package extract turns uploaded documents into plain text split into sections,
+// stripping markup and boilerplate so that only the prose is analyzed
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Supported document formats, named after their file extensions
+const (
+	FormatText     = "txt"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatDOCX     = "docx"
+	FormatPDF      = "pdf"
+)
+
+// Reasons content is excluded from a document's prose
+const (
+	ReasonFrontMatter = "front_matter"
+	ReasonCode        = "code"
+	ReasonLinkURL     = "link_url"
+	ReasonTag         = "tag"
+	ReasonComment     = "comment"
+	ReasonScript      = "script"
+	ReasonBoilerplate = "boilerplate"
+	ReasonSyntax      = "syntax"
+)
+
+// reasons orders the reasons in reports
+var reasons = []string{
+	ReasonFrontMatter,
+	ReasonCode,
+	ReasonLinkURL,
+	ReasonTag,
+	ReasonComment,
+	ReasonScript,
+	ReasonBoilerplate,
+	ReasonSyntax,
+}
+
+// Exclusion counts the non-whitespace characters of a document left out of its prose for one reason
+type Exclusion struct {
+	Reason     string
+	Characters int
+}
+
+// Extraction errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported document format")
+	ErrTextTooLarge      = errors.New("extracted text too large")
+)
+
+// InvalidDocumentError reports a document that cannot be read in its format
+type InvalidDocumentError struct {
+	Format string
+	Err    error
+}
+
+func (e *InvalidDocumentError) Error() string {
+	return fmt.Sprintf("invalid %s document: %v", e.Format, e.Err)
+}
+
+func (e *InvalidDocumentError) Unwrap() error {
+	return e.Err
+}
+
+// Section is a part of a document: the text under a heading, or one page of a PDF
+// Title is the heading, which also starts Text; it is empty for text before the first heading
+type Section struct {
+	Title string
+	Page  int
+	Text  string
+}
+
+// extensions maps file extensions to formats
+var extensions = map[string]string{
+	".txt":      FormatText,
+	".text":     FormatText,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".html":     FormatHTML,
+	".htm":      FormatHTML,
+	".docx":     FormatDOCX,
+	".pdf":      FormatPDF,
+}
+
+// mediaTypes maps media types to formats, for uploads whose file name has no known extension
+var mediaTypes = map[string]string{
+	"text/plain":      FormatText,
+	"text/markdown":   FormatMarkdown,
+	"text/html":       FormatHTML,
+	"application/pdf": FormatPDF,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": FormatDOCX,
+}
+
+// Formats returns the supported formats
+func Formats() []string {
+	return []string{FormatText, FormatMarkdown, FormatHTML, FormatDOCX, FormatPDF}
+}
+
+// DetectFormat picks a document's format from its file name, falling back to its media type
+func DetectFormat(filename, contentType string) (string, bool) {
+	if format, ok := extensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return format, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	format, ok := mediaTypes[mediaType]
+	return format, ok
+}
+
+// Extract returns the sections of a document with non-empty text
+// The extracted text is capped at maxTextBytes, since compressed formats can hold far more text than their size
+func Extract(format string, data []byte, maxTextBytes int) ([]Section, error) {
+	b, err := extract(format, data, maxTextBytes)
+	if err != nil {
+		return nil, err
+	}
+	return b.finish(), nil
+}
+
+// Prose returns the text of a document with its markup removed, one line per paragraph,
+// and how much was left out for each reason
+func Prose(format string, data []byte) (string, []Exclusion, error) {
+	b, err := extract(format, data, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sections := b.finish()
+	texts := make([]string, len(sections))
+	for i, section := range sections {
+		texts[i] = section.Text
+	}
+
+	var exclusions []Exclusion
+	for _, reason := range reasons {
+		if characters := b.excluded[reason]; characters > 0 {
+			exclusions = append(exclusions, Exclusion{Reason: reason, Characters: characters})
+		}
+	}
+	return strings.Join(texts, "\n"), exclusions, nil
+}
+
+// extract runs the extractor of a format over a document
+func extract(format string, data []byte, maxTextBytes int) (*builder, error) {
+	b := &builder{maxBytes: maxTextBytes, excluded: make(map[string]int)}
+
+	var err error
+	switch format {
+	case FormatText:
+		err = extractText(b, data)
+	case FormatMarkdown:
+		err = extractMarkdown(b, data)
+	case FormatHTML:
+		err = extractHTML(b, data)
+	case FormatDOCX:
+		err = extractDOCX(b, data)
+	case FormatPDF:
+		err = extractPDF(b, data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		if errors.Is(err, ErrTextTooLarge) {
+			return nil, err
+		}
+		return nil, &InvalidDocumentError{Format: format, Err: err}
+	}
+	return b, nil
+}
+
+// builder collects the sections of a document as its text is extracted
+type builder struct {
+	maxBytes int
+	bytes    int
+	sections []Section
+	text     strings.Builder
+	excluded map[string]int
+}
+
+// exclude records content left out of the prose
+func (b *builder) exclude(reason, content string) {
+	for _, r := range content {
+		if !unicode.IsSpace(r) {
+			b.excluded[reason]++
+		}
+	}
+}
+
+// heading starts a new section with the given title
+func (b *builder) heading(title string) error {
+	b.flush()
+	title = strings.Join(strings.Fields(title), " ")
+	b.sections = append(b.sections, Section{Title: title})
+	return b.line(title)
+}
+
+// page starts the section of a new PDF page
+func (b *builder) page(number int) {
+	b.flush()
+	b.sections = append(b.sections, Section{Page: number})
+}
+
+// line adds a line of text to the current section; blank lines are dropped
+func (b *builder) line(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	b.bytes += len(text) + 1
+	if b.maxBytes > 0 && b.bytes > b.maxBytes {
+		return ErrTextTooLarge
+	}
+	if len(b.sections) == 0 {
+		b.sections = append(b.sections, Section{})
+	}
+	b.text.WriteString(text)
+	b.text.WriteByte('\n')
+	return nil
+}
+
+// flush stores the text collected for the current section
+func (b *builder) flush() {
+	if len(b.sections) > 0 {
+		b.sections[len(b.sections)-1].Text = strings.TrimSuffix(b.text.String(), "\n")
+	}
+	b.text.Reset()
+}
+
+// finish returns the sections that have text
+func (b *builder) finish() []Section {
+	b.flush()
+	sections := make([]Section, 0, len(b.sections))
+	for _, section := range b.sections {
+		if section.Text != "" {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,143 @@
+package extract
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		wantFormat  string
+		wantOK      bool
+	}{
+		{name: "text", filename: "notes.txt", wantFormat: FormatText, wantOK: true},
+		{name: "markdown", filename: "README.md", wantFormat: FormatMarkdown, wantOK: true},
+		{name: "upper case extension", filename: "PAGE.HTM", wantFormat: FormatHTML, wantOK: true},
+		{name: "docx", filename: "report.docx", wantFormat: FormatDOCX, wantOK: true},
+		{name: "pdf", filename: "paper.pdf", wantFormat: FormatPDF, wantOK: true},
+		{name: "extension wins over media type", filename: "page.html", contentType: "text/plain", wantFormat: FormatHTML, wantOK: true},
+		{name: "media type without extension", filename: "upload", contentType: "application/pdf", wantFormat: FormatPDF, wantOK: true},
+		{name: "media type with parameters", filename: "blob", contentType: "text/markdown; charset=utf-8", wantFormat: FormatMarkdown, wantOK: true},
+		{name: "unsupported extension", filename: "report.doc", contentType: "application/msword"},
+		{name: "unknown media type", filename: "blob", contentType: "application/octet-stream"},
+		{name: "nothing to go on", filename: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := DetectFormat(tt.filename, tt.contentType)
+			if format != tt.wantFormat || ok != tt.wantOK {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.wantFormat, tt.wantOK, format, ok)
+			}
+		})
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	sections, err := Extract(FormatText, []byte("The quick fox.\r\n\r\n  Jumps over the dog.  \n"), 0)
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+
+	want := []Section{{Text: "The quick fox.\nJumps over the dog."}}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("Expected sections %+v, got %+v", want, sections)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		data         string
+		maxTextBytes int
+		wantErr      error
+		wantInvalid  bool
+	}{
+		{name: "unsupported format", format: "doc", data: "text", wantErr: ErrUnsupportedFormat},
+		{name: "text too large", format: FormatText, data: "one line\nanother line", maxTextBytes: 12, wantErr: ErrTextTooLarge},
+		{name: "text within the limit", format: FormatText, data: "one line\nanother line", maxTextBytes: 22},
+		{name: "text not UTF-8", format: FormatText, data: "caf\xe9", wantInvalid: true},
+		{name: "corrupt docx", format: FormatDOCX, data: "not a zip archive", wantInvalid: true},
+		{name: "corrupt pdf", format: FormatPDF, data: "%PDF-1.4 truncated", wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Extract(tt.format, []byte(tt.data), tt.maxTextBytes)
+
+			var invalid *InvalidDocumentError
+			switch {
+			case tt.wantInvalid:
+				if !errors.As(err, &invalid) || invalid.Format != tt.format {
+					t.Errorf("Expected an invalid %s document error, got %v", tt.format, err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProse(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		data           string
+		wantText       string
+		wantExclusions []Exclusion
+	}{
+		{
+			name:     "plain text",
+			format:   FormatText,
+			data:     "Nothing <b>to</b> strip.",
+			wantText: "Nothing <b>to</b> strip.",
+		},
+		{
+			name:   "markdown",
+			format: FormatMarkdown,
+			data: "---\ntitle: Notes\n---\n# Notes\n\nSee [the docs](https://example.com) or run `make`.\n\n" +
+				"```sh\nmake test\n```\n<!-- draft -->",
+			wantText: "Notes\nSee the docs or run .",
+			wantExclusions: []Exclusion{
+				{Reason: ReasonFrontMatter, Characters: 17},
+				{Reason: ReasonCode, Characters: 22},
+				{Reason: ReasonLinkURL, Characters: 21},
+				{Reason: ReasonComment, Characters: 12},
+				{Reason: ReasonSyntax, Characters: 3},
+			},
+		},
+		{
+			name:   "html",
+			format: FormatHTML,
+			data: `<!DOCTYPE html><html><head><title>Page</title></head><body><nav>Home</nav>` +
+				`<p>Read <a href="/more">more</a>.</p><script>track()</script><pre>x := 1</pre></body></html>`,
+			wantText: "Read more.",
+			wantExclusions: []Exclusion{
+				{Reason: ReasonCode, Characters: 4},
+				{Reason: ReasonTag, Characters: 133},
+				{Reason: ReasonScript, Characters: 7},
+				{Reason: ReasonBoilerplate, Characters: 8},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, exclusions, err := Prose(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Prose returned error: %v", err)
+			}
+			if text != tt.wantText {
+				t.Errorf("Expected text %q, got %q", tt.wantText, text)
+			}
+			if !reflect.DeepEqual(exclusions, tt.wantExclusions) {
+				t.Errorf("Expected exclusions %+v, got %+v", tt.wantExclusions, exclusions)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package extract
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped holds the elements whose content is not part of a page's prose, with the reason it is left out
+var skipped = map[atom.Atom]string{
+	atom.Head:     ReasonBoilerplate,
+	atom.Title:    ReasonBoilerplate,
+	atom.Nav:      ReasonBoilerplate,
+	atom.Header:   ReasonBoilerplate,
+	atom.Footer:   ReasonBoilerplate,
+	atom.Aside:    ReasonBoilerplate,
+	atom.Form:     ReasonBoilerplate,
+	atom.Iframe:   ReasonBoilerplate,
+	atom.Button:   ReasonBoilerplate,
+	atom.Select:   ReasonBoilerplate,
+	atom.Script:   ReasonScript,
+	atom.Style:    ReasonScript,
+	atom.Noscript: ReasonScript,
+	atom.Template: ReasonScript,
+	atom.Svg:      ReasonScript,
+	atom.Pre:      ReasonCode,
+	atom.Code:     ReasonCode,
+}
+
+// void holds the elements that have no content or end tag
+var void = map[atom.Atom]bool{
+	atom.Area:   true,
+	atom.Base:   true,
+	atom.Br:     true,
+	atom.Col:    true,
+	atom.Embed:  true,
+	atom.Hr:     true,
+	atom.Img:    true,
+	atom.Input:  true,
+	atom.Link:   true,
+	atom.Meta:   true,
+	atom.Source: true,
+	atom.Track:  true,
+	atom.Wbr:    true,
+}
+
+// headings holds the elements that start a section
+var headings = map[atom.Atom]bool{
+	atom.H1: true,
+	atom.H2: true,
+	atom.H3: true,
+	atom.H4: true,
+	atom.H5: true,
+	atom.H6: true,
+}
+
+// inline holds the elements that do not break a line of text; any other element, br included, does
+var inline = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.Abbr:   true,
+	atom.B:      true,
+	atom.Bdi:    true,
+	atom.Bdo:    true,
+	atom.Cite:   true,
+	atom.Data:   true,
+	atom.Del:    true,
+	atom.Dfn:    true,
+	atom.Em:     true,
+	atom.I:      true,
+	atom.Img:    true,
+	atom.Ins:    true,
+	atom.Kbd:    true,
+	atom.Label:  true,
+	atom.Mark:   true,
+	atom.Q:      true,
+	atom.S:      true,
+	atom.Samp:   true,
+	atom.Small:  true,
+	atom.Span:   true,
+	atom.Strong: true,
+	atom.Sub:    true,
+	atom.Sup:    true,
+	atom.Time:   true,
+	atom.U:      true,
+	atom.Var:    true,
+	atom.Wbr:    true,
+}
+
+// extractHTML splits an HTML document into sections at its h1 to h6 headings, dropping tags, comments,
+// scripts, styles, code and boilerplate such as navigation, headers and footers
+// A page with a main element only has prose inside it
+func extractHTML(b *builder, data []byte) error {
+	hasMain, err := hasElement(data, atom.Main)
+	if err != nil {
+		return err
+	}
+
+	var (
+		text      strings.Builder
+		heading   strings.Builder
+		inHeading atom.Atom
+		// skip holds the open elements whose content is left out, outermost first
+		skip   []openElement
+		inMain int
+	)
+	breakLine := func() error {
+		line := strings.Join(strings.Fields(text.String()), " ")
+		text.Reset()
+		return b.line(line)
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tokenType := z.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return breakLine()
+			}
+			return z.Err()
+		case html.CommentToken:
+			b.exclude(ReasonComment, string(z.Raw()))
+			continue
+		case html.DoctypeToken:
+			b.exclude(ReasonTag, string(z.Raw()))
+			continue
+		case html.TextToken:
+			content := string(z.Text())
+			switch {
+			case len(skip) > 0:
+				b.exclude(skip[0].reason, content)
+			case hasMain && inMain == 0:
+				b.exclude(ReasonBoilerplate, content)
+			case inHeading != 0:
+				heading.WriteString(content)
+			default:
+				text.WriteString(content)
+			}
+			continue
+		}
+
+		// The token is a tag
+		b.exclude(ReasonTag, string(z.Raw()))
+		name, hasAttr := z.TagName()
+		a := atom.Lookup(name)
+		start := tokenType == html.StartTagToken
+		if tokenType == html.SelfClosingTagToken || void[a] {
+			// Void elements have no content to skip, but still break lines
+			if !inline[a] && len(skip) == 0 && inHeading == 0 {
+				if err := breakLine(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if n := len(skip); n > 0 {
+			top := &skip[n-1]
+			switch {
+			case start && a == top.tag:
+				top.depth++
+			case !start && a == top.tag:
+				if top.depth--; top.depth == 0 {
+					skip = skip[:n-1]
+				}
+			case start && (skipped[a] != "" || hasAttr && hidden(z)):
+				skip = append(skip, openElement{tag: a, depth: 1, reason: skip[0].reason})
+			}
+			continue
+		}
+		if start {
+			if reason := skipped[a]; reason != "" {
+				skip = append(skip, openElement{tag: a, depth: 1, reason: reason})
+				continue
+			}
+			if hasAttr && hidden(z) {
+				skip = append(skip, openElement{tag: a, depth: 1, reason: ReasonBoilerplate})
+				continue
+			}
+		}
+
+		switch {
+		case a == atom.Main:
+			if start {
+				inMain++
+			} else if inMain > 0 {
+				inMain--
+			}
+		case headings[a] && start && inHeading == 0:
+			if err := breakLine(); err != nil {
+				return err
+			}
+			inHeading = a
+			heading.Reset()
+			continue
+		case a == inHeading && !start:
+			inHeading = 0
+			if hasMain && inMain == 0 {
+				b.exclude(ReasonBoilerplate, heading.String())
+				continue
+			}
+			if err := b.heading(heading.String()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !inline[a] && inHeading == 0 {
+			if err := breakLine(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// openElement is an element whose content is being left out
+// depth counts the nested elements of the same kind, so the right end tag closes it
+type openElement struct {
+	tag    atom.Atom
+	depth  int
+	reason string
+}
+
+// hasElement reports whether an HTML document has an element of the given kind
+func hasElement(data []byte, a atom.Atom) (bool, error) {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return false, nil
+			}
+			return false, z.Err()
+		case html.StartTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == a {
+				return true, nil
+			}
+		}
+	}
+}
+
+// hidden reports whether the tag being read marks its element as not rendered
+// It reads the tag's attributes, so it must be called at most once per tag
+func hidden(z *html.Tokenizer) bool {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == "hidden" || string(key) == "aria-hidden" && string(val) == "true" {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []Section
+	}{
+		{
+			name: "sections by heading",
+			html: `<html><head><title>Page</title><style>p { color: red }</style></head><body>
+				<p>Intro <em>text</em>.</p>
+				<h1>First <small>part</small></h1><p>Body one.</p><p>Line<br>break.</p>
+				<h2>Second</h2><ul><li>Item one</li><li>Item two</li></ul>
+			</body></html>`,
+			want: []Section{
+				{Text: "Intro text."},
+				{Title: "First part", Text: "First part\nBody one.\nLine\nbreak."},
+				{Title: "Second", Text: "Second\nItem one\nItem two"},
+			},
+		},
+		{
+			name: "boilerplate dropped",
+			html: `<body><header>Site name</header><nav><a href="/">Home</a></nav>
+				<script>var x = "hidden";</script><p>Content.</p><div hidden>Secret</div>
+				<aside>Related</aside><footer>Copyright</footer></body>`,
+			want: []Section{{Text: "Content."}},
+		},
+		{
+			name: "only the main element",
+			html: `<body><div>Sidebar links</div><main><h1>Article</h1><p>Story.</p></main><div>Comments</div></body>`,
+			want: []Section{{Title: "Article", Text: "Article\nStory."}},
+		},
+		{
+			name: "entities",
+			html: `<p>Fish &amp; chips &mdash; caf&eacute;</p>`,
+			want: []Section{{Text: "Fish & chips — café"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := Extract(FormatHTML, []byte(tt.html), 0)
+			if err != nil {
+				t.Fatalf("Extract returned error: %v", err)
+			}
+			if !reflect.DeepEqual(sections, tt.want) {
+				t.Errorf("Expected sections %+v, got %+v", tt.want, sections)
+			}
+		})
+	}
+}
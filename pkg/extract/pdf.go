@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF splits a PDF document into a section per page
+// Only text drawn with fonts is found, so scanned pages have none
+func extractPDF(b *builder, data []byte) (err error) {
+	// The PDF reader panics on malformed content instead of returning errors
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+
+		b.page(i)
+		for _, line := range pageLines(page.Content().Text) {
+			if err := b.line(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pageLines joins the glyphs drawn on a page into lines of words, in the order they were drawn
+// A glyph starts a new line when it is off the previous glyph's baseline, and a new word
+// when it is drawn clearly after the previous glyph's end
+func pageLines(glyphs []pdf.Text) []string {
+	var (
+		lines    []string
+		line     strings.Builder
+		previous *pdf.Text
+	)
+	for i := range glyphs {
+		glyph := &glyphs[i]
+		// The reader marks the end of each text array with a line feed of its own
+		if glyph.S == "\n" {
+			continue
+		}
+
+		if previous != nil {
+			size := math.Max(glyph.FontSize, previous.FontSize)
+			// Fonts without widths give glyphs no width, so only a wide gap separates words
+			width := previous.W
+			if width == 0 {
+				width = previous.FontSize
+			}
+
+			switch {
+			case math.Abs(glyph.Y-previous.Y) > size/2:
+				lines = append(lines, line.String())
+				line.Reset()
+			case glyph.X-(previous.X+width) > size/5:
+				line.WriteByte(' ')
+			}
+		}
+		line.WriteString(glyph.S)
+		previous = glyph
+	}
+	return append(lines, line.String())
+}
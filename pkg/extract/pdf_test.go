@@ -0,0 +1,67 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newPDF builds a PDF whose pages draw the given content streams in a font whose glyphs are all 556 units wide
+func newPDF(pages ...string) []byte {
+	widths := strings.TrimSpace(strings.Repeat("556 ", 126-32+1))
+	var objects []string
+	kids := ""
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(pages)),
+		fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths),
+	)
+	for i, content := range pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	data := newPDF(
+		"BT /F1 12 Tf 72 720 Td (Hello world.) Tj 0 -16 Td (Second line) Tj 100 0 Td (continues.) Tj ET",
+		"",
+		"BT /F1 12 Tf 72 720 Td [(Last) -250 (page.)] TJ ET",
+	)
+
+	sections, err := Extract(FormatPDF, data, 0)
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+
+	// Words are told apart by the gaps between them, and the blank second page has no section
+	want := []Section{
+		{Page: 1, Text: "Hello world.\nSecond line continues."},
+		{Page: 3, Text: "Last page."},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("Expected sections %+v, got %+v", want, sections)
+	}
+}
@@ -0,0 +1,167 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// errNotUTF8 is returned for text documents in another encoding
+var errNotUTF8 = errors.New("text is not valid UTF-8")
+
+// extractText splits a plain text document into lines of a single section
+func extractText(b *builder, data []byte) error {
+	if !utf8.Valid(data) {
+		return errNotUTF8
+	}
+	return eachLine(data, b.line)
+}
+
+// Markdown syntax that is removed, keeping the text it marks up
+var (
+	frontMatter    = regexp.MustCompile(`^(?:---\r?\n(?:.*\r?\n)*?(?:---|\.\.\.)|\+\+\+\r?\n(?:.*\r?\n)*?\+\+\+)[ \t]*(?:\r?\n|$)`)
+	atxHeading     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextUnder    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fence          = regexp.MustCompile("^ {0,3}(```|~~~)")
+	thematicBreak  = regexp.MustCompile(`^ {0,3}([-*_])([ \t]*[-*_]){2,}[ \t]*$`)
+	linkDefinition = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s`)
+	tableDelimiter = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	blockMarker    = regexp.MustCompile(`^[ \t]*(?:>[ \t]?)*(?:(?:[-*+]|\d{1,9}[.)])[ \t]+)?(?:\[[ xX]\][ \t]+)?`)
+	inlineCode     = regexp.MustCompile("``[^`]+(?:`[^`]+)*``|`[^`]+`")
+	image          = regexp.MustCompile(`!\[([^\]]*)\](\([^)]*\))`)
+	link           = regexp.MustCompile(`\[([^\]]+)\](\([^)]*\)|\[[^\]]*\])`)
+	autolink       = regexp.MustCompile(`<(?:https?|mailto):[^>]+>`)
+	bareURL        = regexp.MustCompile(`https?://[^\s<>()]+`)
+	htmlComment    = regexp.MustCompile(`<!--.*?-->`)
+	htmlTag        = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	emphasis       = regexp.MustCompile("\\*{1,3}|~~|`+")
+	// Underscores only mark emphasis at the edge of a word, unlike in snake_case
+	underscores = regexp.MustCompile(`(^|[^\pL\pN_])_{1,3}|_{1,3}([^\pL\pN_]|$)`)
+)
+
+// extractMarkdown splits a Markdown document into sections at its headings, dropping front matter,
+// code, link URLs, HTML and thematic breaks and keeping the text of the rest of the markup
+func extractMarkdown(b *builder, data []byte) error {
+	if !utf8.Valid(data) {
+		return errNotUTF8
+	}
+	if m := frontMatter.Find(data); m != nil {
+		b.exclude(ReasonFrontMatter, string(m))
+		data = data[len(m):]
+	}
+
+	var (
+		inFence  string
+		previous string
+	)
+	// A paragraph line is held back until the next line shows whether it is a setext heading
+	flush := func() error {
+		line := previous
+		previous = ""
+		return b.line(line)
+	}
+
+	err := eachLine(data, func(line string) error {
+		if inFence != "" {
+			b.exclude(ReasonCode, line)
+			if strings.HasPrefix(strings.TrimSpace(line), inFence) {
+				inFence = ""
+			}
+			return nil
+		}
+		if m := fence.FindStringSubmatch(line); m != nil {
+			inFence = m[1]
+			b.exclude(ReasonCode, line)
+			return flush()
+		}
+
+		if previous != "" && setextUnder.MatchString(line) {
+			b.exclude(ReasonSyntax, line)
+			title := previous
+			previous = ""
+			return b.heading(title)
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		switch {
+		case thematicBreak.MatchString(line), tableDelimiter.MatchString(line):
+			b.exclude(ReasonSyntax, line)
+			return nil
+		case linkDefinition.MatchString(line):
+			b.exclude(ReasonLinkURL, line)
+			return nil
+		}
+		if m := atxHeading.FindStringSubmatchIndex(line); m != nil {
+			if m[4] < 0 {
+				b.exclude(ReasonSyntax, line)
+				return nil
+			}
+			b.exclude(ReasonSyntax, line[:m[4]]+line[m[5]:])
+			return b.heading(b.stripInline(line[m[4]:m[5]]))
+		}
+
+		marker := blockMarker.FindString(line)
+		b.exclude(ReasonSyntax, marker)
+		if text := b.stripInline(line[len(marker):]); text != "" {
+			previous = text
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// stripInline removes inline Markdown and HTML, keeping link and image text
+func (b *builder) stripInline(text string) string {
+	drop := func(reason string) func(string) string {
+		return func(m string) string {
+			b.exclude(reason, m)
+			return ""
+		}
+	}
+	// Link text is kept while its brackets are syntax and its destination a URL
+	keepText := func(re *regexp.Regexp, marker string) func(string) string {
+		return func(m string) string {
+			groups := re.FindStringSubmatch(m)
+			b.exclude(ReasonSyntax, marker)
+			b.exclude(ReasonLinkURL, groups[2])
+			return groups[1]
+		}
+	}
+
+	text = inlineCode.ReplaceAllStringFunc(text, drop(ReasonCode))
+	text = image.ReplaceAllStringFunc(text, keepText(image, "![]"))
+	text = link.ReplaceAllStringFunc(text, keepText(link, "[]"))
+	text = autolink.ReplaceAllStringFunc(text, drop(ReasonLinkURL))
+	text = bareURL.ReplaceAllStringFunc(text, drop(ReasonLinkURL))
+	text = htmlComment.ReplaceAllStringFunc(text, drop(ReasonComment))
+	text = htmlTag.ReplaceAllStringFunc(text, drop(ReasonTag))
+	text = emphasis.ReplaceAllStringFunc(text, drop(ReasonSyntax))
+	text = underscores.ReplaceAllStringFunc(text, func(m string) string {
+		b.exclude(ReasonSyntax, strings.Repeat("_", strings.Count(m, "_")))
+		return strings.ReplaceAll(m, "_", "")
+	})
+
+	// Table cells are separated like words
+	b.exclude(ReasonSyntax, strings.Repeat("|", strings.Count(text, "|")))
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "|", " ")), " ")
+}
+
+// eachLine calls fn with every line of data, without its line ending
+func eachLine(data []byte, fn func(line string) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if err := fn(strings.TrimSuffix(scanner.Text(), "\r")); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
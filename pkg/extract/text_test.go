@@ -0,0 +1,74 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []Section
+	}{
+		{
+			name:     "sections by heading",
+			markdown: "Intro text.\n\n# First\n\nBody one.\n\n## Second ##\nBody two.\n",
+			want: []Section{
+				{Text: "Intro text."},
+				{Title: "First", Text: "First\nBody one."},
+				{Title: "Second", Text: "Second\nBody two."},
+			},
+		},
+		{
+			name:     "setext headings",
+			markdown: "Title\n=====\nText.\n\nPart\n----\nMore.",
+			want: []Section{
+				{Title: "Title", Text: "Title\nText."},
+				{Title: "Part", Text: "Part\nMore."},
+			},
+		},
+		{
+			name:     "inline markup",
+			markdown: "Some **bold**, _em_ and `code` with a [link](http://example.com) and ![an image](x.png) in snake_case.",
+			want:     []Section{{Text: "Some bold, em and with a link and an image in snake_case."}},
+		},
+		{
+			name:     "lists and quotes",
+			markdown: "- one\n* two\n1. three\n> quoted\n- [x] done",
+			want:     []Section{{Text: "one\ntwo\nthree\nquoted\ndone"}},
+		},
+		{
+			name:     "code, rules and link definitions dropped",
+			markdown: "Before.\n\n```go\nfunc main() {}\n```\n\n***\n\n[ref]: http://example.com\nAfter.",
+			want:     []Section{{Text: "Before.\nAfter."}},
+		},
+		{
+			name:     "tables",
+			markdown: "| Name | Age |\n|------|----:|\n| Ann  | 30  |",
+			want:     []Section{{Text: "Name Age\nAnn 30"}},
+		},
+		{
+			name:     "html",
+			markdown: "<p align=\"center\">Centered <b>text</b></p>",
+			want:     []Section{{Text: "Centered text"}},
+		},
+		{
+			name:     "empty headings dropped",
+			markdown: "#\n\n# Only a heading",
+			want:     []Section{{Title: "Only a heading", Text: "Only a heading"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := Extract(FormatMarkdown, []byte(tt.markdown), 0)
+			if err != nil {
+				t.Fatalf("Extract returned error: %v", err)
+			}
+			if !reflect.DeepEqual(sections, tt.want) {
+				t.Errorf("Expected sections %+v, got %+v", tt.want, sections)
+			}
+		})
+	}
+}
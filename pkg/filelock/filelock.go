@@ -0,0 +1,20 @@
+// Package filelock serializes changes to files that several processes share, such as replicas
+// mounting the same volume
+package filelock
+
+import "os"
+
+// Lock opens the lock file at path, creating it if needed, and blocks until the caller holds it exclusively
+// The returned function releases the lock
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// lock does nothing where flock is not available, so callers are only serialized within one process
+func lock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	// A second holder, as another replica would be, waits for the first to release the lock
+	acquired := make(chan func())
+	go func() {
+		second, err := Lock(path)
+		if err != nil {
+			t.Errorf("Lock() error = %v", err)
+			close(acquired)
+			return
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second Lock() to wait while the lock is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case second := <-acquired:
+		if second != nil {
+			second()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second Lock() to succeed once the lock was released")
+	}
+}
@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckTimeout bounds how long a single check may take
+const CheckTimeout = 2 * time.Second
+
+// ErrDraining is reported by the draining check once shutdown has started
+var ErrDraining = errors.New("server is shutting down")
+
+// Check reports whether a dependency is usable; a nil error means healthy
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of a single named check
+// The report is public, so why a check failed is only logged, never returned
+type CheckResult struct {
+	Status string `json:"status"`
+}
+
+// Report is the response body of the liveness and readiness endpoints
+type Report struct {
+	Status  string                 `json:"status"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+	Version version.Info           `json:"version"`
+}
+
+// OK reports whether every check in the report passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+var (
+	checksMu sync.RWMutex
+	checks   = map[string]Check{"draining": checkDraining}
+
+	draining atomic.Bool
+)
+
+// Register adds a named readiness check, replacing any check with the same name
+func Register(name string, check Check) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks[name] = check
+}
+
+// Unregister removes a named readiness check
+func Unregister(name string) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	delete(checks, name)
+}
+
+// Names returns the names of the registered checks in sorted order
+func Names() []string {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDraining marks the server as shutting down (or not), which fails readiness
+func SetDraining(value bool) {
+	draining.Store(value)
+}
+
+// Draining reports whether the server is shutting down
+func Draining() bool {
+	return draining.Load()
+}
+
+func checkDraining(context.Context) error {
+	if Draining() {
+		return ErrDraining
+	}
+	return nil
+}
+
+// Live returns the liveness report, which only confirms the process is serving requests
+func Live() Report {
+	return Report{Status: StatusOK, Version: version.Get()}
+}
+
+// Ready runs every registered check concurrently and returns the readiness report
+func Ready(ctx context.Context) Report {
+	checksMu.RLock()
+	snapshot := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		snapshot[name] = check
+	}
+	checksMu.RUnlock()
+
+	report := Report{
+		Status:  StatusOK,
+		Checks:  make(map[string]CheckResult, len(snapshot)),
+		Version: version.Get(),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range snapshot {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			result, err := run(ctx, check)
+			if err != nil {
+				slog.WarnContext(ctx, "readiness check failed", "check", name, "error", err,
+					"duration_ms", float64(time.Since(start).Microseconds())/1000)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run executes a single check with a timeout, returning its result and the error it failed with
+func run(ctx context.Context, check Check) (CheckResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		return CheckResult{Status: StatusFail}, err
+	}
+	return CheckResult{Status: StatusOK}, nil
+}
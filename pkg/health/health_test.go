@@ -0,0 +1,98 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/logging"
+)
+
+func TestReady(t *testing.T) {
+	defer Unregister("ok")
+	defer Unregister("broken")
+	defer SetDraining(false)
+
+	Register("ok", func(context.Context) error { return nil })
+
+	report := Ready(context.Background())
+	if !report.OK() {
+		t.Fatalf("Expected a passing report, got %+v", report)
+	}
+	if _, ok := report.Checks["draining"]; !ok {
+		t.Error("Expected the built-in draining check in the report")
+	}
+	if report.Version.GoVersion == "" {
+		t.Error("Expected version info in the report")
+	}
+
+	// A failing check fails the whole report; its error is logged rather than reported
+	var buf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json", "info"))
+	defer slog.SetDefault(original)
+
+	Register("broken", func(context.Context) error { return errors.New("unreachable") })
+	report = Ready(context.Background())
+	if report.OK() {
+		t.Error("Expected a failing report")
+	}
+	if result := report.Checks["broken"]; result != (CheckResult{Status: StatusFail}) {
+		t.Errorf("Expected broken check to fail without details, got %+v", result)
+	}
+	if logs := buf.String(); !strings.Contains(logs, `"check":"broken"`) || !strings.Contains(logs, "unreachable") {
+		t.Errorf("Expected the check's error to be logged, got %s", logs)
+	}
+	if result := report.Checks["ok"]; result.Status != StatusOK {
+		t.Errorf("Expected ok check to pass, got %+v", result)
+	}
+}
+
+func TestReadyDraining(t *testing.T) {
+	defer SetDraining(false)
+
+	SetDraining(true)
+	report := Ready(context.Background())
+
+	if report.OK() {
+		t.Error("Expected readiness to fail while draining")
+	}
+	if result := report.Checks["draining"]; result.Status != StatusFail {
+		t.Errorf("Expected draining check to fail, got %+v", result)
+	}
+
+	// Liveness is unaffected by draining
+	if !Live().OK() {
+		t.Error("Expected liveness to pass while draining")
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// A check that ignores its context is still cut off
+	result, err := run(ctx, func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	if result.Status != StatusFail || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a hanging check to fail with a deadline error, got %+v, %v", result, err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	defer Unregister("a_check")
+
+	Register("a_check", func(context.Context) error { return nil })
+	names := Names()
+
+	if len(names) < 2 || names[0] != "a_check" {
+		t.Errorf("Expected sorted names starting with a_check, got %v", names)
+	}
+}
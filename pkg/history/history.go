@@ -0,0 +1,231 @@
+// Package history keeps each client's past analyses so they can look back at what they analyzed,
+// for as long as the client's retention allows
+package history
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// ErrEntryNotFound is returned when a history has no entry with the given ID
+var ErrEntryNotFound = errors.New("history entry not found")
+
+// previewLength is how many characters of an analyzed text its entry keeps
+const previewLength = 200
+
+// Log is the history of one client, oldest entry first
+type Log struct {
+	Owner string `json:"owner"`
+	// RetentionDays is the retention the client chose; the server's applies when it is nil
+	RetentionDays *int `json:"retention_days,omitempty"`
+	// LastID is the ID of the last entry recorded, so IDs are not reused after deletions
+	LastID  int64                 `json:"last_id"`
+	Entries []domain.HistoryEntry `json:"entries"`
+}
+
+// NewLog creates an empty history
+func NewLog(owner string) *Log {
+	return &Log{Owner: owner, Entries: []domain.HistoryEntry{}}
+}
+
+// Retention returns how many days entries are kept: the client's choice, capped by maxDays,
+// or maxDays when the client has not chosen
+func (l *Log) Retention(maxDays int) int {
+	if l.RetentionDays == nil {
+		return maxDays
+	}
+	return min(*l.RetentionDays, maxDays)
+}
+
+// Cutoff returns the creation time of the oldest entries still kept at now
+func (l *Log) Cutoff(now time.Time, maxDays int) time.Time {
+	return now.AddDate(0, 0, -l.Retention(maxDays))
+}
+
+// Add stamps an entry with the next ID and appends it, then drops the oldest entries beyond maxEntries
+func (l *Log) Add(entry domain.HistoryEntry, maxEntries int) domain.HistoryEntry {
+	l.LastID++
+	entry.ID = l.LastID
+	l.Entries = append(l.Entries, entry)
+	if extra := len(l.Entries) - maxEntries; extra > 0 {
+		l.Entries = append([]domain.HistoryEntry{}, l.Entries[extra:]...)
+	}
+	return entry
+}
+
+// Expire drops the entries created before cutoff and reports how many were dropped
+func (l *Log) Expire(cutoff time.Time) int {
+	kept := sort.Search(len(l.Entries), func(i int) bool {
+		return !l.Entries[i].CreatedAt.Before(cutoff)
+	})
+	if kept > 0 {
+		l.Entries = append([]domain.HistoryEntry{}, l.Entries[kept:]...)
+	}
+	return kept
+}
+
+// Remove drops the entry with the given ID
+func (l *Log) Remove(id int64) error {
+	for i, entry := range l.Entries {
+		if entry.ID == id {
+			l.Entries = append(l.Entries[:i:i], l.Entries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrEntryNotFound
+}
+
+// RemoveRange drops the entries created in [from, to) and reports how many were dropped
+// A zero from or to leaves that end of the range open
+func (l *Log) RemoveRange(from, to time.Time) int {
+	kept := make([]domain.HistoryEntry, 0, len(l.Entries))
+	for _, entry := range l.Entries {
+		if !inRange(entry.CreatedAt, from, to) {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(l.Entries) - len(kept)
+	l.Entries = kept
+	return removed
+}
+
+// Query selects a page of a history
+type Query struct {
+	// From and To keep the entries created in [From, To); a zero time leaves that end open
+	From, To time.Time
+	// Before keeps the entries with a lower ID, so a page continues where the previous one ended;
+	// zero starts from the newest entry
+	Before int64
+	// Limit is the most entries returned
+	Limit int
+}
+
+// Page returns the newest entries matching q, newest first, and whether older ones also match
+func (l *Log) Page(q Query) ([]domain.HistoryEntry, bool) {
+	entries := make([]domain.HistoryEntry, 0, q.Limit)
+	for i := len(l.Entries) - 1; i >= 0; i-- {
+		entry := l.Entries[i]
+		if (q.Before > 0 && entry.ID >= q.Before) || !inRange(entry.CreatedAt, q.From, q.To) {
+			continue
+		}
+		if len(entries) == q.Limit {
+			return entries, true
+		}
+		entries = append(entries, entry)
+	}
+	return entries, false
+}
+
+// inRange reports whether t lies in [from, to), either end being open when zero
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+// clone returns a deep copy of the history
+func (l *Log) clone() *Log {
+	copied := *l
+	if l.RetentionDays != nil {
+		days := *l.RetentionDays
+		copied.RetentionDays = &days
+	}
+	copied.Entries = append([]domain.HistoryEntry{}, l.Entries...)
+	return &copied
+}
+
+// Recorder records analyses in the history of the client making a request
+type Recorder struct {
+	owner      string
+	maxDays    int
+	maxEntries int
+}
+
+// NewRecorder creates a Recorder for the given client that keeps at most maxEntries entries
+// and none for longer than maxDays
+func NewRecorder(owner string, maxDays, maxEntries int) *Recorder {
+	return &Recorder{owner: owner, maxDays: maxDays, maxEntries: maxEntries}
+}
+
+// errNotKept aborts an update of a history whose client keeps none
+var errNotKept = errors.New("history not kept")
+
+// Record stamps an entry with the current time, shortens its preview and adds it to the client's history,
+// dropping the entries the client's retention no longer keeps
+// Nothing is recorded when the client chose to keep no history
+func (rec *Recorder) Record(entry domain.HistoryEntry) error {
+	now := time.Now().UTC()
+	entry.CreatedAt = now
+	entry.Preview = preview(entry.Preview)
+
+	_, err := GetStore().Update(rec.owner, func(l *Log) error {
+		if l.Retention(rec.maxDays) == 0 {
+			return errNotKept
+		}
+		l.Expire(l.Cutoff(now, rec.maxDays))
+		l.Add(entry, rec.maxEntries)
+		return nil
+	})
+	if errors.Is(err, errNotKept) {
+		return nil
+	}
+	return err
+}
+
+// preview returns the first previewLength characters of a text
+func preview(text string) string {
+	if utf8.RuneCountInString(text) <= previewLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:previewLength])
+}
+
+// Context key type to avoid collisions
+type contextKey string
+
+// recorderKey is the key used to store the Recorder in the context
+const recorderKey contextKey = "history_recorder"
+
+// WithRecorder adds the client's Recorder to the context
+func WithRecorder(ctx context.Context, rec *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey, rec)
+}
+
+// Record adds an entry to the history of the client whose Recorder is stored in the context
+// Requests without a Recorder in the context are not recorded
+func Record(ctx context.Context, entry domain.HistoryEntry) error {
+	rec, ok := ctx.Value(recorderKey).(*Recorder)
+	if !ok {
+		return nil
+	}
+	return rec.Record(entry)
+}
+
+// Prune drops the entries of every history that its client's retention, capped by maxDays, no longer keeps
+func Prune(now time.Time, maxDays int) error {
+	owners, err := GetStore().Owners()
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		log, err := GetStore().Get(owner)
+		if err != nil {
+			return err
+		}
+		cutoff := log.Cutoff(now, maxDays)
+		if len(log.Entries) == 0 || !log.Entries[0].CreatedAt.Before(cutoff) {
+			continue
+		}
+		if _, err := GetStore().Update(owner, func(l *Log) error {
+			l.Expire(l.Cutoff(now, maxDays))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
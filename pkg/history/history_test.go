@@ -0,0 +1,224 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// logAt returns a history with an entry created at each of the given times, oldest first
+func logAt(times ...time.Time) *Log {
+	l := NewLog("alice")
+	for _, t := range times {
+		l.Add(domain.HistoryEntry{CreatedAt: t}, len(times))
+	}
+	return l
+}
+
+// ids returns the IDs of entries
+func ids(entries []domain.HistoryEntry) []int64 {
+	list := []int64{}
+	for _, entry := range entries {
+		list = append(list, entry.ID)
+	}
+	return list
+}
+
+func TestLogRetention(t *testing.T) {
+	l := NewLog("alice")
+	if got := l.Retention(30); got != 30 {
+		t.Errorf("Retention() without a choice = %d, want 30", got)
+	}
+
+	days := 7
+	l.RetentionDays = &days
+	if got := l.Retention(30); got != 7 {
+		t.Errorf("Retention() = %d, want 7", got)
+	}
+	// Lowering the server's retention shortens every client's
+	if got := l.Retention(3); got != 3 {
+		t.Errorf("Retention() above the maximum = %d, want 3", got)
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	if got, want := l.Cutoff(now, 30), time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Cutoff() = %v, want %v", got, want)
+	}
+}
+
+func TestLogAdd(t *testing.T) {
+	l := NewLog("alice")
+	for i := 0; i < 4; i++ {
+		l.Add(domain.HistoryEntry{}, 3)
+	}
+	if got := ids(l.Entries); !reflect.DeepEqual(got, []int64{2, 3, 4}) {
+		t.Errorf("Expected the oldest entry to be dropped, got IDs %v", got)
+	}
+
+	// IDs are not reused once entries are removed
+	if err := l.Remove(4); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if entry := l.Add(domain.HistoryEntry{}, 3); entry.ID != 5 {
+		t.Errorf("Add() after Remove() ID = %d, want 5", entry.ID)
+	}
+	if err := l.Remove(4); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Remove() twice error = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestLogExpireAndRemoveRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+
+	l := logAt(day(1), day(2), day(3), day(4))
+	if removed := l.Expire(day(3)); removed != 2 {
+		t.Errorf("Expire() = %d, want 2", removed)
+	}
+	if got := ids(l.Entries); !reflect.DeepEqual(got, []int64{3, 4}) {
+		t.Errorf("Expected entries from day 3 to be kept, got IDs %v", got)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []int64
+	}{
+		{name: "everything", want: []int64{}},
+		{name: "from", from: day(3), want: []int64{1, 2}},
+		{name: "to", to: day(3), want: []int64{3, 4}},
+		{name: "between", from: day(2), to: day(4), want: []int64{1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := logAt(day(1), day(2), day(3), day(4))
+			if removed := l.RemoveRange(tt.from, tt.to); removed != 4-len(tt.want) {
+				t.Errorf("RemoveRange() = %d, want %d", removed, 4-len(tt.want))
+			}
+			if got := ids(l.Entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected IDs %v to be kept, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLogPage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	l := logAt(day(1), day(2), day(3), day(4), day(5))
+
+	tests := []struct {
+		name     string
+		query    Query
+		want     []int64
+		wantMore bool
+	}{
+		{name: "newest first", query: Query{Limit: 10}, want: []int64{5, 4, 3, 2, 1}},
+		{name: "first page", query: Query{Limit: 2}, want: []int64{5, 4}, wantMore: true},
+		{name: "next page", query: Query{Before: 4, Limit: 2}, want: []int64{3, 2}, wantMore: true},
+		{name: "last page", query: Query{Before: 2, Limit: 2}, want: []int64{1}},
+		{name: "exactly filled", query: Query{Before: 3, Limit: 2}, want: []int64{2, 1}},
+		{name: "date range", query: Query{From: day(2), To: day(4), Limit: 10}, want: []int64{3, 2}},
+		{name: "date range paged", query: Query{From: day(2), Limit: 1}, want: []int64{5}, wantMore: true},
+		{name: "nothing in range", query: Query{From: day(6), Limit: 10}, want: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, more := l.Page(tt.query)
+			if got := ids(entries); !reflect.DeepEqual(got, tt.want) || more != tt.wantMore {
+				t.Errorf("Page() = %v, %v, want %v, %v", got, more, tt.want, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	original := GetStore()
+	defer SetStore(original)
+	SetStore(NewMemoryStore())
+
+	// Requests without a Recorder are not recorded
+	if err := Record(context.Background(), domain.HistoryEntry{Preview: "Lost."}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if owners, _ := GetStore().Owners(); len(owners) != 0 {
+		t.Errorf("Expected nothing recorded without a Recorder, got owners %v", owners)
+	}
+
+	ctx := WithRecorder(context.Background(), NewRecorder("alice", 30, 2))
+	long := strings.Repeat("a", previewLength+10)
+	for _, text := range []string{"One.", "Two.", long} {
+		if err := Record(ctx, domain.HistoryEntry{Endpoint: "/v1/analyze", Preview: text, CharacterCount: len(text)}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	l, _ := GetStore().Get("alice")
+	if got := ids(l.Entries); !reflect.DeepEqual(got, []int64{2, 3}) {
+		t.Fatalf("Expected the newest 2 entries to be kept, got IDs %v", got)
+	}
+	last := l.Entries[1]
+	if len(last.Preview) != previewLength || last.CharacterCount != previewLength+10 || last.CreatedAt.IsZero() {
+		t.Errorf("Expected a stamped entry with a shortened preview, got %+v", last)
+	}
+
+	// Expired entries are dropped, and nothing is kept once the client chooses no retention
+	if _, err := GetStore().Update("alice", func(l *Log) error {
+		l.Entries[0].CreatedAt = time.Now().AddDate(0, 0, -31)
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := Record(ctx, domain.HistoryEntry{Preview: "Four."}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if l, _ := GetStore().Get("alice"); !reflect.DeepEqual(ids(l.Entries), []int64{3, 4}) {
+		t.Errorf("Expected the expired entry to be dropped, got IDs %v", ids(l.Entries))
+	}
+
+	none := 0
+	if _, err := GetStore().Update("alice", func(l *Log) error {
+		l.RetentionDays = &none
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := Record(ctx, domain.HistoryEntry{Preview: "Five."}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if l, _ := GetStore().Get("alice"); l.LastID != 4 {
+		t.Errorf("Expected nothing recorded without retention, got last ID %d", l.LastID)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	original := GetStore()
+	defer SetStore(original)
+	SetStore(NewMemoryStore())
+
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	week := 7
+	for owner, retention := range map[string]*int{"alice": nil, "bob": &week} {
+		if _, err := GetStore().Update(owner, func(l *Log) error {
+			l.RetentionDays = retention
+			l.Add(domain.HistoryEntry{CreatedAt: now.AddDate(0, 0, -20)}, 10)
+			l.Add(domain.HistoryEntry{CreatedAt: now.AddDate(0, 0, -1)}, 10)
+			return nil
+		}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	if err := Prune(now, 30); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if l, _ := GetStore().Get("alice"); len(l.Entries) != 2 {
+		t.Errorf("Expected alice's 2 entries to be kept for 30 days, got %d", len(l.Entries))
+	}
+	if l, _ := GetStore().Get("bob"); len(l.Entries) != 1 {
+		t.Errorf("Expected bob's older entry to expire after 7 days, got %d entries", len(l.Entries))
+	}
+}
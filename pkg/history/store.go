@@ -0,0 +1,235 @@
+package history
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/filelock"
+)
+
+// Store persists histories, each identified by its owner
+type Store interface {
+	// Get returns an owner's history, empty when nothing was recorded
+	Get(owner string) (*Log, error)
+	// Update applies fn to an owner's history and saves the result unless fn fails;
+	// updates are applied one at a time, so fn sees every earlier update
+	Update(owner string, fn func(l *Log) error) (*Log, error)
+	// Owners lists the owners with a saved history
+	Owners() ([]string, error)
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu   sync.RWMutex
+	logs map[string]*Log
+}
+
+// NewMemoryStore creates an empty in-memory history store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{logs: make(map[string]*Log)}
+}
+
+// Get returns a copy of an owner's history
+func (s *MemoryStore) Get(owner string) (*Log, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.logs[owner]
+	if !ok {
+		return NewLog(owner), nil
+	}
+	return l.clone(), nil
+}
+
+// Update applies fn to a copy of an owner's history and stores the result unless fn fails
+func (s *MemoryStore) Update(owner string, fn func(l *Log) error) (*Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := NewLog(owner)
+	if existing, ok := s.logs[owner]; ok {
+		l = existing.clone()
+	}
+	if err := fn(l); err != nil {
+		return nil, err
+	}
+	s.logs[owner] = l
+	return l.clone(), nil
+}
+
+// Owners lists the owners with a stored history, in order
+func (s *MemoryStore) Owners() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	owners := make([]string, 0, len(s.logs))
+	for owner := range s.logs {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
+
+// FileStore is a Store that keeps each owner's history in a JSON file under a directory,
+// so histories survive restarts without a database
+// Files are replaced atomically and updates hold a lock file, so replicas may share a directory
+type FileStore struct {
+	dir string
+	// mu serializes updates within this process, where the lock file may not be enforced;
+	// reads need no lock as files are replaced by renaming
+	mu sync.Mutex
+}
+
+// NewFileStore creates a file-backed history store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// lock holds the lock file of the directory until the returned function is called,
+// so an update from another replica cannot be lost between reading a file and replacing it
+func (s *FileStore) lock() (func(), error) {
+	return filelock.Lock(filepath.Join(s.dir, ".lock"))
+}
+
+// fileSuffix ends the name of every history file
+const fileSuffix = ".json"
+
+// path returns the file of an owner's history
+// Owners are user IDs, so they are encoded to be safe as file names
+func (s *FileStore) path(owner string) string {
+	return filepath.Join(s.dir, "owner-"+base64.RawURLEncoding.EncodeToString([]byte(owner))+fileSuffix)
+}
+
+// Get reads an owner's history
+func (s *FileStore) Get(owner string) (*Log, error) {
+	return s.read(owner)
+}
+
+// Update applies fn to an owner's history read from its file and writes the result unless fn fails
+func (s *FileStore) Update(owner string, fn func(l *Log) error) (*Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	l, err := s.read(owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(l); err != nil {
+		return nil, err
+	}
+	if err := s.write(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Owners lists the owners with a history file, ordered by file name
+func (s *FileStore) Owners() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		encoded, ok := strings.CutPrefix(entry.Name(), "owner-")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		encoded, ok = strings.CutSuffix(encoded, fileSuffix)
+		if !ok {
+			continue
+		}
+		owner, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		owners = append(owners, string(owner))
+	}
+	return owners, nil
+}
+
+// read decodes an owner's history from its file, or returns an empty one when there is no file
+func (s *FileStore) read(owner string) (*Log, error) {
+	data, err := os.ReadFile(s.path(owner))
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewLog(owner), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewLog(owner)
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// write replaces the file of a history by writing a temporary file next to it and renaming it into place,
+// so a crash never leaves a partly written history
+func (s *FileStore) write(l *Log) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(l.Owner))
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store = NewMemoryStore()
+)
+
+// SetStore replaces the store used for histories
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+// GetStore returns the store used for histories
+func GetStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// CheckStore verifies that the history store can be queried
+func CheckStore() error {
+	_, err := GetStore().Owners()
+	return err
+}
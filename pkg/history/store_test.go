@@ -0,0 +1,150 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/domain"
+)
+
+// newStores returns each Store implementation, empty
+func newStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "history"))
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	return map[string]Store{"memory": NewMemoryStore(), "file": fileStore}
+}
+
+// addEntry returns an update that adds an entry previewing text
+func addEntry(text string) func(l *Log) error {
+	return func(l *Log) error {
+		l.Add(domain.HistoryEntry{CreatedAt: time.Now().UTC(), Preview: text}, 10)
+		return nil
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			l, err := store.Get("alice")
+			if err != nil || l.Owner != "alice" || len(l.Entries) != 0 {
+				t.Fatalf("Get() before any update = %+v, %v, want an empty history", l, err)
+			}
+			if owners, err := store.Owners(); err != nil || len(owners) != 0 {
+				t.Errorf("Owners() = %v, %v, want none", owners, err)
+			}
+
+			if _, err := store.Update("alice", addEntry("One.")); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			l, err = store.Update("alice", addEntry("Two."))
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if len(l.Entries) != 2 || l.Entries[1].ID != 2 {
+				t.Errorf("Update() = %+v, want two entries", l)
+			}
+
+			// A failed update leaves the history unchanged
+			failure := errors.New("failed")
+			if _, err := store.Update("alice", func(l *Log) error {
+				l.Add(domain.HistoryEntry{Preview: "Three."}, 10)
+				return failure
+			}); !errors.Is(err, failure) {
+				t.Errorf("Update() error = %v, want %v", err, failure)
+			}
+			got, err := store.Get("alice")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Owner != "alice" || got.LastID != 2 || len(got.Entries) != 2 || got.Entries[0].Preview != "One." {
+				t.Errorf("Get() = %+v", got)
+			}
+
+			// Histories are scoped to their owner
+			if _, err := store.Update("user/1", addEntry("Four.")); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if l, _ := store.Get("bob"); len(l.Entries) != 0 {
+				t.Errorf("Get() for another owner = %+v, want an empty history", l)
+			}
+			owners, err := store.Owners()
+			if err != nil {
+				t.Fatalf("Owners() error = %v", err)
+			}
+			if len(owners) != 2 {
+				t.Errorf("Owners() = %v, want alice and user/1", owners)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReturnsCopies(t *testing.T) {
+	store := NewMemoryStore()
+	if _, err := store.Update("alice", func(l *Log) error {
+		days := 7
+		l.RetentionDays = &days
+		return addEntry("One.")(l)
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	l, _ := store.Get("alice")
+	l.Entries[0].Preview = "changed"
+	*l.RetentionDays = 1
+	if got, _ := store.Get("alice"); got.Entries[0].Preview != "One." || *got.RetentionDays != 7 {
+		t.Errorf("Changing a returned history changed the store: %+v", got)
+	}
+}
+
+func TestFileStorePersists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	if _, err := store.Update("user/1", addEntry("One.")); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// A new store over the same directory, as after a restart, sees the history
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	l, err := reopened.Get("user/1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(l.Entries) != 1 || l.Entries[0].Preview != "One." {
+		t.Errorf("Get() = %+v", l)
+	}
+	if owners, _ := reopened.Owners(); !reflect.DeepEqual(owners, []string{"user/1"}) {
+		t.Errorf("Owners() = %v, want [user/1]", owners)
+	}
+
+	// Only the history file is left beside the lock file, its name encoding the owner
+	files, _ := filepath.Glob(filepath.Join(dir, "owner-*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "owner-dXNlci8x.json" {
+		t.Errorf("Expected a single owner-dXNlci8x.json, got %v", files)
+	}
+	if temps, _ := filepath.Glob(filepath.Join(dir, "tmp-*")); len(temps) != 0 {
+		t.Errorf("Expected no temporary files to be left, got %v", temps)
+	}
+}
+
+func TestCheckStore(t *testing.T) {
+	original := GetStore()
+	defer SetStore(original)
+
+	SetStore(NewMemoryStore())
+	if err := CheckStore(); err != nil {
+		t.Errorf("CheckStore() error = %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+// Package jobs keeps long-running analyses and the events they publish so that
+// clients can follow their progress and resume after reconnecting
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Job errors
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrTooManyJobs = errors.New("too many jobs")
+)
+
+// Limits bounds the jobs each client may have; a zero limit is not enforced
+type Limits struct {
+	// Running is the most jobs a client may have running at once
+	Running int
+	// Retained is the most jobs, running or finished, kept for a client until they are pruned
+	Retained int
+}
+
+// Event is one step of a job; IDs start at 1 and increase by one per event
+type Event struct {
+	ID   int64
+	Type string
+	Data interface{}
+}
+
+// Job is a long-running analysis owned by the client that started it
+// Events are kept until the job is pruned so a client can replay them after reconnecting
+type Job struct {
+	ID        string
+	Owner     string
+	CreatedAt time.Time
+
+	mu         sync.Mutex
+	events     []Event
+	finishedAt time.Time
+	changed    chan struct{}
+}
+
+// New creates a running job owned by owner
+func New(owner string) (*Job, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	return &Job{
+		ID:        hex.EncodeToString(idBytes),
+		Owner:     owner,
+		CreatedAt: time.Now().UTC(),
+		changed:   make(chan struct{}),
+	}, nil
+}
+
+// Publish appends an event and wakes every client waiting for one
+// Events published after Finish are dropped
+func (j *Job) Publish(eventType string, data interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.finishedAt.IsZero() {
+		return
+	}
+	j.publish(eventType, data)
+}
+
+// Finish publishes the job's last event and marks it finished
+func (j *Job) Finish(eventType string, data interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.finishedAt.IsZero() {
+		return
+	}
+	j.finishedAt = time.Now()
+	j.publish(eventType, data)
+}
+
+func (j *Job) publish(eventType string, data interface{}) {
+	j.events = append(j.events, Event{ID: int64(len(j.events)) + 1, Type: eventType, Data: data})
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// EventsAfter returns the events published after lastID and whether the job has finished
+// When it has not, changed is closed as soon as another event is published
+func (j *Job) EventsAfter(lastID int64) (events []Event, finished bool, changed <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if lastID < 0 {
+		lastID = 0
+	}
+	if lastID < int64(len(j.events)) {
+		events = append(events, j.events[lastID:]...)
+	}
+	return events, !j.finishedAt.IsZero(), j.changed
+}
+
+// Finished reports whether the job has finished
+func (j *Job) Finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return !j.finishedAt.IsZero()
+}
+
+// FinishedBefore reports whether the job finished before t
+func (j *Job) FinishedBefore(t time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return !j.finishedAt.IsZero() && j.finishedAt.Before(t)
+}
+
+// Store keeps jobs while they run and for a while after they finish
+type Store interface {
+	// Create stores a new job, or returns ErrTooManyJobs when its owner already has as many as limits allow
+	Create(job *Job, limits Limits) error
+	Get(id string) (*Job, error)
+	// Prune removes the jobs that finished before the given time
+	Prune(before time.Time) error
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore creates an empty in-memory job store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Create stores a new job unless its owner already has as many as limits allow
+func (s *MemoryStore) Create(job *Job, limits Limits) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	running, retained := 0, 0
+	for _, other := range s.jobs {
+		if other.Owner != job.Owner {
+			continue
+		}
+		retained++
+		if !other.Finished() {
+			running++
+		}
+	}
+	if (limits.Running > 0 && running >= limits.Running) || (limits.Retained > 0 && retained >= limits.Retained) {
+		return ErrTooManyJobs
+	}
+
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Get returns the job with the given ID
+func (s *MemoryStore) Get(id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Prune removes the jobs that finished before the given time
+func (s *MemoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		if job.FinishedBefore(before) {
+			delete(s.jobs, id)
+		}
+	}
+	return nil
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store = NewMemoryStore()
+)
+
+// SetStore replaces the store used for jobs
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+// GetStore returns the store used for jobs
+func GetStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// CheckStore verifies that the job store can be queried
+func CheckStore() error {
+	if _, err := GetStore().Get(""); err != nil && !errors.Is(err, ErrJobNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobEvents(t *testing.T) {
+	job, err := New("user:alice")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	events, finished, changed := job.EventsAfter(0)
+	if len(events) != 0 || finished {
+		t.Fatalf("Expected a new job to have no events, got %v (finished %v)", events, finished)
+	}
+
+	job.Publish("progress", 1)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected publishing to wake waiting clients")
+	}
+	job.Publish("progress", 2)
+	job.Finish("result", 3)
+	job.Publish("progress", 4)
+	job.Finish("result", 5)
+
+	tests := []struct {
+		name    string
+		lastID  int64
+		wantIDs []int64
+	}{
+		{name: "all events", lastID: 0, wantIDs: []int64{1, 2, 3}},
+		{name: "resume after an event", lastID: 1, wantIDs: []int64{2, 3}},
+		{name: "up to date", lastID: 3, wantIDs: nil},
+		{name: "unknown future ID", lastID: 10, wantIDs: nil},
+		{name: "negative ID", lastID: -1, wantIDs: []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, finished, _ := job.EventsAfter(tt.lastID)
+			if !finished {
+				t.Error("Expected the job to be finished")
+			}
+			if len(events) != len(tt.wantIDs) {
+				t.Fatalf("Expected events %v, got %v", tt.wantIDs, events)
+			}
+			for i, event := range events {
+				if event.ID != tt.wantIDs[i] {
+					t.Errorf("Expected event %d to have ID %d, got %d", i, tt.wantIDs[i], event.ID)
+				}
+			}
+		})
+	}
+
+	events, _, _ = job.EventsAfter(2)
+	if events[0].Type != "result" || events[0].Data != 3 {
+		t.Errorf("Expected the first final event to be kept, got %+v", events[0])
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	running, _ := New("user:alice")
+	finished, _ := New("user:alice")
+	finished.Finish("result", nil)
+	for _, job := range []*Job{running, finished} {
+		if err := store.Create(job, Limits{}); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+
+	if got, err := store.Get(finished.ID); err != nil || got != finished {
+		t.Fatalf("Expected to get the finished job, got %v, %v", got, err)
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	// Running jobs are kept however old they are
+	if err := store.Prune(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if _, err := store.Get(finished.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected the finished job to be pruned, got %v", err)
+	}
+	if _, err := store.Get(running.ID); err != nil {
+		t.Errorf("Expected the running job to be kept, got %v", err)
+	}
+
+	if err := CheckStore(); err != nil {
+		t.Errorf("CheckStore returned error: %v", err)
+	}
+}
+
+func TestMemoryStoreLimits(t *testing.T) {
+	store := NewMemoryStore()
+	limits := Limits{Running: 1, Retained: 2}
+
+	first, _ := New("user:alice")
+	if err := store.Create(first, limits); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// A second running job is refused until the first finishes
+	second, _ := New("user:alice")
+	if err := store.Create(second, limits); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("Expected ErrTooManyJobs while a job runs, got %v", err)
+	}
+	first.Finish("result", nil)
+	if err := store.Create(second, limits); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	second.Finish("result", nil)
+
+	// Finished jobs count against the retained limit until they are pruned
+	third, _ := New("user:alice")
+	if err := store.Create(third, limits); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("Expected ErrTooManyJobs with 2 jobs kept, got %v", err)
+	}
+	if err := store.Prune(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if err := store.Create(third, limits); err != nil {
+		t.Errorf("Expected a job to be accepted once the others were pruned, got %v", err)
+	}
+
+	// Other clients have their own limits
+	other, _ := New("user:bob")
+	if err := store.Create(other, limits); err != nil {
+		t.Errorf("Expected another client's job to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"unknown", slog.LevelInfo},
+		{"", slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLevel(tt.level); got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestNewJSONLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json", "info")
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	logger.With("component", "test").InfoContext(ctx, "hello", "answer", 42)
+	logger.DebugContext(ctx, "filtered out")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log line, got %d: %s", len(lines), buf.String())
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Failed to unmarshal log line: %v", err)
+	}
+	if record["request_id"] != "req-42" {
+		t.Errorf("Expected request_id req-42, got %v", record["request_id"])
+	}
+	if record["component"] != "test" {
+		t.Errorf("Expected component test, got %v", record["component"])
+	}
+	if record["msg"] != "hello" {
+		t.Errorf("Expected msg hello, got %v", record["msg"])
+	}
+}
+
+func TestNewTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "text", "debug")
+
+	logger.Debug("debugging")
+
+	if !strings.Contains(buf.String(), "level=DEBUG") || !strings.Contains(buf.String(), "msg=debugging") {
+		t.Errorf("Expected a text debug line, got %q", buf.String())
+	}
+}
+
+func TestRequestIDFromEmptyContext(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("Expected empty request ID, got %q", id)
+	}
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+// Namespace prefixes every metric exported by the service
+const Namespace = "sentence_analyzer"
+
+// Registry holds all service metrics together with the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+// BuildInfo is always 1; its labels identify the running build
+var BuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: Namespace,
+	Name:      "build_info",
+	Help:      "Build information of the running binary; the value is always 1.",
+}, []string{"version", "commit", "build_time", "go_version"})
+
+// HTTP metrics, labelled by registered route pattern rather than raw path to bound cardinality
+var (
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	RequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served by route.",
+	}, []string{"route"})
+)
+
+// Authentication metrics
+var (
+	AuthFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "auth_failures_total",
+		Help:      "Total number of rejected authentication attempts by reason.",
+	}, []string{"reason"})
+
+	LoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "logins_total",
+		Help:      "Total number of login attempts by result (success, failure, locked).",
+	}, []string{"result"})
+)
+
+// Analysis metrics
+var (
+	AnalysesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "analyses_total",
+		Help:      "Total number of completed sentence analyses.",
+	})
+
+	CharactersProcessedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "characters_processed_total",
+		Help:      "Total number of characters analyzed.",
+	})
+
+	WordsProcessedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "words_processed_total",
+		Help:      "Total number of words counted.",
+	})
+
+	SentenceLength = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "sentence_length_characters",
+		Help:      "Distribution of analyzed input lengths in characters.",
+		Buckets:   prometheus.ExponentialBuckets(16, 4, 8),
+	})
+)
+
+// Live analysis metrics
+var (
+	LiveSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "live_sessions",
+		Help:      "Number of open live analysis WebSocket connections.",
+	})
+
+	LiveMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "live_messages_total",
+		Help:      "Total number of live analysis messages by type and result (ok, error).",
+	}, []string{"type", "result"})
+)
+
+// Analysis job metrics
+var (
+	JobsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "jobs_running",
+		Help:      "Number of long-running analysis jobs in progress.",
+	})
+
+	JobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "jobs_total",
+		Help:      "Total number of finished analysis jobs by final event (result, failed).",
+	}, []string{"result"})
+
+	EventStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "event_streams",
+		Help:      "Number of open job event streams.",
+	})
+)
+
+// Document upload metrics
+var DocumentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "documents_total",
+	Help:      "Total number of uploaded documents by format and result (ok, invalid, empty).",
+}, []string{"format", "result"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		BuildInfo,
+		RequestsTotal,
+		RequestDuration,
+		RequestsInFlight,
+		AuthFailuresTotal,
+		LoginsTotal,
+		AnalysesTotal,
+		CharactersProcessedTotal,
+		WordsProcessedTotal,
+		SentenceLength,
+		LiveSessions,
+		LiveMessagesTotal,
+		JobsRunning,
+		JobsTotal,
+		EventStreams,
+		DocumentsTotal,
+	)
+}
+
+// Auth failure reasons
+const (
+	ReasonNoToken       = "no_token"
+	ReasonExpiredToken  = "expired_token"
+	ReasonInvalidToken  = "invalid_token"
+	ReasonInvalidAPIKey = "invalid_api_key"
+	ReasonExpiredAPIKey = "expired_api_key"
+	ReasonRevokedAPIKey = "revoked_api_key"
+	ReasonForbidden     = "forbidden"
+	ReasonError         = "error"
+)
+
+// Login results
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginLocked  = "locked"
+)
+
+// ObserveAnalysis records a completed analysis of the given size
+func ObserveAnalysis(characters, words int) {
+	AnalysesTotal.Inc()
+	CharactersProcessedTotal.Add(float64(characters))
+	WordsProcessedTotal.Add(float64(words))
+	SentenceLength.Observe(float64(characters))
+}
+
+// SetBuildInfo publishes the build information as the build_info metric
+func SetBuildInfo(info version.Info) {
+	BuildInfo.Reset()
+	BuildInfo.WithLabelValues(info.Version, info.Commit, info.BuildTime, info.GoVersion).Set(1)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// HandleMetrics handles the metrics endpoint
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	Handler().ServeHTTP(w, r)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/version"
+)
+
+func TestObserveAnalysis(t *testing.T) {
+	before := testutil.ToFloat64(CharactersProcessedTotal)
+	beforeWords := testutil.ToFloat64(WordsProcessedTotal)
+
+	ObserveAnalysis(11, 2)
+
+	if got := testutil.ToFloat64(CharactersProcessedTotal) - before; got != 11 {
+		t.Errorf("Expected 11 characters to be recorded, got %v", got)
+	}
+	if got := testutil.ToFloat64(WordsProcessedTotal) - beforeWords; got != 2 {
+		t.Errorf("Expected 2 words to be recorded, got %v", got)
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	AuthFailuresTotal.WithLabelValues(ReasonExpiredToken).Inc()
+	LoginsTotal.WithLabelValues(LoginSuccess).Inc()
+
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	HandleMetrics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	for _, name := range []string{
+		"sentence_analyzer_auth_failures_total{reason=\"expired_token\"}",
+		"sentence_analyzer_logins_total{result=\"success\"}",
+		"sentence_analyzer_characters_processed_total",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, name) {
+			t.Errorf("Expected metrics output to contain %s", name)
+		}
+	}
+}
+
+func TestSetBuildInfo(t *testing.T) {
+	SetBuildInfo(version.Info{Version: "1.0.0", Commit: "old", BuildTime: "t", GoVersion: "go"})
+	SetBuildInfo(version.Info{Version: "1.2.3", Commit: "abc1234", BuildTime: "t", GoVersion: "go"})
+
+	// Only the latest build is reported
+	if got := testutil.CollectAndCount(BuildInfo); got != 1 {
+		t.Errorf("Expected 1 build_info series, got %d", got)
+	}
+	if got := testutil.ToFloat64(BuildInfo.WithLabelValues("1.2.3", "abc1234", "t", "go")); got != 1 {
+		t.Errorf("Expected build_info to be 1, got %v", got)
+	}
+}
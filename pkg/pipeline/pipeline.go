@@ -0,0 +1,174 @@
+// Package pipeline runs analyses as named stages that clients select per request
+// Teams add stages by registering an Analyzer from an init function; the built-in stages are always registered
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/tracing"
+)
+
+// Analyzer is a named stage of the analysis pipeline
+// A stage reads the text and the outputs of the stages it requires; its own output is returned to
+// clients keyed by its name, so it must encode as JSON
+type Analyzer interface {
+	Name() string
+	// Requires lists the stages whose outputs Analyze reads; they always run first
+	Requires() []string
+	Analyze(ctx context.Context, input *Input) (interface{}, error)
+}
+
+// Input is what a stage analyzes: the text and the outputs of the stages run before it
+type Input struct {
+	Text    string
+	outputs map[string]interface{}
+}
+
+// Output returns the output of a stage that has run, nil for any other
+// A stage can rely on the outputs of the stages it requires
+func (in *Input) Output(name string) interface{} {
+	return in.outputs[name]
+}
+
+// Func returns an Analyzer named name that requires the given stages and runs fn
+func Func(name string, requires []string, fn func(ctx context.Context, input *Input) (interface{}, error)) Analyzer {
+	return funcAnalyzer{name: name, requires: requires, fn: fn}
+}
+
+type funcAnalyzer struct {
+	name     string
+	requires []string
+	fn       func(ctx context.Context, input *Input) (interface{}, error)
+}
+
+func (a funcAnalyzer) Name() string       { return a.name }
+func (a funcAnalyzer) Requires() []string { return a.requires }
+
+func (a funcAnalyzer) Analyze(ctx context.Context, input *Input) (interface{}, error) {
+	return a.fn(ctx, input)
+}
+
+// stageName matches stage names, which are also used as response field and XML element names
+var stageName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Analyzer)
+)
+
+// Register adds a stage to the pipeline
+// Names are 1 to 64 lowercase letters, digits and '_', starting with a letter, and must be unique;
+// the stages a stage requires must be registered before it, so the pipeline cannot have cycles
+func Register(a Analyzer) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := a.Name()
+	if !stageName.MatchString(name) {
+		return fmt.Errorf("invalid stage name %q", name)
+	}
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("stage %q is already registered", name)
+	}
+	for _, required := range a.Requires() {
+		if _, ok := registry[required]; !ok {
+			return fmt.Errorf("stage %q requires unregistered stage %q", name, required)
+		}
+	}
+	registry[name] = a
+	return nil
+}
+
+// MustRegister is Register for stages registered from init functions; it panics on error
+func MustRegister(a Analyzer) {
+	if err := Register(a); err != nil {
+		panic(err)
+	}
+}
+
+// Stages returns the names of the registered stages in alphabetical order
+func Stages() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrUnknownStage is returned when a requested stage is not registered
+var ErrUnknownStage = errors.New("unknown analysis stage")
+
+// Run runs the named stages, and the stages they require, on a text and returns the outputs of
+// the named stages keyed by name
+// Each stage runs once, after the stages it requires, in a span named after it
+func Run(ctx context.Context, text string, names []string) (map[string]interface{}, error) {
+	stages, err := plan(names)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &Input{Text: text, outputs: make(map[string]interface{}, len(stages))}
+	for _, stage := range stages {
+		stageCtx, span := tracing.Start(ctx, "analyzer."+stage.Name())
+		output, err := stage.Analyze(stageCtx, input)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			return nil, fmt.Errorf("stage %s: %w", stage.Name(), err)
+		}
+		span.End()
+		input.outputs[stage.Name()] = output
+	}
+
+	outputs := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		outputs[name] = input.outputs[name]
+	}
+	return outputs, nil
+}
+
+// plan orders the named stages and the stages they require so that each comes after its requirements
+func plan(names []string) ([]Analyzer, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var stages []Analyzer
+	planned := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if planned[name] {
+			return nil
+		}
+		stage, ok := registry[name]
+		if !ok {
+			return fmt.Errorf("%w %q", ErrUnknownStage, name)
+		}
+		// Requirements were registered first, so this terminates
+		for _, required := range stage.Requires() {
+			if err := visit(required); err != nil {
+				return err
+			}
+		}
+		planned[name] = true
+		stages = append(stages, stage)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return stages, nil
+}
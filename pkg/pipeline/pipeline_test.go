@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// restoreRegistry returns a function that unregisters the stages registered by a test
+func restoreRegistry() func() {
+	registryMu.Lock()
+	original := make(map[string]Analyzer, len(registry))
+	for name, stage := range registry {
+		original[name] = stage
+	}
+	registryMu.Unlock()
+
+	return func() {
+		registryMu.Lock()
+		registry = original
+		registryMu.Unlock()
+	}
+}
+
+// constant returns a stage that outputs its name and records when it ran
+func constant(name string, requires []string, ran *[]string) Analyzer {
+	return Func(name, requires, func(ctx context.Context, input *Input) (interface{}, error) {
+		*ran = append(*ran, name)
+		return name, nil
+	})
+}
+
+func TestRegister(t *testing.T) {
+	defer restoreRegistry()()
+
+	var ran []string
+	tests := []struct {
+		name    string
+		stage   Analyzer
+		wantErr string
+	}{
+		{name: "valid", stage: constant("custom_1", nil, &ran)},
+		{name: "requires registered stage", stage: constant("custom_2", []string{"custom_1", StageCount}, &ran)},
+		{name: "duplicate", stage: constant(StageCount, nil, &ran), wantErr: "already registered"},
+		{name: "upper case", stage: constant("Custom", nil, &ran), wantErr: "invalid stage name"},
+		{name: "leading digit", stage: constant("1custom", nil, &ran), wantErr: "invalid stage name"},
+		{name: "empty", stage: constant("", nil, &ran), wantErr: "invalid stage name"},
+		{name: "too long", stage: constant(strings.Repeat("a", 65), nil, &ran), wantErr: "invalid stage name"},
+		{name: "unregistered requirement", stage: constant("custom_3", []string{"missing"}, &ran), wantErr: "unregistered stage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.stage)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Register() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Register() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	want := []string{StageClassifyCharacters, StageCount, "custom_1", "custom_2", StageMetrics, StageTokenize}
+	if got := Stages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stages() = %v, want %v", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	defer restoreRegistry()()
+
+	var ran []string
+	MustRegister(constant("first", nil, &ran))
+	MustRegister(constant("second", []string{"first"}, &ran))
+	MustRegister(constant("third", []string{"first", "second"}, &ran))
+
+	outputs, err := Run(context.Background(), "text", []string{"third", "first"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// Each stage runs once, after the stages it requires
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected stages to run in order %v, got %v", want, ran)
+	}
+	// Only the requested outputs are returned
+	if want := map[string]interface{}{"first": "first", "third": "third"}; !reflect.DeepEqual(outputs, want) {
+		t.Errorf("Run() = %v, want %v", outputs, want)
+	}
+
+	if _, err := Run(context.Background(), "text", []string{"first", "missing"}); !errors.Is(err, ErrUnknownStage) {
+		t.Errorf("Run() with an unknown stage error = %v, want ErrUnknownStage", err)
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	defer restoreRegistry()()
+
+	// A plugin reads the outputs of the stages it requires
+	MustRegister(Func("long_words", []string{StageTokenize}, func(ctx context.Context, input *Input) (interface{}, error) {
+		long := 0
+		for _, word := range input.Output(StageTokenize).(Tokens).Words {
+			if len(word) > 5 {
+				long++
+			}
+		}
+		return long, nil
+	}))
+	outputs, err := Run(context.Background(), "Plugins extend the analyzer", []string{"long_words"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := outputs["long_words"]; got != 3 {
+		t.Errorf("long_words = %v, want 3", got)
+	}
+
+	failure := errors.New("plugin failed")
+	MustRegister(Func("failing", nil, func(ctx context.Context, input *Input) (interface{}, error) {
+		return nil, failure
+	}))
+	if _, err := Run(context.Background(), "text", []string{"failing"}); !errors.Is(err, failure) || !strings.Contains(err.Error(), "failing") {
+		t.Errorf("Run() error = %v, want the stage's error naming the stage", err)
+	}
+}
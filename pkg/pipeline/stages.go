@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hc12r/sentence-analyzer-vm/internal/analyzer"
+)
+
+// Built-in stage names
+const (
+	StageTokenize           = "tokenize"
+	StageClassifyCharacters = "classify_characters"
+	StageCount              = "count"
+	StageMetrics            = "metrics"
+)
+
+// The outputs of the built-in stages are returned to clients as they are
+
+// Tokens is the output of the tokenize stage: the whitespace-separated words of the text, in order
+type Tokens struct {
+	Words []string `json:"words"`
+}
+
+// CharacterClasses is the output of the classify_characters stage
+// Only ASCII letters are vowels or consonants; every other character, spaces included, is counted as other
+type CharacterClasses struct {
+	Vowels     int `json:"vowels"`
+	Consonants int `json:"consonants"`
+	Other      int `json:"other"`
+}
+
+// Counts is the output of the count stage
+type Counts struct {
+	WordCount      int `json:"word_count"`
+	VowelCount     int `json:"vowel_count"`
+	ConsonantCount int `json:"consonant_count"`
+	CharacterCount int `json:"character_count"`
+	SentenceCount  int `json:"sentence_count"`
+}
+
+// Metrics is the output of the metrics stage, derived from the counts
+// Each ratio is 0 for a text without the words or letters it divides by
+type Metrics struct {
+	// AverageWordLength is the mean number of letters per word
+	AverageWordLength float64 `json:"average_word_length"`
+	// VowelRatio is the share of vowels among vowels and consonants
+	VowelRatio float64 `json:"vowel_ratio"`
+	// LexicalDiversity is the number of distinct words per word, compared as in domain.WordFrequencies
+	LexicalDiversity float64 `json:"lexical_diversity"`
+	// ReadingEase is the Flesch reading ease, with syllables estimated for English
+	ReadingEase float64 `json:"reading_ease"`
+}
+
+func init() {
+	MustRegister(Func(StageTokenize, nil, tokenize))
+	MustRegister(Func(StageClassifyCharacters, nil, classifyCharacters))
+	MustRegister(Func(StageCount, []string{StageTokenize, StageClassifyCharacters}, count))
+	MustRegister(Func(StageMetrics, []string{StageTokenize, StageCount}, metrics))
+}
+
+// tokenize splits the text into words at whitespace
+func tokenize(ctx context.Context, input *Input) (interface{}, error) {
+	words := strings.Fields(input.Text)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("analyzer.word_count", len(words)))
+	return Tokens{Words: words}, nil
+}
+
+// classifyCharacters counts the vowels, consonants and other characters of the text, ignoring case
+func classifyCharacters(ctx context.Context, input *Input) (interface{}, error) {
+	var classes CharacterClasses
+	classes.Vowels, classes.Consonants, classes.Other = analyzer.ClassifyCharacters(input.Text)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("analyzer.vowel_count", classes.Vowels),
+		attribute.Int("analyzer.consonant_count", classes.Consonants),
+	)
+	return classes, nil
+}
+
+// count gathers the word, letter, character and sentence counts of the text
+func count(ctx context.Context, input *Input) (interface{}, error) {
+	tokens := input.Output(StageTokenize).(Tokens)
+	classes := input.Output(StageClassifyCharacters).(CharacterClasses)
+	return Counts{
+		WordCount:      len(tokens.Words),
+		VowelCount:     classes.Vowels,
+		ConsonantCount: classes.Consonants,
+		CharacterCount: utf8.RuneCountInString(input.Text),
+		SentenceCount:  len(analyzer.SplitSentences(ctx, input.Text)),
+	}, nil
+}
+
+// metrics derives word length, vowel ratio, lexical diversity and reading ease from the words and counts
+func metrics(ctx context.Context, input *Input) (interface{}, error) {
+	tokens := input.Output(StageTokenize).(Tokens)
+	counts := input.Output(StageCount).(Counts)
+
+	letters, syllables := 0, 0
+	distinct := make(map[string]bool)
+	for _, word := range tokens.Words {
+		for _, char := range word {
+			if unicode.IsLetter(char) {
+				letters++
+			}
+		}
+		syllables += analyzer.Syllables(word)
+		if normalized := analyzer.NormalizeWord(word); normalized != "" {
+			distinct[normalized] = true
+		}
+	}
+
+	result := Metrics{
+		ReadingEase: analyzer.ReadingEase(counts.WordCount, counts.SentenceCount, syllables),
+	}
+	if counts.WordCount > 0 {
+		result.AverageWordLength = float64(letters) / float64(counts.WordCount)
+		result.LexicalDiversity = float64(len(distinct)) / float64(counts.WordCount)
+	}
+	if classified := counts.VowelCount + counts.ConsonantCount; classified > 0 {
+		result.VowelRatio = float64(counts.VowelCount) / float64(classified)
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/hc12r/sentence-analyzer-vm/internal/analyzer"
+)
+
+func TestBuiltinStages(t *testing.T) {
+	outputs, err := Run(context.Background(), "The cat sat. The cat ran!", []string{StageTokenize, StageClassifyCharacters, StageCount})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := (Tokens{Words: []string{"The", "cat", "sat.", "The", "cat", "ran!"}}); !reflect.DeepEqual(outputs[StageTokenize], want) {
+		t.Errorf("tokenize = %+v, want %+v", outputs[StageTokenize], want)
+	}
+	if want := (CharacterClasses{Vowels: 6, Consonants: 12, Other: 7}); outputs[StageClassifyCharacters] != want {
+		t.Errorf("classify_characters = %+v, want %+v", outputs[StageClassifyCharacters], want)
+	}
+	want := Counts{WordCount: 6, VowelCount: 6, ConsonantCount: 12, CharacterCount: 25, SentenceCount: 2}
+	if outputs[StageCount] != want {
+		t.Errorf("count = %+v, want %+v", outputs[StageCount], want)
+	}
+}
+
+func TestMetricsStage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Metrics
+	}{
+		{
+			name: "repeated words",
+			text: "The cat sat. The cat ran!",
+			want: Metrics{
+				AverageWordLength: 18.0 / 6,
+				VowelRatio:        6.0 / 18,
+				LexicalDiversity:  4.0 / 6,
+				ReadingEase:       analyzer.ReadingEase(6, 2, 6),
+			},
+		},
+		{name: "empty", text: "", want: Metrics{}},
+		{name: "no letters", text: "42 !", want: Metrics{ReadingEase: analyzer.ReadingEase(2, 1, 0), LexicalDiversity: 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := Run(context.Background(), tt.text, []string{StageMetrics})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			got := outputs[StageMetrics].(Metrics)
+			for _, field := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"average_word_length", got.AverageWordLength, tt.want.AverageWordLength},
+				{"vowel_ratio", got.VowelRatio, tt.want.VowelRatio},
+				{"lexical_diversity", got.LexicalDiversity, tt.want.LexicalDiversity},
+				{"reading_ease", got.ReadingEase, tt.want.ReadingEase},
+			} {
+				if math.Abs(field.got-field.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
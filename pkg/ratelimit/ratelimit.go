@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a client has used up its daily character quota
+var ErrQuotaExceeded = errors.New("daily character quota exceeded")
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket capacity
+	Limit int
+	// Remaining is the number of requests that can be made right now
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed when Allowed is false
+	RetryAfter time.Duration
+}
+
+// bucket is a token bucket refilled continuously at rate tokens per second
+type bucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64
+	updated  time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.updated = now
+	}
+}
+
+// usage tracks characters analyzed on a single UTC day
+type usage struct {
+	day  string
+	used int
+}
+
+// Limiter enforces per-client request rates and daily character quotas
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	usage   map[string]*usage
+	checks  int
+	now     func() time.Time
+}
+
+// NewLimiter creates an empty Limiter
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		usage:   make(map[string]*usage),
+		now:     time.Now,
+	}
+}
+
+// Allow takes one token from the bucket for key
+// requestsPerMinute sets the refill rate and burst the bucket capacity
+func (l *Limiter) Allow(key string, requestsPerMinute, burst int) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	rate := float64(requestsPerMinute) / 60
+	capacity := float64(burst)
+
+	b, ok := l.buckets[key]
+	if !ok || b.rate != rate || b.capacity != capacity {
+		// New client or changed limits: start with a full bucket
+		b = &bucket{tokens: capacity, capacity: capacity, rate: rate, updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	// Drop idle buckets now and then; a full bucket carries no state
+	l.checks++
+	if l.checks%1000 == 0 {
+		l.prune(now)
+	}
+
+	decision := Decision{Limit: burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = seconds((b.capacity - b.tokens) / rate)
+
+	return decision
+}
+
+// Charge adds n characters to the daily usage for key
+// It returns ErrQuotaExceeded, without charging, when that would exceed limit
+// A limit of 0 means unlimited
+func (l *Limiter) Charge(key string, n, limit int) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UTC()
+	day := now.Format("2006-01-02")
+
+	u, ok := l.usage[key]
+	if !ok || u.day != day {
+		u = &usage{day: day}
+		l.usage[key] = u
+	}
+
+	if limit > 0 && u.used+n > limit {
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return midnight.Sub(now), ErrQuotaExceeded
+	}
+
+	u.used += n
+	return 0, nil
+}
+
+// Refund takes n characters off today's usage for key, for work that failed after it was charged
+func (l *Limiter) Refund(key string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.usage[key]
+	if !ok || u.day != l.now().UTC().Format("2006-01-02") {
+		return
+	}
+	u.used = max(u.used-n, 0)
+}
+
+// Used returns the characters charged to key today
+func (l *Limiter) Used(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.usage[key]
+	if !ok || u.day != l.now().UTC().Format("2006-01-02") {
+		return 0
+	}
+	return u.used
+}
+
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	for key, u := range l.usage {
+		if u.day != day {
+			delete(l.usage, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Quota charges characters against the daily quota of the client making a request
+type Quota struct {
+	limiter *Limiter
+	key     string
+	limit   int
+}
+
+// NewQuota creates a Quota for the client identified by key
+func NewQuota(limiter *Limiter, key string, limit int) *Quota {
+	return &Quota{limiter: limiter, key: key, limit: limit}
+}
+
+// Charge adds n characters to the client's daily usage
+func (q *Quota) Charge(n int) (time.Duration, error) {
+	return q.limiter.Charge(q.key, n, q.limit)
+}
+
+// Refund gives n characters back to the client's daily usage
+func (q *Quota) Refund(n int) {
+	q.limiter.Refund(q.key, n)
+}
+
+// Context key type to avoid collisions
+type contextKey string
+
+// quotaKey is the key used to store the Quota in the context
+const quotaKey contextKey = "quota"
+
+// WithQuota adds the client's Quota to the context
+func WithQuota(ctx context.Context, quota *Quota) context.Context {
+	return context.WithValue(ctx, quotaKey, quota)
+}
+
+// ChargeCharacters charges n characters to the quota stored in the context
+// Requests without a quota in the context are not limited
+func ChargeCharacters(ctx context.Context, n int) (time.Duration, error) {
+	quota, ok := ctx.Value(quotaKey).(*Quota)
+	if !ok {
+		return 0, nil
+	}
+	return quota.Charge(n)
+}
+
+// RefundCharacters gives back n characters charged to the quota stored in the context,
+// for an analysis that failed after it was charged
+func RefundCharacters(ctx context.Context, n int) {
+	if quota, ok := ctx.Value(quotaKey).(*Quota); ok {
+		quota.Refund(n)
+	}
+}
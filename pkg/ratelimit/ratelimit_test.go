@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestLimiter creates a Limiter whose clock is controlled by the returned pointer
+func newTestLimiter() (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestAllowTokenBucket(t *testing.T) {
+	limiter, now := newTestLimiter()
+
+	// A burst of 3 is allowed, the 4th request is rejected
+	for i := 0; i < 3; i++ {
+		decision := limiter.Allow("client", 60, 3)
+		if !decision.Allowed {
+			t.Fatalf("request %d: expected to be allowed", i+1)
+		}
+		if decision.Remaining != 2-i {
+			t.Errorf("request %d: expected Remaining %d, got %d", i+1, 2-i, decision.Remaining)
+		}
+	}
+
+	decision := limiter.Allow("client", 60, 3)
+	if decision.Allowed {
+		t.Fatal("Expected the request over the burst to be rejected")
+	}
+	if decision.RetryAfter != time.Second {
+		t.Errorf("Expected RetryAfter of 1s at 60 rpm, got %v", decision.RetryAfter)
+	}
+
+	// One token is refilled per second at 60 requests per minute
+	*now = now.Add(time.Second)
+	if decision := limiter.Allow("client", 60, 3); !decision.Allowed {
+		t.Error("Expected the request to be allowed after a refill")
+	}
+
+	// Other clients have their own bucket
+	if decision := limiter.Allow("other", 60, 3); !decision.Allowed {
+		t.Error("Expected another client to be allowed")
+	}
+}
+
+func TestChargeDailyQuota(t *testing.T) {
+	limiter, now := newTestLimiter()
+
+	if _, err := limiter.Charge("client", 60, 100); err != nil {
+		t.Fatalf("Expected charge to succeed, got %v", err)
+	}
+
+	retryAfter, err := limiter.Charge("client", 50, 100)
+	if err != ErrQuotaExceeded {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if retryAfter != 12*time.Hour {
+		t.Errorf("Expected RetryAfter until midnight UTC, got %v", retryAfter)
+	}
+	if used := limiter.Used("client"); used != 60 {
+		t.Errorf("Expected rejected charges not to count, got %d used", used)
+	}
+
+	// The quota resets on the next UTC day
+	*now = now.Add(13 * time.Hour)
+	if _, err := limiter.Charge("client", 50, 100); err != nil {
+		t.Errorf("Expected charge to succeed on the next day, got %v", err)
+	}
+}
+
+func TestChargeUnlimited(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	if _, err := limiter.Charge("client", 1000000, 0); err != nil {
+		t.Errorf("Expected a zero limit to be unlimited, got %v", err)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	if _, err := limiter.Charge("client", 60, 100); err != nil {
+		t.Fatalf("Expected charge to succeed, got %v", err)
+	}
+	limiter.Refund("client", 40)
+	if used := limiter.Used("client"); used != 20 {
+		t.Errorf("Expected 20 used after the refund, got %d", used)
+	}
+	limiter.Refund("client", 40)
+	if used := limiter.Used("client"); used != 0 {
+		t.Errorf("Expected usage never to drop below 0, got %d", used)
+	}
+}
+
+func TestChargeCharactersContext(t *testing.T) {
+	limiter, _ := newTestLimiter()
+
+	// Without a quota in the context nothing is limited
+	if _, err := ChargeCharacters(context.Background(), 1000); err != nil {
+		t.Errorf("Expected no error without a quota, got %v", err)
+	}
+
+	ctx := WithQuota(context.Background(), NewQuota(limiter, "client", 10))
+	if _, err := ChargeCharacters(ctx, 10); err != nil {
+		t.Errorf("Expected charge within quota to succeed, got %v", err)
+	}
+	if _, err := ChargeCharacters(ctx, 1); err != ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// A refunded analysis frees its characters again
+	RefundCharacters(ctx, 5)
+	if _, err := ChargeCharacters(ctx, 5); err != nil {
+		t.Errorf("Expected refunded characters to be charged again, got %v", err)
+	}
+	RefundCharacters(context.Background(), 5)
+}
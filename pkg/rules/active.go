@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// active is the Set applied to every analysis
+var active atomic.Pointer[Set]
+
+// SetActive replaces the rules applied to every analysis; nil removes them
+func SetActive(s *Set) {
+	active.Store(s)
+}
+
+// Active returns the rules applied to every analysis, nil when there are none
+func Active() *Set {
+	return active.Load()
+}
+
+// Reloader loads a rules file into the active rules whenever it changes
+type Reloader struct {
+	path string
+
+	mu   sync.Mutex
+	last []byte
+}
+
+// NewReloader returns a Reloader of the rules file at path; nothing is loaded until Reload is called
+func NewReloader(path string) *Reloader {
+	return &Reloader{path: path}
+}
+
+// Reload reads the rules file and, when its contents changed since the last successful load,
+// compiles it and makes it the active rules
+// Invalid rules are reported and the active rules are kept, so a bad edit cannot take counting down
+func (r *Reloader) Reload() (changed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("reading rules: %w", err)
+	}
+	if r.last != nil && bytes.Equal(data, r.last) {
+		return false, nil
+	}
+
+	set, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+	SetActive(set)
+	r.last = data
+	return true, nil
+}
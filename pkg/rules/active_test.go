@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReloader(t *testing.T) {
+	original := Active()
+	defer SetActive(original)
+	SetActive(nil)
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	reloader := NewReloader(path)
+	if _, err := reloader.Reload(); err == nil {
+		t.Fatal("Expected an error for a missing rules file")
+	}
+
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("Failed to write rules: %v", err)
+		}
+	}
+	write("rules:\n  - name: hashtags\n    pattern: '#\\w+'\n")
+	if changed, err := reloader.Reload(); err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v, want true, nil", changed, err)
+	}
+	if got, want := Active().Count("#go #rules"), map[string]int{"hashtags": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Active().Count() = %v, want %v", got, want)
+	}
+
+	// An unchanged file is not compiled again
+	if changed, err := reloader.Reload(); err != nil || changed {
+		t.Errorf("Reload() of an unchanged file = %v, %v, want false, nil", changed, err)
+	}
+
+	// Invalid rules keep the active rules
+	write("rules:\n  - name: hashtags\n    pattern: '#('\n")
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("Expected an error for an invalid rule")
+	}
+	if got := Active().Count("#go")["hashtags"]; got != 1 {
+		t.Errorf("Expected the previous rules to stay active, got %v", Active().Count("#go"))
+	}
+
+	write("rules:\n  - name: mentions\n    pattern: '@\\w+'\n")
+	if changed, err := reloader.Reload(); err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v, want true, nil", changed, err)
+	}
+	if got, want := Active().Count("#go @ana"), map[string]int{"mentions": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Active().Count() after reload = %v, want %v", got, want)
+	}
+}
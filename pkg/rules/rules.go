@@ -0,0 +1,190 @@
+// Package rules counts matches of operator-defined rules, such as hashtags or currency amounts, in analyzed text
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule counts the matches of one pattern, character set or word list in a text
+// Exactly one of Pattern, Characters and Words is set
+type Rule struct {
+	// Name is the key of the count in responses
+	Name string `yaml:"name"`
+	// Pattern is a regular expression in RE2 syntax; its non-overlapping matches are counted
+	Pattern string `yaml:"pattern,omitempty"`
+	// Characters is a set of characters; each occurrence of one of them is counted
+	Characters string `yaml:"characters,omitempty"`
+	// Words is a list of words; each word of the text that is in the list is counted
+	Words []string `yaml:"words,omitempty"`
+	// IgnoreCase matches regardless of case
+	IgnoreCase bool `yaml:"ignore_case,omitempty"`
+}
+
+// File is the format of a rules file
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// ruleName matches rule names, which are also used as response field and XML element names
+var ruleName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Set is a compiled list of rules
+// A nil Set has no rules
+type Set struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	name  string
+	count func(text string) int
+}
+
+// Compile validates and compiles rules into a Set
+// Every invalid rule is reported, each error naming the rule by position and name
+func Compile(rules []Rule) (*Set, error) {
+	var errs []error
+	set := &Set{rules: make([]compiledRule, 0, len(rules))}
+	seen := make(map[string]bool, len(rules))
+
+	for i, rule := range rules {
+		count, err := compile(rule)
+		switch {
+		case !ruleName.MatchString(rule.Name):
+			err = errors.New("name must be 1 to 64 lowercase letters, digits and '_', starting with a letter")
+		case seen[rule.Name]:
+			err = errors.New("name is already used")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%q): %w", i, rule.Name, err))
+			continue
+		}
+		seen[rule.Name] = true
+		set.rules = append(set.rules, compiledRule{name: rule.Name, count: count})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return set, nil
+}
+
+// compile returns the counting function of a rule
+func compile(rule Rule) (func(string) int, error) {
+	kinds := 0
+	for _, set := range []bool{rule.Pattern != "", rule.Characters != "", len(rule.Words) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, errors.New("exactly one of pattern, characters and words is required")
+	}
+
+	switch {
+	case rule.Pattern != "":
+		pattern := rule.Pattern
+		if rule.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		// A pattern matching nothing would be counted between every character
+		if re.MatchString("") {
+			return nil, errors.New("pattern must not match the empty string")
+		}
+		return func(text string) int {
+			return len(re.FindAllStringIndex(text, -1))
+		}, nil
+
+	case rule.Characters != "":
+		characters := make(map[rune]bool)
+		for _, char := range fold(rule.Characters, rule.IgnoreCase) {
+			characters[char] = true
+		}
+		return func(text string) int {
+			count := 0
+			for _, char := range fold(text, rule.IgnoreCase) {
+				if characters[char] {
+					count++
+				}
+			}
+			return count
+		}, nil
+
+	default:
+		words := make(map[string]bool, len(rule.Words))
+		for i, word := range rule.Words {
+			if fields := splitWords(word); len(fields) != 1 || fields[0] != word {
+				return nil, fmt.Errorf("words[%d] must be a single word of letters, digits and apostrophes", i)
+			}
+			words[fold(word, rule.IgnoreCase)] = true
+		}
+		return func(text string) int {
+			count := 0
+			for _, word := range splitWords(fold(text, rule.IgnoreCase)) {
+				if words[word] {
+					count++
+				}
+			}
+			return count
+		}, nil
+	}
+}
+
+// fold lower cases s when ignoreCase is set
+func fold(s string, ignoreCase bool) string {
+	if ignoreCase {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// splitWords splits a text into runs of letters, digits and apostrophes
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '\''
+	})
+}
+
+// Parse decodes and compiles a rules file in YAML, or JSON, which YAML includes
+// Unknown fields are errors, so a misspelled option is not silently ignored
+func Parse(data []byte) (*Set, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding rules: %w", err)
+	}
+	return Compile(file.Rules)
+}
+
+// Len returns the number of rules
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Count counts the matches of each rule in a text, keyed by rule name
+// Every rule is included, even without matches; the result is nil when there are no rules
+func (s *Set) Count(text string) map[string]int {
+	if s.Len() == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(s.rules))
+	for _, rule := range s.rules {
+		counts[rule.name] = rule.count(text)
+	}
+	return counts
+}
@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetCount(t *testing.T) {
+	set, err := Compile([]Rule{
+		{Name: "hashtags", Pattern: `#\w+`},
+		{Name: "mentions", Pattern: `@\w+`},
+		{Name: "currency_amounts", Pattern: `[$€£]\d+(?:\.\d{2})?`},
+		{Name: "exclamations", Characters: "!"},
+		{Name: "greek_vowels", Characters: "αε", IgnoreCase: true},
+		{Name: "profanity_hits", Words: []string{"darn", "heck"}, IgnoreCase: true},
+		{Name: "brand", Pattern: "acme", IgnoreCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	got := set.Count("Darn! @ana paid $12.50 for #acme #deals at ACME, heck... darned Αε!")
+	want := map[string]int{
+		"hashtags":         2,
+		"mentions":         1,
+		"currency_amounts": 1,
+		"exclamations":     2,
+		"greek_vowels":     2,
+		"profanity_hits":   2,
+		"brand":            2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Count() = %v, want %v", got, want)
+	}
+
+	// Every rule is reported, even without matches
+	if got := set.Count("")["hashtags"]; got != 0 {
+		t.Errorf("Count() of an empty text = %d, want 0", got)
+	}
+}
+
+func TestSetCountWithoutRules(t *testing.T) {
+	var set *Set
+	if got := set.Count("#go"); got != nil {
+		t.Errorf("Count() of a nil Set = %v, want nil", got)
+	}
+	empty, err := Compile(nil)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if got := empty.Count("#go"); got != nil {
+		t.Errorf("Count() without rules = %v, want nil", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr string
+	}{
+		{name: "invalid name", rules: []Rule{{Name: "Hash Tags", Pattern: "#"}}, wantErr: `rule 0 ("Hash Tags"): name must be`},
+		{name: "duplicate name", rules: []Rule{{Name: "tags", Pattern: "#"}, {Name: "tags", Characters: "#"}}, wantErr: `rule 1 ("tags"): name is already used`},
+		{name: "no matcher", rules: []Rule{{Name: "tags"}}, wantErr: "exactly one of pattern, characters and words is required"},
+		{name: "two matchers", rules: []Rule{{Name: "tags", Pattern: "#", Characters: "#"}}, wantErr: "exactly one of"},
+		{name: "invalid pattern", rules: []Rule{{Name: "tags", Pattern: "#("}}, wantErr: "invalid pattern"},
+		{name: "empty match", rules: []Rule{{Name: "tags", Pattern: "#*"}}, wantErr: "must not match the empty string"},
+		{name: "phrase in words", rules: []Rule{{Name: "words", Words: []string{"ok", "no way"}}}, wantErr: "words[1] must be a single word"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.rules); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Every invalid rule is reported at once
+	_, err := Compile([]Rule{{Name: "a"}, {Name: "b", Pattern: "b"}, {Name: "c", Pattern: "("}})
+	if err == nil || !strings.Contains(err.Error(), `rule 0 ("a")`) || !strings.Contains(err.Error(), `rule 2 ("c")`) {
+		t.Errorf("Compile() error = %v, want errors for rules 0 and 2", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantRules int
+		wantErr   string
+	}{
+		{name: "yaml", data: "rules:\n  - name: hashtags\n    pattern: '#\\w+'\n  - name: shouts\n    characters: '!'\n", wantRules: 2},
+		{name: "json", data: `{"rules": [{"name": "swears", "words": ["darn"], "ignore_case": true}]}`, wantRules: 1},
+		{name: "empty", data: "", wantRules: 0},
+		{name: "unknown field", data: "rules:\n  - name: tags\n    regex: '#'\n", wantErr: "field regex not found"},
+		{name: "invalid rule", data: "rules:\n  - name: tags\n", wantErr: "exactly one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if set.Len() != tt.wantRules {
+				t.Errorf("Parse() rules = %d, want %d", set.Len(), tt.wantRules)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+// instrumentationName identifies the spans created by this service
+const instrumentationName = "github.com/hc12r/sentence-analyzer-vm"
+
+// Tracer returns the tracer used throughout the service
+// Until Setup is called it is a no-op tracer, so instrumented code is safe to use in tests
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// Setup installs the W3C trace context propagator and, when an endpoint is configured,
+// a tracer provider that exports spans to an OTLP/HTTP collector
+// The returned function flushes and stops the exporter
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOptions(cfg.Endpoint)...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// exporterOptions converts a collector URL such as http://otel-collector:4318 into exporter options
+func exporterOptions(endpoint string) []otlptracehttp.Option {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		// Treat a bare host:port as an insecure endpoint
+		return []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure()}
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	// Like OTEL_EXPORTER_OTLP_ENDPOINT, the URL is a base to which the traces path is appended
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(path+"/v1/traces"))
+	}
+	return opts
+}
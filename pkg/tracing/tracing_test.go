@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/hc12r/sentence-analyzer-vm/pkg/config"
+)
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{ServiceName: "test"})
+	if err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown returned error: %v", err)
+	}
+
+	// Without an endpoint spans are not recorded
+	_, span := Start(context.Background(), "noop")
+	defer span.End()
+	if span.IsRecording() {
+		t.Error("Expected a non-recording span when no endpoint is configured")
+	}
+}
+
+func TestSetupExportsToCollector(t *testing.T) {
+	original := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(original)
+
+	// Stub OTLP/HTTP collector that counts trace exports
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{
+		Endpoint:    collector.URL,
+		ServiceName: "test",
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+
+	_, span := Start(context.Background(), "exported")
+	if !span.IsRecording() {
+		t.Error("Expected a recording span when an endpoint is configured")
+	}
+	span.End()
+
+	// Shutdown flushes the batch to the collector
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown returned error: %v", err)
+	}
+	if exports.Load() == 0 {
+		t.Error("Expected spans to be exported to the collector")
+	}
+}
+
+func TestExporterOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     int
+	}{
+		{"http url", "http://otel-collector:4318", 2},
+		{"https url", "https://otel.example.com", 1},
+		{"url with base path", "http://otel-collector:4318/otlp/", 3},
+		{"bare host and port", "otel-collector:4318", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(exporterOptions(tt.endpoint)); got != tt.want {
+				t.Errorf("exporterOptions(%q) returned %d options, want %d", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
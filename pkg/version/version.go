@@ -0,0 +1,73 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// Build information, set at build time with
+//
+//	-ldflags "-X github.com/hc12r/sentence-analyzer-vm/pkg/version.Version=1.2.3 \
+//	          -X github.com/hc12r/sentence-analyzer-vm/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	          -X github.com/hc12r/sentence-analyzer-vm/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When they are not set, Commit and BuildTime fall back to the VCS information stamped by the Go toolchain
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildTime string   `json:"build_time"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features,omitempty"`
+}
+
+var (
+	featuresMu sync.RWMutex
+	features   []string
+)
+
+// SetFeatures records the feature modules enabled in this process
+func SetFeatures(enabled []string) {
+	sorted := append([]string(nil), enabled...)
+	sort.Strings(sorted)
+
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	features = sorted
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	featuresMu.RLock()
+	enabled := append([]string(nil), features...)
+	featuresMu.RUnlock()
+
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Features:  enabled,
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "unknown":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "unknown":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	return info
+}
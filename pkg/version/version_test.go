@@ -0,0 +1,32 @@
+package version
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	originalVersion, originalCommit := Version, Commit
+	defer func() {
+		Version, Commit = originalVersion, originalCommit
+		SetFeatures(nil)
+	}()
+
+	// Values injected with -ldflags take precedence
+	Version = "1.2.3"
+	Commit = "abc1234"
+	SetFeatures([]string{"tracing", "metrics"})
+
+	info := Get()
+
+	if info.Version != "1.2.3" || info.Commit != "abc1234" {
+		t.Errorf("Expected injected version and commit, got %+v", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version %s, got %s", runtime.Version(), info.GoVersion)
+	}
+	if want := []string{"metrics", "tracing"}; !reflect.DeepEqual(info.Features, want) {
+		t.Errorf("Expected sorted features %v, got %v", want, info.Features)
+	}
+}